@@ -4,21 +4,53 @@ import (
 	"context"
 	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // tz query parameters must work in minimal containers
 
+	"google.golang.org/grpc"
+
+	tasksv1 "tiny-tasks/api/tasks/v1"
+	"tiny-tasks/internal/grpcapi"
 	"tiny-tasks/internal/httpapi"
+	"tiny-tasks/internal/reminder"
+	"tiny-tasks/internal/report"
+	"tiny-tasks/internal/store/blobstore"
 	"tiny-tasks/internal/store/memorystore"
 	"tiny-tasks/internal/task"
 )
 
+const (
+	defaultSnapshotInterval = 1 * time.Minute
+	defaultGRPCAddr         = ":9090"
+)
+
 func main() {
-	repo := memorystore.NewTaskStore()
-	service := task.NewService(repo)
-	handler := httpapi.NewServer(service)
+	dataDir := os.Getenv("TINY_TASKS_DATA_DIR")
+	repo, err := openStore(dataDir)
+	if err != nil {
+		log.Fatalf("open store: %v", err)
+	}
+	blobs, err := openBlobStore(dataDir)
+	if err != nil {
+		log.Fatalf("open blob store: %v", err)
+	}
+	service := task.NewService(task.Deps{
+		Tasks:       repo,
+		Teams:       repo,
+		Comments:    repo,
+		Attachments: repo,
+		Blobs:       blobs,
+	})
+	reminders := reminder.NewScheduler(service, repo, newNotifier(), reminder.DefaultCatchUp)
+	handler := httpapi.NewServer(service, report.NewReporter(service), reminders, httpapi.WithCORS(corsConfig()))
 
 	httpServer := &http.Server{
 		Addr:              ":8080",
@@ -26,6 +58,29 @@ func main() {
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	background.Go(func() { runSnapshots(backgroundCtx, repo, snapshotInterval()) })
+	background.Go(func() { reminders.Run(backgroundCtx) })
+
+	grpcAddr := os.Getenv("TINY_TASKS_GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = defaultGRPCAddr
+	}
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("grpc listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(grpcapi.UnaryIdentity), grpc.StreamInterceptor(grpcapi.StreamIdentity))
+	tasksv1.RegisterTaskServiceServer(grpcServer, grpcapi.NewServer(service))
+
+	go func() {
+		log.Printf("grpc listening on %s", grpcListener.Addr())
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatalf("grpc server error: %v", err)
+		}
+	}()
+
 	go func() {
 		log.Printf("listening on %s", httpServer.Addr)
 		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -45,5 +100,134 @@ func main() {
 	if err := httpServer.Shutdown(ctx); err != nil {
 		log.Printf("shutdown error: %v", err)
 	}
+	stopGRPC(ctx, grpcServer)
+
+	stopBackground()
+	background.Wait()
+	if err := repo.Snapshot(); err != nil {
+		log.Printf("final snapshot error: %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		log.Printf("close store error: %v", err)
+	}
 	log.Printf("bye")
 }
+
+// stopGRPC waits for in-flight RPCs until ctx expires, then cuts off the rest
+// (WatchTasks streams never finish on their own).
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
+
+// newNotifier picks the reminder channel from TINY_TASKS_NOTIFIER: log
+// (default), webhook or smtp.
+func newNotifier() reminder.Notifier {
+	switch kind := os.Getenv("TINY_TASKS_NOTIFIER"); kind {
+	case "", "log":
+		return reminder.LogNotifier{}
+	case "webhook":
+		url := os.Getenv("TINY_TASKS_NOTIFY_WEBHOOK_URL")
+		if url == "" {
+			log.Fatal("TINY_TASKS_NOTIFY_WEBHOOK_URL is required for the webhook notifier")
+		}
+		return reminder.WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+	case "smtp":
+		addr := os.Getenv("TINY_TASKS_SMTP_ADDR")
+		if addr == "" {
+			addr = "localhost:1025"
+		}
+		from := os.Getenv("TINY_TASKS_SMTP_FROM")
+		if from == "" {
+			from = "tiny-tasks@localhost"
+		}
+		to := os.Getenv("TINY_TASKS_SMTP_TO")
+		if to == "" {
+			log.Fatal("TINY_TASKS_SMTP_TO is required for the smtp notifier")
+		}
+		return reminder.SMTPNotifier{Addr: addr, From: from, To: strings.Split(to, ",")}
+	default:
+		log.Fatalf("unknown TINY_TASKS_NOTIFIER %q", kind)
+		return nil
+	}
+}
+
+// corsConfig reads comma-separated lists from TINY_TASKS_CORS_ORIGINS
+// (CORS stays off when empty), TINY_TASKS_CORS_METHODS and
+// TINY_TASKS_CORS_HEADERS.
+func corsConfig() httpapi.CORSConfig {
+	return httpapi.CORSConfig{
+		AllowedOrigins: splitList(os.Getenv("TINY_TASKS_CORS_ORIGINS")),
+		AllowedMethods: splitList(os.Getenv("TINY_TASKS_CORS_METHODS")),
+		AllowedHeaders: splitList(os.Getenv("TINY_TASKS_CORS_HEADERS")),
+	}
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// openStore keeps everything in memory when dir is empty.
+func openStore(dir string) (*memorystore.TaskStore, error) {
+	if dir == "" {
+		return memorystore.NewTaskStore(), nil
+	}
+	log.Printf("loading tasks from %s", dir)
+	return memorystore.Open(dir)
+}
+
+// openBlobStore keeps attachments in TINY_TASKS_BLOB_DIR, defaulting to a
+// blobs directory inside the data dir, and in memory when neither is set.
+func openBlobStore(dataDir string) (task.BlobStore, error) {
+	dir := os.Getenv("TINY_TASKS_BLOB_DIR")
+	if dir == "" && dataDir != "" {
+		dir = filepath.Join(dataDir, "blobs")
+	}
+	if dir == "" {
+		return memorystore.NewBlobStore(), nil
+	}
+	return blobstore.NewLocal(dir)
+}
+
+func snapshotInterval() time.Duration {
+	v := os.Getenv("TINY_TASKS_SNAPSHOT_INTERVAL")
+	if v == "" {
+		return defaultSnapshotInterval
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("invalid TINY_TASKS_SNAPSHOT_INTERVAL %q, using %s", v, defaultSnapshotInterval)
+		return defaultSnapshotInterval
+	}
+	return d
+}
+
+func runSnapshots(ctx context.Context, repo *memorystore.TaskStore, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := repo.Snapshot(); err != nil {
+				log.Printf("snapshot error: %v", err)
+			}
+		}
+	}
+}
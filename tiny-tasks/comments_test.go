@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+func decodeComment(t *testing.T, data []byte) model.Comment {
+	t.Helper()
+	var c model.Comment
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("unmarshal comment: %v; body=%s", err, string(data))
+	}
+	return c
+}
+
+func TestComments_CRUD(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Plan offsite", "team_id": team.ID})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create task: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	commentsURL := ts.URL + "/tasks/" + decodeTask(t, data).ID + "/comments"
+
+	resp, data = doAs(t, "carol", http.MethodPost, commentsURL, map[string]any{"body": "  Lisbon?  "})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("viewer comment: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	first := decodeComment(t, data)
+	if first.Author != "carol" || first.Body != "Lisbon?" {
+		t.Fatalf("unexpected comment %+v", first)
+	}
+
+	resp, data = doAs(t, "bob", http.MethodPost, commentsURL, map[string]any{"body": "Porto"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("member comment: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	second := decodeComment(t, data)
+
+	cases := []struct {
+		name   string
+		user   string
+		method string
+		url    string
+		body   any
+		want   int
+	}{
+		{"outsider cannot list", "dave", http.MethodGet, commentsURL, nil, http.StatusForbidden},
+		{"outsider cannot comment", "dave", http.MethodPost, commentsURL, map[string]any{"body": "hi"}, http.StatusForbidden},
+		{"anonymous cannot comment", "", http.MethodPost, commentsURL, map[string]any{"body": "hi"}, http.StatusUnauthorized},
+		{"empty body", "bob", http.MethodPost, commentsURL, map[string]any{"body": "  "}, http.StatusBadRequest},
+		{"only the author edits", "bob", http.MethodPatch, commentsURL + "/" + first.ID, map[string]any{"body": "Madrid"}, http.StatusForbidden},
+		{"author edits", "carol", http.MethodPatch, commentsURL + "/" + first.ID, map[string]any{"body": "Lisbon in May?"}, http.StatusOK},
+		{"others cannot delete", "carol", http.MethodDelete, commentsURL + "/" + second.ID, nil, http.StatusForbidden},
+		{"task admin deletes", "alice", http.MethodDelete, commentsURL + "/" + second.ID, nil, http.StatusNoContent},
+		{"deleted is gone", "alice", http.MethodGet, commentsURL + "/" + second.ID, nil, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, data := doAs(t, tc.user, tc.method, tc.url, tc.body)
+			if resp.StatusCode != tc.want {
+				t.Fatalf("expected %d, got %d body=%s", tc.want, resp.StatusCode, string(data))
+			}
+		})
+	}
+
+	resp, data = doAs(t, "bob", http.MethodGet, commentsURL, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	var list struct {
+		Count int             `json:"count"`
+		Items []model.Comment `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != 1 || list.Items[0].Body != "Lisbon in May?" || !list.Items[0].UpdatedAt.After(list.Items[0].CreatedAt) {
+		t.Fatalf("unexpected comments %+v", list.Items)
+	}
+}
+
+func TestComments_BodyTooLarge(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Write the essay"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create task: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	commentsURL := ts.URL + "/tasks/" + decodeTask(t, data).ID + "/comments"
+
+	huge := `{"body":"` + strings.Repeat("a", 3<<20) + `"}`
+	resp, data = doAs(t, "alice", http.MethodPost, commentsURL, json.RawMessage(huge))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("declared length: expected 413, got %d body=%s", resp.StatusCode, string(data))
+	}
+
+	// Without a Content-Length the limit applies while reading.
+	resp, data = upload(t, "alice", commentsURL, "application/json", io.MultiReader(strings.NewReader(huge)))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("streamed: expected 413, got %d body=%s", resp.StatusCode, string(data))
+	}
+}
+
+func upload(t *testing.T, userID, url, contentType string, body io.Reader) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-User-Id", userID)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp, data
+}
+
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestAttachments_UploadDownloadAndCleanup(t *testing.T) {
+	dir := t.TempDir()
+	ts, repo := newPersistentTestServer(t, dir)
+
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "File taxes"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create task: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	taskID := decodeTask(t, data).ID
+	taskURL := ts.URL + "/tasks/" + taskID
+
+	resp, data = upload(t, "alice", taskURL+"/attachments?name=receipts.csv", "text/csv", strings.NewReader("date,amount\n"))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("upload: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	var a model.Attachment
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "receipts.csv" || a.Size != 12 || a.ContentType != "text/csv" || a.UploadedBy != "alice" {
+		t.Fatalf("unexpected attachment %+v", a)
+	}
+
+	resp, _ = upload(t, "bob", taskURL+"/attachments?name=x.txt", "text/plain", strings.NewReader("x"))
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("outsider upload: expected 403, got %d", resp.StatusCode)
+	}
+
+	resp, data = upload(t, "alice", taskURL+"/attachments?name=big.bin", "application/octet-stream",
+		bytes.NewReader(make([]byte, task.MaxAttachmentSize+1)))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: expected 413, got %d body=%s", resp.StatusCode, string(data))
+	}
+	// Hide the length so the limit has to be enforced while streaming.
+	resp, data = upload(t, "alice", taskURL+"/attachments?name=big.bin", "application/octet-stream",
+		io.MultiReader(bytes.NewReader(make([]byte, task.MaxAttachmentSize)), strings.NewReader("!")))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("streamed oversized upload: expected 413, got %d body=%s", resp.StatusCode, string(data))
+	}
+
+	blobDir := filepath.Join(dir, "blobs")
+	if n := countFiles(t, blobDir); n != 1 {
+		t.Fatalf("expected 1 blob after rejected uploads, found %d", n)
+	}
+
+	// Attachments survive a restart.
+	ts.Close()
+	repo.Close()
+	ts, repo = newPersistentTestServer(t, dir)
+	defer ts.Close()
+	defer repo.Close()
+	taskURL = ts.URL + "/tasks/" + taskID
+
+	resp, data = doAs(t, "alice", http.MethodGet, taskURL+"/attachments/"+a.ID+"/content", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("download: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	if string(data) != "date,amount\n" || resp.Header.Get("Content-Type") != "text/csv" {
+		t.Fatalf("unexpected download %q (%s)", string(data), resp.Header.Get("Content-Type"))
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename=receipts.csv` {
+		t.Fatalf("unexpected Content-Disposition %q", cd)
+	}
+
+	resp, _ = doAs(t, "alice", http.MethodDelete, taskURL, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete task: expected 204, got %d", resp.StatusCode)
+	}
+	if n := countFiles(t, blobDir); n != 0 {
+		t.Fatalf("expected blobs to be removed with the task, found %d", n)
+	}
+	resp, _ = doAs(t, "alice", http.MethodGet, taskURL+"/attachments", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("attachments of deleted task: expected 404, got %d", resp.StatusCode)
+	}
+}
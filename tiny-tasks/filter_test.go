@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func createTask(t *testing.T, baseURL string, client *http.Client, body map[string]any) string {
+	t.Helper()
+	resp, data := doJSON(t, client, http.MethodPost, baseURL+"/tasks", body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(data))
+	}
+	return decodeTask(t, data).ID
+}
+
+func listIDs(t *testing.T, baseURL string, client *http.Client, query url.Values) map[string]bool {
+	t.Helper()
+	resp, data := doJSON(t, client, http.MethodGet, baseURL+"/tasks?"+query.Encode(), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("query=%s status=%d body=%s", query.Encode(), resp.StatusCode, string(data))
+	}
+	_, items := decodeList(t, data)
+	out := make(map[string]bool, len(items))
+	for _, it := range items {
+		out[it.ID] = true
+	}
+	return out
+}
+
+func TestListFilterExpression(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	c := ts.Client()
+
+	work := createTask(t, ts.URL, c, map[string]any{"title": "Ship release", "tags": []string{"work"}})
+	milk := createTask(t, ts.URL, c, map[string]any{"title": "Buy milk", "tags": []string{"home"}})
+	done := createTask(t, ts.URL, c, map[string]any{"title": "Old work item", "tags": []string{"work"}})
+
+	resp, body := doJSON(t, c, http.MethodPatch, ts.URL+"/tasks/"+done, map[string]any{"completed": true})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	cases := []struct {
+		filter string
+		want   []string
+	}{
+		{"completed:false AND tag:work", []string{work}},
+		{"tag:work", []string{work, done}},
+		{`title:"buy milk" OR completed:true`, []string{milk, done}},
+		{"NOT tag:work", []string{milk}},
+		{"tag:work (completed:true OR title:ship)", []string{work, done}},
+		{"completed>=2000-01-01 AND completed<2999-01-01", []string{done}},
+		{"created<2000-01-01", nil},
+	}
+	for _, tc := range cases {
+		got := listIDs(t, ts.URL, c, url.Values{"filter": {tc.filter}})
+		if len(got) != len(tc.want) {
+			t.Fatalf("filter %q: got %v, want %v", tc.filter, got, tc.want)
+		}
+		for _, id := range tc.want {
+			if !got[id] {
+				t.Fatalf("filter %q: missing %s in %v", tc.filter, id, got)
+			}
+		}
+	}
+
+	for _, bad := range []string{"tag:", "color:red", "(tag:work", "tag:work OR", `title:"open`, "overdue:maybe"} {
+		resp, body := doJSON(t, c, http.MethodGet, ts.URL+"/tasks?"+url.Values{"filter": {bad}}.Encode(), nil)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("filter %q: status=%d body=%s", bad, resp.StatusCode, string(body))
+		}
+	}
+}
+
+func TestListFilterTimeZoneAndRanges(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	c := ts.Client()
+
+	before := time.Now().UTC().Add(-time.Second)
+	id := createTask(t, ts.URL, c, map[string]any{"title": "Finish today"})
+	resp, body := doJSON(t, c, http.MethodPatch, ts.URL+"/tasks/"+id, map[string]any{"completed": true})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	localToday := time.Now().In(loc).Format("2006-01-02")
+
+	got := listIDs(t, ts.URL, c, url.Values{"completed_on": {localToday}, "tz": {"Pacific/Kiritimati"}})
+	if !got[id] {
+		t.Fatalf("expected task completed on %s in Kiritimati", localToday)
+	}
+
+	got = listIDs(t, ts.URL, c, url.Values{"completed_after": {before.Format(time.RFC3339)}, "created_after": {"2000-01-01"}})
+	if !got[id] {
+		t.Fatalf("expected task in completed_after range")
+	}
+
+	got = listIDs(t, ts.URL, c, url.Values{"completed_before": {before.Format(time.RFC3339)}})
+	if len(got) != 0 {
+		t.Fatalf("expected no tasks completed before %s, got %v", before, got)
+	}
+
+	resp, body = doJSON(t, c, http.MethodGet, ts.URL+"/tasks?updated_since=yesterday", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+}
+
+// An unescaped "+" in an offset is form-decoded to a space.
+func TestListFilter_UnescapedPlusOffset(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	c := ts.Client()
+
+	id := createTask(t, ts.URL, c, map[string]any{"title": "Due in Warsaw", "due_at": "2026-03-01T12:00:00Z"})
+
+	for _, raw := range []string{
+		"filter=due<2026-03-01T13:30:00+02:00",
+		"filter=due<2026-03-01T14:30:00%2B02:00",
+		"filter=(due>2026-03-01T13:30:00+02:00)",
+	} {
+		resp, data := doJSON(t, c, http.MethodGet, ts.URL+"/tasks?"+raw, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status=%d body=%s", raw, resp.StatusCode, string(data))
+		}
+		_, items := decodeList(t, data)
+		found := len(items) == 1 && items[0].ID == id
+		if want := raw != "filter=due<2026-03-01T13:30:00+02:00"; found != want {
+			t.Fatalf("%s: got %d tasks, want match=%v", raw, len(items), want)
+		}
+	}
+
+	got := listIDs(t, ts.URL, c, url.Values{"created_after": {"2000-01-01T00:00:00 02:00"}})
+	if !got[id] {
+		t.Fatal("created_after with a spaced offset should parse")
+	}
+}
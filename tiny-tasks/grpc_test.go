@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	tasksv1 "tiny-tasks/api/tasks/v1"
+	"tiny-tasks/internal/grpcapi"
+	"tiny-tasks/internal/store/memorystore"
+	"tiny-tasks/internal/task"
+)
+
+func newTestGRPCClient(t *testing.T) tasksv1.TaskServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(grpcapi.UnaryIdentity), grpc.StreamInterceptor(grpcapi.StreamIdentity))
+	repo := memorystore.NewTaskStore()
+	tasksv1.RegisterTaskServiceServer(srv, grpcapi.NewServer(newService(repo, memorystore.NewBlobStore())))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return tasksv1.NewTaskServiceClient(conn)
+}
+
+func TestGRPC_TaskLifecycle(t *testing.T) {
+	client := newTestGRPCClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.CreateTask(ctx, &tasksv1.CreateTaskRequest{Title: " "})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+
+	created, err := client.CreateTask(ctx, &tasksv1.CreateTaskRequest{Title: "Call mom", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if _, err := client.CompleteTask(ctx, &tasksv1.CompleteTaskRequest{Id: created.GetId()}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	list, err := client.ListTasks(ctx, &tasksv1.ListTasksRequest{Completed: proto.Bool(true), Filter: "tag:home"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.GetItems()) != 1 || list.GetItems()[0].GetCompletedAt() == nil {
+		t.Fatalf("unexpected list: %v", list.GetItems())
+	}
+
+	_, err = client.ListTasks(ctx, &tasksv1.ListTasksRequest{Filter: "tag:"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for bad filter, got %v", err)
+	}
+
+	patched, err := client.PatchTask(ctx, &tasksv1.PatchTaskRequest{Id: created.GetId(), Title: proto.String("Call dad"), SetTags: true})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if patched.GetTitle() != "Call dad" || len(patched.GetTags()) != 0 {
+		t.Fatalf("unexpected patched task: %v", patched)
+	}
+
+	if _, err := client.DeleteTask(ctx, &tasksv1.DeleteTaskRequest{Id: created.GetId()}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = client.GetTask(ctx, &tasksv1.GetTaskRequest{Id: created.GetId()})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestGRPC_WatchTasks(t *testing.T) {
+	client := newTestGRPCClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTasks(ctx, &tasksv1.WatchTasksRequest{})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// Headers arrive once the server has subscribed.
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("watch header: %v", err)
+	}
+
+	created, err := client.CreateTask(ctx, &tasksv1.CreateTaskRequest{Title: "Watch me"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := client.DeleteTask(ctx, &tasksv1.DeleteTaskRequest{Id: created.GetId()}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	want := []tasksv1.TaskEvent_Type{tasksv1.TaskEvent_TYPE_CREATED, tasksv1.TaskEvent_TYPE_DELETED}
+	for _, typ := range want {
+		ev, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		if ev.GetType() != typ || ev.GetTask().GetId() != created.GetId() {
+			t.Fatalf("got %v, want %v for %s", ev, typ, created.GetId())
+		}
+	}
+}
+
+func TestWatch_ConcurrentUpdatesArriveInOrder(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	service := newService(repo, memorystore.NewBlobStore())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := service.Create(ctx, task.NewTask{Title: "Race"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	events := service.Watch(ctx)
+
+	const writers = 16
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			title := fmt.Sprintf("Race %d", i)
+			if _, err := service.Patch(ctx, created.ID, task.TaskUpdate{Title: &title}); err != nil {
+				t.Errorf("patch: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var last uint64
+	for i := 0; i < writers; i++ {
+		ev := <-events
+		if ev.Task.Version <= last {
+			t.Fatalf("event %d has version %d after %d", i, ev.Task.Version, last)
+		}
+		last = ev.Task.Version
+	}
+}
+
+func TestWatch_DroppedSubscriberReleasesGoroutine(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	service := newService(repo, memorystore.NewBlobStore())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	baseline := runtime.NumGoroutine()
+	events := service.Watch(ctx)
+	// Never read: the subscriber is dropped once its buffer is full.
+	for i := 0; i <= 64; i++ {
+		if _, err := service.Create(ctx, task.NewTask{Title: fmt.Sprintf("Task %d", i)}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	for range events {
+	}
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= baseline })
+}
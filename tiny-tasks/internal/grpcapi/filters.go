@@ -0,0 +1,41 @@
+package grpcapi
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tasksv1 "tiny-tasks/api/tasks/v1"
+	"tiny-tasks/internal/task"
+)
+
+// listFilter builds the same filter tree as GET /tasks from typed fields.
+func listFilter(req *tasksv1.ListTasksRequest, userID string, now time.Time) (task.Filter, error) {
+	lq := task.ListQuery{
+		TZ:              req.GetTz(),
+		Completed:       req.Completed,
+		CompletedOn:     req.GetCompletedOn(),
+		CompletedAfter:  formatBound(req.GetCompletedAfter()),
+		CompletedBefore: formatBound(req.GetCompletedBefore()),
+		CreatedAfter:    formatBound(req.GetCreatedAfter()),
+		CreatedBefore:   formatBound(req.GetCreatedBefore()),
+		UpdatedSince:    formatBound(req.GetUpdatedSince()),
+		Assignee:        req.GetAssignee(),
+		Expr:            req.GetFilter(),
+	}
+	filter, err := lq.Filter(userID, now)
+	if errors.Is(err, task.ErrAssigneeMeWithoutUser) {
+		return nil, errors.New("assignee=me requires the x-user-id metadata")
+	}
+	return filter, err
+}
+
+// formatBound renders a timestamp as task.ListQuery takes it; an unset one
+// stays empty.
+func formatBound(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.AsTime().Format(time.RFC3339Nano)
+}
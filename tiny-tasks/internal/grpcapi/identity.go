@@ -0,0 +1,44 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"tiny-tasks/internal/task"
+)
+
+// userMetadataKey carries the caller's identity, like the X-User-Id header
+// of the HTTP API.
+const userMetadataKey = "x-user-id"
+
+// UnaryIdentity and StreamIdentity must be installed with grpc.NewServer so
+// the service sees who is calling.
+func UnaryIdentity(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withUser(ctx), req)
+}
+
+func StreamIdentity(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, identityStream{ServerStream: ss, ctx: withUser(ss.Context())})
+}
+
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s identityStream) Context() context.Context {
+	return s.ctx
+}
+
+func withUser(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(userMetadataKey) {
+		if userID := strings.TrimSpace(v); userID != "" {
+			return task.WithUser(ctx, userID)
+		}
+	}
+	return ctx
+}
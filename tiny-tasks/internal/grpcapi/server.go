@@ -0,0 +1,256 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tasksv1 "tiny-tasks/api/tasks/v1"
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+var _ tasksv1.TaskServiceServer = (*Server)(nil)
+
+type Server struct {
+	tasksv1.UnimplementedTaskServiceServer
+	service *task.Service
+}
+
+func NewServer(service *task.Service) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) CreateTask(ctx context.Context, req *tasksv1.CreateTaskRequest) (*tasksv1.Task, error) {
+	created, err := s.service.Create(ctx, task.NewTask{
+		Title:           req.GetTitle(),
+		DueAt:           fromTimestamp(req.GetDueAt()),
+		Tags:            req.GetTags(),
+		ReminderOffsets: fromDurations(req.GetReminderOffsets()),
+		TeamID:          strings.TrimSpace(req.GetTeamId()),
+		Assignee:        strings.TrimSpace(req.GetAssignee()),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(created), nil
+}
+
+func (s *Server) GetTask(ctx context.Context, req *tasksv1.GetTaskRequest) (*tasksv1.Task, error) {
+	found, err := s.service.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(found), nil
+}
+
+func (s *Server) ListTasks(ctx context.Context, req *tasksv1.ListTasksRequest) (*tasksv1.ListTasksResponse, error) {
+	filter, err := listFilter(req, task.UserFrom(ctx), time.Now().UTC())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	order, err := task.ParseSortOrder(req.GetSort())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	tasks, err := s.service.List(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	task.Sort(tasks, order)
+
+	out := &tasksv1.ListTasksResponse{Items: make([]*tasksv1.Task, 0, len(tasks))}
+	for _, t := range tasks {
+		out.Items = append(out.Items, toProto(t))
+	}
+	return out, nil
+}
+
+func (s *Server) PatchTask(ctx context.Context, req *tasksv1.PatchTaskRequest) (*tasksv1.Task, error) {
+	upd := task.TaskUpdate{
+		Title:      req.Title,
+		Completed:  req.Completed,
+		DueAt:      fromTimestamp(req.GetDueAt()),
+		ClearDueAt: req.GetClearDueAt(),
+	}
+	if req.TeamId != nil {
+		teamID := strings.TrimSpace(req.GetTeamId())
+		upd.TeamID = &teamID
+	}
+	if req.Assignee != nil {
+		assignee := strings.TrimSpace(req.GetAssignee())
+		upd.Assignee = &assignee
+	}
+	if req.GetSetTags() {
+		tags := req.GetTags()
+		if tags == nil {
+			tags = []string{}
+		}
+		upd.Tags = &tags
+	}
+	if req.GetSetReminderOffsets() {
+		offsets := fromDurations(req.GetReminderOffsets())
+		if offsets == nil {
+			offsets = []model.Duration{}
+		}
+		upd.ReminderOffsets = &offsets
+	}
+
+	updated, err := s.service.Patch(ctx, req.GetId(), upd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(updated), nil
+}
+
+func (s *Server) CompleteTask(ctx context.Context, req *tasksv1.CompleteTaskRequest) (*tasksv1.Task, error) {
+	updated, err := s.service.Complete(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(updated), nil
+}
+
+func (s *Server) UndoTask(ctx context.Context, req *tasksv1.UndoTaskRequest) (*tasksv1.Task, error) {
+	updated, err := s.service.Undo(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(updated), nil
+}
+
+func (s *Server) DeleteTask(ctx context.Context, req *tasksv1.DeleteTaskRequest) (*tasksv1.DeleteTaskResponse, error) {
+	if err := s.service.Delete(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &tasksv1.DeleteTaskResponse{}, nil
+}
+
+func (s *Server) MoveTask(ctx context.Context, req *tasksv1.MoveTaskRequest) (*tasksv1.Task, error) {
+	moved, err := s.service.Move(ctx, req.GetId(), req.GetBeforeId(), req.GetAfterId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(moved), nil
+}
+
+// WatchTasks sends response headers once subscribed, so a client that waits
+// for them knows no later change will be missed.
+func (s *Server) WatchTasks(req *tasksv1.WatchTasksRequest, stream tasksv1.TaskService_WatchTasksServer) error {
+	ctx := stream.Context()
+	events := s.service.Watch(ctx)
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return status.Error(codes.ResourceExhausted, "watcher fell behind; reconnect and re-list")
+			}
+			if err := stream.Send(&tasksv1.TaskEvent{Type: eventType(ev.Type), Task: toProto(ev.Task)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toStatus is the gRPC side of the error mapping in httpapi.writeServiceError.
+func toStatus(err error) error {
+	switch task.KindOf(err) {
+	case task.KindInvalid:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case task.KindNotFound:
+		return status.Error(codes.NotFound, "task not found")
+	case task.KindUnauthenticated:
+		return status.Error(codes.Unauthenticated, err.Error())
+	case task.KindForbidden:
+		return status.Error(codes.PermissionDenied, "forbidden")
+	case task.KindConflict:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case task.KindTooLarge:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func toProto(t model.Task) *tasksv1.Task {
+	return &tasksv1.Task{
+		Id:              t.ID,
+		Title:           t.Title,
+		DueAt:           toTimestamp(t.DueAt),
+		Tags:            t.Tags,
+		ReminderOffsets: toDurations(t.ReminderOffsets),
+		OwnerId:         t.OwnerID,
+		TeamId:          t.TeamID,
+		Assignee:        t.Assignee,
+		Position:        t.Position,
+		CreatedAt:       timestamppb.New(t.CreatedAt),
+		UpdatedAt:       timestamppb.New(t.UpdatedAt),
+		CompletedAt:     toTimestamp(t.CompletedAt),
+	}
+}
+
+func eventType(t task.EventType) tasksv1.TaskEvent_Type {
+	switch t {
+	case task.EventCreated:
+		return tasksv1.TaskEvent_TYPE_CREATED
+	case task.EventUpdated:
+		return tasksv1.TaskEvent_TYPE_UPDATED
+	case task.EventDeleted:
+		return tasksv1.TaskEvent_TYPE_DELETED
+	default:
+		return tasksv1.TaskEvent_TYPE_UNSPECIFIED
+	}
+}
+
+func toDurations(ds []model.Duration) []*durationpb.Duration {
+	if len(ds) == 0 {
+		return nil
+	}
+	out := make([]*durationpb.Duration, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, durationpb.New(time.Duration(d)))
+	}
+	return out
+}
+
+func fromDurations(ds []*durationpb.Duration) []model.Duration {
+	if len(ds) == 0 {
+		return nil
+	}
+	out := make([]model.Duration, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, model.Duration(d.AsDuration()))
+	}
+	return out
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
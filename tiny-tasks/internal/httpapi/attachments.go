@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"tiny-tasks/internal/task"
+)
+
+// handleUploadAttachment takes the raw file as the request body, its name from
+// the name query parameter and its type from Content-Type.
+func (s *Server) handleUploadAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength > task.MaxAttachmentSize {
+		writeError(w, http.StatusRequestEntityTooLarge, task.ErrAttachmentTooLarge.Error())
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, task.MaxAttachmentSize)
+	defer body.Close()
+
+	created, err := s.service.Attach(r.Context(), r.PathValue("id"), r.URL.Query().Get("name"), r.Header.Get("Content-Type"), body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		err = task.ErrAttachmentTooLarge
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleListAttachments(w http.ResponseWriter, r *http.Request) {
+	attachments, err := s.service.ListAttachments(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count": len(attachments),
+		"items": attachments,
+	})
+}
+
+func (s *Server) handleGetAttachment(w http.ResponseWriter, r *http.Request) {
+	found, err := s.service.GetAttachment(r.Context(), r.PathValue("id"), r.PathValue("attachment_id"))
+	if err != nil {
+		writeResourceError(w, err, "attachment")
+		return
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+func (s *Server) handleDownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	a, content, err := s.service.OpenAttachment(r.Context(), r.PathValue("id"), r.PathValue("attachment_id"))
+	if err != nil {
+		writeResourceError(w, err, "attachment")
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("download attachment %s: %v", a.ID, err)
+	}
+}
+
+func (s *Server) handleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	if err := s.service.DeleteAttachment(r.Context(), r.PathValue("id"), r.PathValue("attachment_id")); err != nil {
+		writeResourceError(w, err, "attachment")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package httpapi
+
+import "net/http"
+
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	var req commentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	created, err := s.service.AddComment(r.Context(), r.PathValue("id"), req.Body)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	comments, err := s.service.ListComments(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count": len(comments),
+		"items": comments,
+	})
+}
+
+func (s *Server) handleGetComment(w http.ResponseWriter, r *http.Request) {
+	found, err := s.service.GetComment(r.Context(), r.PathValue("id"), r.PathValue("comment_id"))
+	if err != nil {
+		writeResourceError(w, err, "comment")
+		return
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+func (s *Server) handlePatchComment(w http.ResponseWriter, r *http.Request) {
+	var req commentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	updated, err := s.service.EditComment(r.Context(), r.PathValue("id"), r.PathValue("comment_id"), req.Body)
+	if err != nil {
+		writeResourceError(w, err, "comment")
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	if err := s.service.DeleteComment(r.Context(), r.PathValue("id"), r.PathValue("comment_id")); err != nil {
+		writeResourceError(w, err, "comment")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,201 @@
+package httpapi
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// minCompressSize keeps small responses, which compression would barely
+// shrink, cheap to produce.
+const minCompressSize = 1024
+
+// supportedEncodings is in order of preference when the client rates several
+// equally.
+var supportedEncodings = []string{"br", "gzip"}
+
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+// compress encodes responses with br or gzip, as negotiated by
+// Accept-Encoding. Only compressible content types of at least
+// minCompressSize bytes are encoded.
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks the supported coding with the highest q-value, or
+// "" for identity.
+func negotiateEncoding(accept string) string {
+	q := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		q[coding] = weight
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range supportedEncodings {
+		weight, ok := q[coding]
+		if !ok {
+			weight = q["*"]
+		}
+		if weight > bestQ {
+			best, bestQ = coding, weight
+		}
+	}
+	return best
+}
+
+func compressible(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range compressibleTypes {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds back the status line and the first minCompressSize
+// bytes until it knows whether the response is worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	cw.status = status
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if !compressible(cw.Header()) {
+			if err := cw.decide(false); err != nil {
+				return 0, err
+			}
+		} else {
+			cw.buf = append(cw.buf, p...)
+			if len(cw.buf) < minCompressSize {
+				return len(p), nil
+			}
+			if err := cw.decide(true); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide sends the held-back status and bytes, through an encoder if
+// compressing.
+func (cw *compressWriter) decide(compressing bool) error {
+	cw.decided = true
+	if compressing {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		switch cw.encoding {
+		case "br":
+			cw.enc = brotli.NewWriterLevel(cw.ResponseWriter, brotli.DefaultCompression)
+		default:
+			cw.enc = gzip.NewWriter(cw.ResponseWriter)
+		}
+	}
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide(len(cw.buf) > 0 && compressible(cw.Header()))
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) close() {
+	if !cw.decided && (cw.status != 0 || len(cw.buf) > 0) {
+		_ = cw.decide(false)
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Close()
+	}
+}
@@ -0,0 +1,121 @@
+package httpapi
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig controls which browser origins may call the API. An empty
+// AllowedOrigins disables CORS entirely; "*" allows any origin.
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	MaxAge         time.Duration
+}
+
+var (
+	defaultCORSMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost,
+		http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}
+	defaultCORSHeaders        = []string{"Content-Type", "X-User-Id", "X-Request-Id"}
+	defaultCORSExposedHeaders = []string{"X-Request-Id"}
+)
+
+const defaultCORSMaxAge = 10 * time.Minute
+
+func (c CORSConfig) withDefaults() CORSConfig {
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = defaultCORSMethods
+	}
+	if len(c.AllowedHeaders) == 0 {
+		c.AllowedHeaders = defaultCORSHeaders
+	}
+	if len(c.ExposedHeaders) == 0 {
+		c.ExposedHeaders = defaultCORSExposedHeaders
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = defaultCORSMaxAge
+	}
+	return c
+}
+
+func (c CORSConfig) allowsOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// allowsHeaders compares case-insensitively, as header names are.
+func (c CORSConfig) allowsHeaders(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		if !slices.ContainsFunc(c.AllowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, h) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// cors answers preflight requests itself and decorates actual requests from
+// allowed origins. Requests from other origins pass through untouched; the
+// browser then refuses to hand the response to the page.
+func cors(next http.Handler, cfg CORSConfig) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return next
+	}
+	cfg = cfg.withDefaults()
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Responses differ by Origin even when it is absent: a cached answer
+		// to a request without one must not be served to a browser page.
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !cfg.allowsOrigin(origin) {
+			if preflight {
+				writeError(w, http.StatusForbidden, "origin not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", exposed)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !slices.Contains(cfg.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) ||
+			!cfg.allowsHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+			writeError(w, http.StatusForbidden, "method or headers not allowed")
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+		w.Header().Set("Access-Control-Max-Age", maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
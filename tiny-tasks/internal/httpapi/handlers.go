@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -18,23 +17,31 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 }
 
 type createTaskRequest struct {
-	Title string `json:"title"`
+	Title           string           `json:"title"`
+	DueAt           *time.Time       `json:"due_at,omitempty"`
+	Tags            []string         `json:"tags,omitempty"`
+	ReminderOffsets []model.Duration `json:"reminder_offsets,omitempty"`
+	TeamID          string           `json:"team_id,omitempty"`
+	Assignee        string           `json:"assignee,omitempty"`
 }
 
 func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	var req createTaskRequest
-	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
-	created, err := s.service.Create(req.Title)
+	created, err := s.service.Create(r.Context(), task.NewTask{
+		Title:           req.Title,
+		DueAt:           req.DueAt,
+		Tags:            req.Tags,
+		ReminderOffsets: req.ReminderOffsets,
+		TeamID:          strings.TrimSpace(req.TeamID),
+		Assignee:        strings.TrimSpace(req.Assignee),
+	})
 	if err != nil {
-		if errors.Is(err, task.ErrInvalidTitle) {
-			writeError(w, http.StatusBadRequest, err.Error())
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeServiceError(w, err)
 		return
 	}
 
@@ -42,18 +49,23 @@ func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := s.service.List()
+	filter, err := parseListFilters(r.URL.Query(), task.UserFrom(r.Context()), time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	order, err := task.ParseSortOrder(r.URL.Query().Get("sort"))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeServiceError(w, err)
 		return
 	}
 
-	filters, err := parseListFilters(r.URL.Query())
+	tasks, err := s.service.List(r.Context(), filter)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err)
 		return
 	}
-	tasks = filterTasks(tasks, filters)
+	task.Sort(tasks, order)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"count": len(tasks),
@@ -61,76 +73,90 @@ func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (s *Server) handleTaskByID(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/tasks/")
-	id = strings.TrimSpace(id)
-	if id == "" || strings.Contains(id, "/") {
-		writeError(w, http.StatusNotFound, "not found")
+func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
+	found, err := s.service.Get(r.Context(), taskID(r))
+	if err != nil {
+		writeServiceError(w, err)
 		return
 	}
+	writeJSON(w, http.StatusOK, found)
+}
 
-	switch r.Method {
-	case http.MethodGet:
-		s.handleGetTask(w, id)
-	case http.MethodPatch:
-		s.handlePatchTask(w, r, id)
-	case http.MethodDelete:
-		s.handleDeleteTask(w, id)
-	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
+type patchTaskRequest struct {
+	Title           *string           `json:"title,omitempty"`
+	Completed       *bool             `json:"completed,omitempty"`
+	DueAt           nullableTime      `json:"due_at"`
+	Tags            *[]string         `json:"tags,omitempty"`
+	ReminderOffsets *[]model.Duration `json:"reminder_offsets,omitempty"`
+	TeamID          *string           `json:"team_id,omitempty"`
+	Assignee        *string           `json:"assignee,omitempty"`
+}
+
+func (req patchTaskRequest) update() task.TaskUpdate {
+	return task.TaskUpdate{
+		Title:           req.Title,
+		Completed:       req.Completed,
+		DueAt:           req.DueAt.Value,
+		ClearDueAt:      req.DueAt.Set && req.DueAt.Value == nil,
+		Tags:            req.Tags,
+		ReminderOffsets: req.ReminderOffsets,
+		TeamID:          trimPtr(req.TeamID),
+		Assignee:        trimPtr(req.Assignee),
 	}
 }
 
-func (s *Server) handleGetTask(w http.ResponseWriter, id string) {
-	found, err := s.service.Get(id)
+func (s *Server) handlePatchTask(w http.ResponseWriter, r *http.Request) {
+	var req patchTaskRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	updated, err := s.service.Patch(r.Context(), taskID(r), req.update())
 	if err != nil {
-		if errors.Is(err, model.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "task not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeServiceError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, found)
+
+	writeJSON(w, http.StatusOK, updated)
 }
 
-type patchTaskRequest struct {
-	Title     *string `json:"title,omitempty"`
-	Completed *bool   `json:"completed,omitempty"`
+type moveTaskRequest struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
 }
 
-func (s *Server) handlePatchTask(w http.ResponseWriter, r *http.Request, id string) {
-	var req patchTaskRequest
-	if err := decodeJSON(r, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+func (s *Server) handleMoveTask(w http.ResponseWriter, r *http.Request) {
+	var req moveTaskRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
-	updated, err := s.service.Patch(id, req.Title, req.Completed)
+	moved, err := s.service.Move(r.Context(), r.PathValue("id"), req.Before, req.After)
 	if err != nil {
-		if errors.Is(err, task.ErrInvalidTitle) || errors.Is(err, task.ErrNoFieldsToPatch) {
-			writeError(w, http.StatusBadRequest, err.Error())
-			return
-		}
-		if errors.Is(err, model.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "task not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeServiceError(w, err)
 		return
 	}
-
-	writeJSON(w, http.StatusOK, updated)
+	writeJSON(w, http.StatusOK, moved)
 }
 
-func (s *Server) handleDeleteTask(w http.ResponseWriter, id string) {
-	if err := s.service.Delete(id); err != nil {
-		if errors.Is(err, model.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "task not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "internal error")
+func (s *Server) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
+	if err := s.service.Delete(r.Context(), taskID(r)); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func trimPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	return &v
+}
+
+func taskID(r *http.Request) string {
+	return strings.TrimSpace(r.PathValue("id"))
+}
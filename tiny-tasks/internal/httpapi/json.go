@@ -5,23 +5,52 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
+
+	"tiny-tasks/internal/task"
 )
 
-func decodeJSON(r *http.Request, v any) error {
-	defer r.Body.Close()
+// maxJSONBody caps request bodies read by decodeJSON; a full /sync batch
+// fits comfortably.
+const maxJSONBody = 2 << 20
+
+var errBodyTooLarge = fmt.Errorf("request body exceeds %d bytes", maxJSONBody)
+
+// decodeJSON reads a single JSON value of at most maxJSONBody bytes into v.
+// Hand its error to writeDecodeError.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	if r.ContentLength > maxJSONBody {
+		return errBodyTooLarge
+	}
+	body := http.MaxBytesReader(w, r.Body, maxJSONBody)
+	defer body.Close()
 
-	dec := json.NewDecoder(r.Body)
+	dec := json.NewDecoder(body)
 	dec.DisallowUnknownFields()
 
-	if err := dec.Decode(v); err != nil {
-		return fmt.Errorf("invalid JSON: %w", err)
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		err = errors.New("multiple JSON values")
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errBodyTooLarge
 	}
-	if dec.More() {
-		return errors.New("invalid JSON: multiple JSON values")
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
 	}
 	return nil
 }
 
+// writeDecodeError answers a request whose body decodeJSON rejected.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBodyTooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
@@ -34,3 +63,55 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 func writeError(w http.ResponseWriter, status int, msg string) {
 	writeJSON(w, status, map[string]string{"error": msg})
 }
+
+// writeServiceError maps task.Service errors to responses; the gRPC API uses
+// the same task.KindOf classification.
+func writeServiceError(w http.ResponseWriter, err error) {
+	writeResourceError(w, err, "task")
+}
+
+// writeResourceError is writeServiceError for handlers whose not-found case
+// refers to something other than a task.
+func writeResourceError(w http.ResponseWriter, err error, resource string) {
+	status, msg := errorResponse(err, resource)
+	writeError(w, status, msg)
+}
+
+func errorResponse(err error, resource string) (int, string) {
+	switch task.KindOf(err) {
+	case task.KindInvalid:
+		return http.StatusBadRequest, err.Error()
+	case task.KindNotFound:
+		return http.StatusNotFound, resource + " not found"
+	case task.KindUnauthenticated:
+		return http.StatusUnauthorized, err.Error()
+	case task.KindForbidden:
+		return http.StatusForbidden, "forbidden"
+	case task.KindConflict:
+		return http.StatusConflict, err.Error()
+	case task.KindTooLarge:
+		return http.StatusRequestEntityTooLarge, err.Error()
+	default:
+		return http.StatusInternalServerError, "internal error"
+	}
+}
+
+// nullableTime tells an absent JSON field apart from an explicit null.
+type nullableTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+func (n *nullableTime) UnmarshalJSON(b []byte) error {
+	n.Set = true
+	if string(b) == "null" {
+		n.Value = nil
+		return nil
+	}
+	var t time.Time
+	if err := json.Unmarshal(b, &t); err != nil {
+		return err
+	}
+	n.Value = &t
+	return nil
+}
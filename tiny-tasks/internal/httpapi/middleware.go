@@ -4,13 +4,26 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"tiny-tasks/internal/ids"
+	"tiny-tasks/internal/task"
 )
 
-func withMiddleware(next http.Handler) http.Handler {
-	return requestLogging(requestID(timeout(next, 3*time.Second)))
+func withMiddleware(next http.Handler, corsCfg CORSConfig) http.Handler {
+	return requestLogging(requestID(cors(compress(identity(timeout(next, 3*time.Second))), corsCfg)))
+}
+
+// identity trusts X-User-Id as set by the authenticating proxy in front of
+// the service. Requests without it are anonymous.
+func identity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID := strings.TrimSpace(r.Header.Get("X-User-Id")); userID != "" {
+			r = r.WithContext(task.WithUser(r.Context(), userID))
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 func timeout(next http.Handler, d time.Duration) http.Handler {
@@ -3,90 +3,37 @@ package httpapi
 import (
 	"errors"
 	"net/url"
-	"strings"
 	"time"
 
-	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
 )
 
-type listFilters struct {
-	hasCompleted bool
-	completed    bool
-	start        *time.Time
-	end          *time.Time
-}
-
-func parseListFilters(q url.Values) (listFilters, error) {
-	var filters listFilters
-
-	if v := q.Get("completed"); v != "" {
-		parsed, err := parseBoolStrict(v)
-		if err != nil {
-			return listFilters{}, errors.New("completed must be true or false")
-		}
-		filters.hasCompleted = true
-		filters.completed = parsed
+// parseListFilters turns the GET /tasks query into a single filter, see
+// task.ListQuery. assignee=me refers to userID. A nil filter means
+// "everything".
+func parseListFilters(q url.Values, userID string, now time.Time) (task.Filter, error) {
+	lq := task.ListQuery{
+		TZ:              q.Get("tz"),
+		CompletedOn:     q.Get("completed_on"),
+		CompletedAfter:  q.Get("completed_after"),
+		CompletedBefore: q.Get("completed_before"),
+		CreatedAfter:    q.Get("created_after"),
+		CreatedBefore:   q.Get("created_before"),
+		UpdatedSince:    q.Get("updated_since"),
+		Assignee:        q.Get("assignee"),
+		Expr:            q.Get("filter"),
 	}
-
-	if day := q.Get("completed_on"); day != "" {
-		start, end, err := parseUTCDayRange(day)
+	if v := q.Get("completed"); v != "" {
+		parsed, err := task.ParseBool(v)
 		if err != nil {
-			return listFilters{}, errors.New("completed_on must be YYYY-MM-DD")
+			return nil, errors.New("completed must be true or false")
 		}
-		filters.start = &start
-		filters.end = &end
-	}
-
-	return filters, nil
-}
-
-func filterTasks(tasks []model.Task, filters listFilters) []model.Task {
-	if !filters.hasCompleted && filters.start == nil {
-		return tasks
+		lq.Completed = &parsed
 	}
 
-	out := make([]model.Task, 0, len(tasks))
-	for _, t := range tasks {
-		if filters.hasCompleted {
-			isCompleted := t.CompletedAt != nil
-			if isCompleted != filters.completed {
-				continue
-			}
-		}
-
-		if filters.start != nil {
-			if t.CompletedAt == nil {
-				continue
-			}
-			ct := *t.CompletedAt
-			if ct.Before(*filters.start) || !ct.Before(*filters.end) {
-				continue
-			}
-		}
-
-		out = append(out, t)
-	}
-
-	return out
-}
-
-func parseBoolStrict(s string) (bool, error) {
-	switch strings.ToLower(strings.TrimSpace(s)) {
-	case "true":
-		return true, nil
-	case "false":
-		return false, nil
-	default:
-		return false, errors.New("not a bool")
-	}
-}
-
-func parseUTCDayRange(day string) (time.Time, time.Time, error) {
-	t, err := time.Parse("2006-01-02", day)
-	if err != nil {
-		return time.Time{}, time.Time{}, err
+	filter, err := lq.Filter(userID, now)
+	if errors.Is(err, task.ErrAssigneeMeWithoutUser) {
+		return nil, errors.New("assignee=me requires the X-User-Id header")
 	}
-	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
-	end := start.Add(24 * time.Hour)
-	return start, end, nil
+	return filter, err
 }
@@ -0,0 +1,17 @@
+package httpapi
+
+import "net/http"
+
+func (s *Server) handleTaskReminders(w http.ResponseWriter, r *http.Request) {
+	found, err := s.service.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	items := s.reminders.Reminders(found)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count": len(items),
+		"items": items,
+	})
+}
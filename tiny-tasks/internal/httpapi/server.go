@@ -2,18 +2,37 @@ package httpapi
 
 import (
 	"net/http"
+
+	"tiny-tasks/internal/reminder"
+	"tiny-tasks/internal/report"
 	"tiny-tasks/internal/task"
 )
 
 type Server struct {
-	service *task.Service
-	mux     *http.ServeMux
+	service   *task.Service
+	reports   *report.Reporter
+	reminders *reminder.Scheduler
+	mux       *http.ServeMux
+	cors      CORSConfig
+	handler   http.Handler
+}
+
+type Option func(*Server)
+
+// WithCORS lets browsers on the configured origins call the API.
+func WithCORS(cfg CORSConfig) Option {
+	return func(s *Server) { s.cors = cfg }
 }
 
-func NewServer(service *task.Service) *Server {
+func NewServer(service *task.Service, reports *report.Reporter, reminders *reminder.Scheduler, opts ...Option) *Server {
 	srv := &Server{
-		service: service,
-		mux:     http.NewServeMux(),
+		service:   service,
+		reports:   reports,
+		reminders: reminders,
+		mux:       http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(srv)
 	}
 
 	srv.mux.HandleFunc("GET /healthz", srv.handleHealth)
@@ -21,11 +40,84 @@ func NewServer(service *task.Service) *Server {
 	srv.mux.HandleFunc("POST /tasks", srv.handleCreateTask)
 	srv.mux.HandleFunc("GET /tasks", srv.handleListTasks)
 
-	srv.mux.HandleFunc("/tasks/", srv.handleTaskByID)
+	srv.mux.HandleFunc("GET /tasks/{id}", srv.handleGetTask)
+	srv.mux.HandleFunc("PATCH /tasks/{id}", srv.handlePatchTask)
+	srv.mux.HandleFunc("DELETE /tasks/{id}", srv.handleDeleteTask)
+	srv.mux.HandleFunc("GET /tasks/{id}/reminders", srv.handleTaskReminders)
+	srv.mux.HandleFunc("POST /tasks/{id}/move", srv.handleMoveTask)
+
+	srv.mux.HandleFunc("POST /tasks/{id}/comments", srv.handleCreateComment)
+	srv.mux.HandleFunc("GET /tasks/{id}/comments", srv.handleListComments)
+	srv.mux.HandleFunc("GET /tasks/{id}/comments/{comment_id}", srv.handleGetComment)
+	srv.mux.HandleFunc("PATCH /tasks/{id}/comments/{comment_id}", srv.handlePatchComment)
+	srv.mux.HandleFunc("DELETE /tasks/{id}/comments/{comment_id}", srv.handleDeleteComment)
 
+	srv.mux.HandleFunc("POST /tasks/{id}/attachments", srv.handleUploadAttachment)
+	srv.mux.HandleFunc("GET /tasks/{id}/attachments", srv.handleListAttachments)
+	srv.mux.HandleFunc("GET /tasks/{id}/attachments/{attachment_id}", srv.handleGetAttachment)
+	srv.mux.HandleFunc("GET /tasks/{id}/attachments/{attachment_id}/content", srv.handleDownloadAttachment)
+	srv.mux.HandleFunc("DELETE /tasks/{id}/attachments/{attachment_id}", srv.handleDeleteAttachment)
+
+	srv.mux.HandleFunc("GET /sync", srv.handleChanges)
+	srv.mux.HandleFunc("POST /sync", srv.handleSync)
+
+	srv.mux.HandleFunc("GET /stats", srv.handleStats)
+
+	srv.mux.HandleFunc("POST /teams", srv.handleCreateTeam)
+	srv.mux.HandleFunc("GET /teams", srv.handleListTeams)
+	srv.mux.HandleFunc("GET /teams/{id}", srv.handleGetTeam)
+	srv.mux.HandleFunc("PUT /teams/{id}/members/{user_id}", srv.handleSetMember)
+	srv.mux.HandleFunc("DELETE /teams/{id}/members/{user_id}", srv.handleRemoveMember)
+
+	srv.handler = withMiddleware(jsonFallback(srv.mux), srv.cors)
 	return srv
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	withMiddleware(s.mux).ServeHTTP(w, r)
+	s.handler.ServeHTTP(w, r)
+}
+
+// jsonFallback replaces the mux's plain-text 404 and 405 replies with the
+// usual JSON errors. The 405 keeps the Allow header the mux computes from the
+// registered patterns.
+func jsonFallback(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &headerRecorder{header: make(http.Header)}
+		h.ServeHTTP(rec, r)
+		switch rec.status {
+		case http.StatusMethodNotAllowed:
+			w.Header().Set("Allow", rec.header.Get("Allow"))
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		case http.StatusNotFound:
+			writeError(w, http.StatusNotFound, "not found")
+		default:
+			// Redirects, e.g. to clean up a path; replay them as they were.
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// headerRecorder captures the status and headers of a reply and drops its body.
+type headerRecorder struct {
+	header http.Header
+	status int
+}
+
+func (h *headerRecorder) Header() http.Header { return h.header }
+
+func (h *headerRecorder) WriteHeader(status int) {
+	if h.status == 0 {
+		h.status = status
+	}
+}
+
+func (h *headerRecorder) Write(p []byte) (int, error) {
+	h.WriteHeader(http.StatusOK)
+	return len(p), nil
 }
@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"tiny-tasks/internal/report"
+	"tiny-tasks/internal/task"
+)
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	q, err := parseStatsQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := s.reports.Stats(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, report.ErrInvalidBucket) ||
+			errors.Is(err, report.ErrInvalidRange) ||
+			errors.Is(err, report.ErrRangeTooLarge) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// parseStatsQuery defaults to the last 30 days (or 12 weeks) ending today in tz.
+func parseStatsQuery(q url.Values, now time.Time) (report.StatsQuery, error) {
+	loc, err := task.ParseLocation(q.Get("tz"))
+	if err != nil {
+		return report.StatsQuery{}, err
+	}
+
+	bucket := report.Bucket(q.Get("bucket"))
+	if bucket == "" {
+		bucket = report.BucketDay
+	}
+
+	to := now.In(loc)
+	if v := q.Get("to"); v != "" {
+		if to, _, err = task.ParseDayRange(v, loc); err != nil {
+			return report.StatsQuery{}, errors.New("to must be YYYY-MM-DD")
+		}
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if bucket == report.BucketWeek {
+		from = to.AddDate(0, 0, -7*12+1)
+	}
+	if v := q.Get("from"); v != "" {
+		if from, _, err = task.ParseDayRange(v, loc); err != nil {
+			return report.StatsQuery{}, errors.New("from must be YYYY-MM-DD")
+		}
+	}
+
+	return report.StatsQuery{
+		Location: loc,
+		Bucket:   bucket,
+		From:     from,
+		To:       to,
+	}, nil
+}
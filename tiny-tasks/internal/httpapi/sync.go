@@ -0,0 +1,157 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+type changesResponse struct {
+	Token   string        `json:"token"`
+	Reset   bool          `json:"reset"`
+	Tasks   []model.Task  `json:"tasks"`
+	Deleted []deletedTask `json:"deleted"`
+}
+
+type deletedTask struct {
+	ID      string `json:"id"`
+	Version uint64 `json:"version"`
+}
+
+// handleChanges serves GET /sync. Without a token, or with one the server no
+// longer recognises, it returns every visible task with reset set.
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	since, err := parseChangeToken(strings.TrimSpace(r.URL.Query().Get("since")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "since must be a token returned by GET /sync")
+		return
+	}
+
+	changes, err := s.service.Changes(r.Context(), since)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := changesResponse{
+		Token:   formatChangeToken(changes.Token),
+		Reset:   changes.Reset,
+		Tasks:   changes.Tasks,
+		Deleted: make([]deletedTask, 0, len(changes.Deleted)),
+	}
+	if resp.Tasks == nil {
+		resp.Tasks = []model.Task{}
+	}
+	for _, ts := range changes.Deleted {
+		resp.Deleted = append(resp.Deleted, deletedTask{ID: ts.ID, Version: ts.Version})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Change tokens are "<epoch>.<seq>". A bare sequence number, as handed out
+// before tokens had an epoch, parses with an empty epoch and so resets.
+func formatChangeToken(t task.ChangeToken) string {
+	return t.Epoch + "." + strconv.FormatUint(t.Seq, 10)
+}
+
+func parseChangeToken(raw string) (task.ChangeToken, error) {
+	if raw == "" {
+		return task.ChangeToken{}, nil
+	}
+	epoch, seq, ok := strings.Cut(raw, ".")
+	if !ok {
+		epoch, seq = "", raw
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return task.ChangeToken{}, err
+	}
+	return task.ChangeToken{Epoch: epoch, Seq: n}, nil
+}
+
+type syncRequest struct {
+	Mutations []mutationRequest `json:"mutations"`
+}
+
+type mutationRequest struct {
+	Op          task.MutationOp  `json:"op"`
+	ID          string           `json:"id,omitempty"`
+	ClientID    string           `json:"client_id,omitempty"`
+	BaseVersion uint64           `json:"base_version"`
+	ChangedAt   time.Time        `json:"changed_at"`
+	Fields      patchTaskRequest `json:"fields"`
+}
+
+type syncResponse struct {
+	Results   []mutationResult `json:"results"`
+	Conflicts []conflict       `json:"conflicts"`
+}
+
+type mutationResult struct {
+	ClientID string      `json:"client_id,omitempty"`
+	Status   string      `json:"status"`
+	Task     *model.Task `json:"task,omitempty"`
+	Deleted  bool        `json:"deleted,omitempty"`
+	Code     int         `json:"code,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type conflict struct {
+	TaskID          string      `json:"task_id"`
+	Field           string      `json:"field"`
+	Winner          task.Winner `json:"winner"`
+	ServerChangedAt time.Time   `json:"server_changed_at"`
+	ClientChangedAt time.Time   `json:"client_changed_at"`
+}
+
+// handleSync serves POST /sync. Mutations are applied in order and each is
+// reported on its own, so the response is 200 even when some are rejected.
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	var req syncRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	mutations := make([]task.Mutation, len(req.Mutations))
+	for i, m := range req.Mutations {
+		mutations[i] = task.Mutation{
+			Op:          m.Op,
+			ID:          strings.TrimSpace(m.ID),
+			ClientID:    m.ClientID,
+			BaseVersion: m.BaseVersion,
+			ChangedAt:   m.ChangedAt,
+			Fields:      m.Fields.update(),
+		}
+	}
+
+	results, conflicts, err := s.service.Sync(r.Context(), mutations)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := syncResponse{
+		Results:   make([]mutationResult, len(results)),
+		Conflicts: make([]conflict, len(conflicts)),
+	}
+	for i, res := range results {
+		out := mutationResult{ClientID: res.ClientID, Status: "applied", Deleted: res.Deleted}
+		switch {
+		case res.Err != nil:
+			out.Status = "rejected"
+			out.Code, out.Error = errorResponse(res.Err, "task")
+		case !res.Deleted:
+			out.Task = &res.Task
+		}
+		resp.Results[i] = out
+	}
+	for i, c := range conflicts {
+		resp.Conflicts[i] = conflict(c)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
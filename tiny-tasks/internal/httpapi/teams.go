@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"tiny-tasks/internal/model"
+)
+
+type createTeamRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) handleCreateTeam(w http.ResponseWriter, r *http.Request) {
+	var req createTeamRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	created, err := s.service.CreateTeam(r.Context(), req.Name)
+	if err != nil {
+		writeResourceError(w, err, "team")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleListTeams(w http.ResponseWriter, r *http.Request) {
+	teams, err := s.service.ListTeams(r.Context())
+	if err != nil {
+		writeResourceError(w, err, "team")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count": len(teams),
+		"items": teams,
+	})
+}
+
+func (s *Server) handleGetTeam(w http.ResponseWriter, r *http.Request) {
+	team, err := s.service.GetTeam(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeResourceError(w, err, "team")
+		return
+	}
+	writeJSON(w, http.StatusOK, team)
+}
+
+type setMemberRequest struct {
+	Role model.Role `json:"role"`
+}
+
+func (s *Server) handleSetMember(w http.ResponseWriter, r *http.Request) {
+	var req setMemberRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	team, err := s.service.SetMember(r.Context(), r.PathValue("id"), r.PathValue("user_id"), req.Role)
+	if err != nil {
+		writeResourceError(w, err, "team")
+		return
+	}
+	writeJSON(w, http.StatusOK, team)
+}
+
+func (s *Server) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	team, err := s.service.RemoveMember(r.Context(), r.PathValue("id"), r.PathValue("user_id"))
+	if err != nil {
+		writeResourceError(w, err, "team")
+		return
+	}
+	writeJSON(w, http.StatusOK, team)
+}
@@ -3,9 +3,47 @@ package model
 import "time"
 
 type Task struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	DueAt           *time.Time `json:"due_at,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
+	ReminderOffsets []Duration `json:"reminder_offsets,omitempty"`
+	OwnerID         string     `json:"owner_id,omitempty"`
+	TeamID          string     `json:"team_id,omitempty"`
+	Assignee        string     `json:"assignee,omitempty"`
+	Position        string     `json:"position"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	// ClientID is the id a sync client gave the task when creating it.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Version is the repository's change sequence number at the task's
+	// latest write.
+	Version uint64 `json:"version"`
+	// FieldClocks records when each editable field last changed, keyed by
+	// the Field* names below. Sync uses it to settle concurrent edits field
+	// by field.
+	FieldClocks map[string]FieldClock `json:"field_clocks,omitempty"`
+}
+
+// FieldClock is the version and time of a field's latest change.
+type FieldClock struct {
+	Version uint64    `json:"version"`
+	At      time.Time `json:"at"`
+}
+
+const (
+	FieldTitle           = "title"
+	FieldCompleted       = "completed"
+	FieldDueAt           = "due_at"
+	FieldTags            = "tags"
+	FieldReminderOffsets = "reminder_offsets"
+	FieldTeamID          = "team_id"
+	FieldAssignee        = "assignee"
+)
+
+// EditableFields lists the fields that carry a FieldClock.
+var EditableFields = []string{
+	FieldTitle, FieldCompleted, FieldDueAt, FieldTags, FieldReminderOffsets, FieldTeamID, FieldAssignee,
 }
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+type Team struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Members   map[string]Role `json:"members"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
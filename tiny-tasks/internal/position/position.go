@@ -0,0 +1,153 @@
+// Package position generates fractional index keys: strings whose
+// lexicographic order is the list order, so that a new key can always be made
+// between two neighbours without renumbering anything else.
+package position
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// digits is in ASCII order, so byte-wise string comparison orders keys.
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var (
+	ErrInvalidKey = errors.New("invalid position key")
+	ErrOrder      = errors.New("position keys out of order")
+)
+
+// Between returns a key strictly between a and b. An empty a means the start
+// of the list and an empty b its end. Keys never end in '0', which keeps room
+// below every key.
+func Between(a, b string) (string, error) {
+	if !valid(a) || !valid(b) {
+		return "", ErrInvalidKey
+	}
+	if b != "" && a >= b {
+		return "", ErrOrder
+	}
+	return midpoint(a, b), nil
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		// Copy the shared prefix, reading missing digits of a as '0'.
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(tail(a, n), b[n:])
+		}
+	}
+
+	da := 0
+	if a != "" {
+		da = strings.IndexByte(digits, a[0])
+	}
+	db := len(digits)
+	if b != "" {
+		db = strings.IndexByte(digits, b[0])
+	}
+	if db-da > 1 {
+		return string(digits[(da+db+1)/2])
+	}
+	// The first digits are adjacent: a shorter prefix of b is still above a,
+	// otherwise keep a's digit and go one level deeper.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[da]) + midpoint(tail(a, 1), "")
+}
+
+// appendWidth is the key length After counts at: about 62^3 appends fit
+// behind any key before keys have to grow.
+const appendWidth = 4
+
+// After returns a key greater than a for appending to the end of the list.
+// Unlike Between(a, "") it steps by the smallest increment at appendWidth
+// digits, cutting a longer a down to them, so neither long runs of appends
+// nor a long last key make keys longer.
+func After(a string) (string, error) {
+	if !valid(a) {
+		return "", ErrInvalidKey
+	}
+	if a == "" {
+		return midpoint("", ""), nil
+	}
+
+	key := []byte(a)
+	if len(key) > appendWidth {
+		key = key[:appendWidth]
+	}
+	for len(key) < appendWidth {
+		key = append(key, '0')
+	}
+	for i := len(key) - 1; i >= 0; i-- {
+		if key[i] != digits[len(digits)-1] {
+			key[i] = digits[strings.IndexByte(digits, key[i])+1]
+			return string(key[:i+1]), nil
+		}
+	}
+	// Every digit is already the largest one.
+	return midpoint(a, ""), nil
+}
+
+// Spread returns n increasing keys of equal length, evenly spaced over the
+// whole key space. Stores use it to rebalance once keys grow long.
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	base := big.NewInt(int64(len(digits)))
+	slots := big.NewInt(int64(n) + 1)
+
+	width := 1
+	space := new(big.Int).Set(base)
+	for space.Cmp(slots) <= 0 {
+		space.Mul(space, base)
+		width++
+	}
+
+	keys := make([]string, n)
+	v, rem := new(big.Int), new(big.Int)
+	for i := range keys {
+		v.Mul(space, big.NewInt(int64(i)+1))
+		v.Div(v, slots)
+
+		key := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			v.DivMod(v, base, rem)
+			key[j] = digits[rem.Int64()]
+		}
+		keys[i] = strings.TrimRight(string(key), "0")
+	}
+	return keys
+}
+
+func valid(key string) bool {
+	if strings.HasSuffix(key, "0") {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return '0'
+}
+
+func tail(key string, n int) string {
+	if n < len(key) {
+		return key[n:]
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"tiny-tasks/internal/model"
+)
+
+type Notification struct {
+	TaskID string         `json:"task_id"`
+	Title  string         `json:"title"`
+	DueAt  time.Time      `json:"due_at"`
+	Offset model.Duration `json:"offset"`
+	FireAt time.Time      `json:"fire_at"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+func (l LogNotifier) Notify(ctx context.Context, n Notification) error {
+	logger := l.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("reminder task_id=%s title=%q due_at=%s offset=%s",
+		n.TaskID, n.Title, n.DueAt.Format(time.RFC3339), time.Duration(n.Offset))
+	return nil
+}
+
+// WebhookNotifier POSTs each notification as JSON to URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (wh WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := wh.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPNotifier sends plain-text mail without authentication, which is what
+// local stand-ins such as MailHog or Mailpit accept.
+type SMTPNotifier struct {
+	Addr string
+	From string
+	To   []string
+}
+
+func (m SMTPNotifier) Notify(ctx context.Context, n Notification) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&msg, "Subject: Reminder: %s\r\n", n.Title)
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%q is due at %s.\r\n", n.Title, n.DueAt.Format(time.RFC1123))
+
+	// net/smtp has no context support; bound the call from the outside.
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(m.Addr, nil, m.From, m.To, []byte(msg.String())) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,229 @@
+package reminder
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSent      Status = "sent"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"   // time passed without this process sending it
+	StatusCancelled Status = "cancelled" // task completed before the reminder was due
+)
+
+type Reminder struct {
+	Offset model.Duration `json:"offset"`
+	FireAt time.Time      `json:"fire_at"`
+	Status Status         `json:"status"`
+	SentAt *time.Time     `json:"sent_at,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+const (
+	// DefaultCatchUp is how far back a scheduler starting without a
+	// watermark, or with an older one, looks for reminders it may have missed
+	// while the process was down.
+	DefaultCatchUp = 5 * time.Minute
+
+	maxSleep        = 1 * time.Minute
+	notifyTimeout   = 10 * time.Second
+	deliveryHistory = 7 * 24 * time.Hour
+)
+
+type deliveryKey struct {
+	taskID string
+	fireAt int64
+}
+
+type delivery struct {
+	at  time.Time
+	err error
+}
+
+// WatermarkStore keeps the time up to which reminders have been fired, so a
+// restarted scheduler carries on from there instead of sending the catch-up
+// window again.
+type WatermarkStore interface {
+	ReminderWatermark() (time.Time, error)
+	SetReminderWatermark(t time.Time) error
+}
+
+// Scheduler fires reminders through a Notifier. Pending reminders are
+// recomputed from the tasks on every pass; only the watermark is stored, so a
+// restart loses the record of what was sent but does not send it again.
+type Scheduler struct {
+	service    *task.Service
+	watermarks WatermarkStore
+	notifier   Notifier
+	catchUp    time.Duration
+	now        func() time.Time
+
+	mu         sync.Mutex
+	deliveries map[deliveryKey]delivery
+}
+
+func NewScheduler(service *task.Service, watermarks WatermarkStore, notifier Notifier, catchUp time.Duration) *Scheduler {
+	return &Scheduler{
+		service:    service,
+		watermarks: watermarks,
+		notifier:   notifier,
+		catchUp:    catchUp,
+		now:        func() time.Time { return time.Now().UTC() },
+		deliveries: make(map[deliveryKey]delivery),
+	}
+}
+
+// Run fires reminders until ctx is canceled. It wakes at the next fire time or
+// as soon as any task changes. It acts as the system and so sees every user's
+// tasks.
+func (s *Scheduler) Run(ctx context.Context) {
+	ctx = task.AsSystem(ctx)
+	watermark := s.now().Add(-s.catchUp)
+	if stored, err := s.watermarks.ReminderWatermark(); err != nil {
+		log.Printf("reminders: read watermark: %v", err)
+	} else if stored.After(watermark) {
+		watermark = stored
+	}
+	changes := s.service.Watch(ctx)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				changes = s.service.Watch(ctx)
+			}
+		case <-timer.C:
+		}
+
+		now := s.now()
+		next, err := s.fire(ctx, watermark, now)
+		if err != nil {
+			log.Printf("reminders: %v", err)
+		} else {
+			watermark = now
+			if err := s.watermarks.SetReminderWatermark(now); err != nil {
+				log.Printf("reminders: store watermark: %v", err)
+			}
+		}
+
+		sleep := maxSleep
+		if !next.IsZero() && next.Sub(now) < sleep {
+			sleep = next.Sub(now)
+		}
+		timer.Reset(sleep)
+	}
+}
+
+// fire sends every reminder due in (after, now] and returns the earliest fire
+// time still ahead, or zero when there is none.
+func (s *Scheduler) fire(ctx context.Context, after, now time.Time) (time.Time, error) {
+	tasks, err := s.service.List(ctx, task.And{
+		task.Completed(false),
+		task.TimeRange{Field: task.FieldDueAt},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	for _, t := range tasks {
+		for _, offset := range t.ReminderOffsets {
+			fireAt := t.DueAt.Add(-time.Duration(offset))
+			if fireAt.After(now) {
+				if next.IsZero() || fireAt.Before(next) {
+					next = fireAt
+				}
+				continue
+			}
+			if !fireAt.After(after) {
+				continue
+			}
+			s.send(ctx, Notification{
+				TaskID: t.ID,
+				Title:  t.Title,
+				DueAt:  *t.DueAt,
+				Offset: offset,
+				FireAt: fireAt,
+			})
+		}
+	}
+
+	s.prune(now)
+	return next, nil
+}
+
+func (s *Scheduler) send(ctx context.Context, n Notification) {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	err := s.notifier.Notify(ctx, n)
+	if err != nil {
+		log.Printf("reminders: task_id=%s notify error: %v", n.TaskID, err)
+	}
+
+	s.mu.Lock()
+	s.deliveries[deliveryKey{n.TaskID, n.FireAt.UnixNano()}] = delivery{at: s.now(), err: err}
+	s.mu.Unlock()
+}
+
+func (s *Scheduler) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, d := range s.deliveries {
+		if now.Sub(d.at) > deliveryHistory {
+			delete(s.deliveries, k)
+		}
+	}
+}
+
+// Reminders lists the reminders of t in firing order.
+func (s *Scheduler) Reminders(t model.Task) []Reminder {
+	out := make([]Reminder, 0, len(t.ReminderOffsets))
+	if t.DueAt == nil {
+		return out
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, offset := range t.ReminderOffsets {
+		r := Reminder{Offset: offset, FireAt: t.DueAt.Add(-time.Duration(offset))}
+
+		d, delivered := s.deliveries[deliveryKey{t.ID, r.FireAt.UnixNano()}]
+		switch {
+		case delivered && d.err == nil:
+			r.Status = StatusSent
+			r.SentAt = &d.at
+		case delivered:
+			r.Status = StatusFailed
+			r.Error = d.err.Error()
+		case t.CompletedAt != nil:
+			r.Status = StatusCancelled
+		case r.FireAt.After(now):
+			r.Status = StatusPending
+		default:
+			r.Status = StatusSkipped
+		}
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+type Bucket string
+
+const (
+	BucketDay  Bucket = "day"
+	BucketWeek Bucket = "week"
+)
+
+const maxRangeDays = 366
+
+var (
+	ErrInvalidBucket = errors.New("bucket must be day or week")
+	ErrInvalidRange  = errors.New("from must not be after to")
+	ErrRangeTooLarge = errors.New("range must not exceed 366 days")
+)
+
+// StatsQuery selects the calendar days [From, To] (both inclusive) as seen in
+// Location. From and To only need the right date; their time of day is ignored.
+type StatsQuery struct {
+	Location *time.Location
+	Bucket   Bucket
+	From     time.Time
+	To       time.Time
+}
+
+type Stats struct {
+	TZ        string        `json:"tz"`
+	Bucket    Bucket        `json:"bucket"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Created   int           `json:"created"`
+	Completed int           `json:"completed"`
+	Buckets   []BucketCount `json:"buckets"`
+
+	// Over tasks completed in the range; nil when there are none.
+	MedianTimeToCompleteSeconds *float64 `json:"median_time_to_complete_seconds"`
+
+	// Open tasks past their due date right now, regardless of the range.
+	Overdue int `json:"overdue"`
+
+	Tags []TagStats `json:"tags"`
+}
+
+type BucketCount struct {
+	Start     string `json:"start"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+type TagStats struct {
+	Tag       string `json:"tag"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	Overdue   int    `json:"overdue"`
+}
+
+type Reporter struct {
+	service *task.Service
+	now     func() time.Time
+}
+
+func NewReporter(service *task.Service) *Reporter {
+	return &Reporter{
+		service: service,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Stats counts only the tasks the caller in ctx may view.
+func (r *Reporter) Stats(ctx context.Context, q StatsQuery) (Stats, error) {
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	if q.Bucket == "" {
+		q.Bucket = BucketDay
+	}
+	if q.Bucket != BucketDay && q.Bucket != BucketWeek {
+		return Stats{}, ErrInvalidBucket
+	}
+
+	from := startOfDay(q.From, q.Location)
+	end := startOfDay(q.To, q.Location).AddDate(0, 0, 1)
+	if !from.Before(end) {
+		return Stats{}, ErrInvalidRange
+	}
+	if from.AddDate(0, 0, maxRangeDays).Before(end) {
+		return Stats{}, ErrRangeTooLarge
+	}
+
+	tasks, err := r.service.List(ctx, nil)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	out := Stats{
+		TZ:      q.Location.String(),
+		Bucket:  q.Bucket,
+		From:    from.Format(time.DateOnly),
+		To:      end.AddDate(0, 0, -1).Format(time.DateOnly),
+		Buckets: []BucketCount{},
+		Tags:    []TagStats{},
+	}
+
+	index := make(map[string]int)
+	for start := bucketStart(from, q.Bucket); start.Before(end); start = nextBucket(start, q.Bucket) {
+		key := start.Format(time.DateOnly)
+		index[key] = len(out.Buckets)
+		out.Buckets = append(out.Buckets, BucketCount{Start: key})
+	}
+	bucketFor := func(t time.Time) *BucketCount {
+		if t.Before(from) || !t.Before(end) {
+			return nil
+		}
+		key := bucketStart(t.In(q.Location), q.Bucket).Format(time.DateOnly)
+		return &out.Buckets[index[key]]
+	}
+
+	now := r.now()
+	tags := make(map[string]*TagStats)
+	var durations []time.Duration
+
+	for _, t := range tasks {
+		var created, completed bool
+		if b := bucketFor(t.CreatedAt); b != nil {
+			b.Created++
+			out.Created++
+			created = true
+		}
+		if t.CompletedAt != nil {
+			if b := bucketFor(*t.CompletedAt); b != nil {
+				b.Completed++
+				out.Completed++
+				completed = true
+				durations = append(durations, t.CompletedAt.Sub(t.CreatedAt))
+			}
+		}
+
+		overdue := isOverdue(t, now)
+		if overdue {
+			out.Overdue++
+		}
+
+		for _, tag := range t.Tags {
+			ts := tags[tag]
+			if ts == nil {
+				ts = &TagStats{Tag: tag}
+				tags[tag] = ts
+			}
+			if created {
+				ts.Created++
+			}
+			if completed {
+				ts.Completed++
+			}
+			if overdue {
+				ts.Overdue++
+			}
+		}
+	}
+
+	if m, ok := median(durations); ok {
+		secs := m.Seconds()
+		out.MedianTimeToCompleteSeconds = &secs
+	}
+
+	for _, ts := range tags {
+		out.Tags = append(out.Tags, *ts)
+	}
+	sort.Slice(out.Tags, func(i, j int) bool { return out.Tags[i].Tag < out.Tags[j].Tag })
+
+	return out, nil
+}
+
+func isOverdue(t model.Task, now time.Time) bool {
+	return t.CompletedAt == nil && t.DueAt != nil && t.DueAt.Before(now)
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// bucketStart expects t in the report location. Weeks start on Monday.
+func bucketStart(t time.Time, b Bucket) time.Time {
+	day := startOfDay(t, t.Location())
+	if b == BucketWeek {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+func nextBucket(start time.Time, b Bucket) time.Time {
+	if b == BucketWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func median(ds []time.Duration) (time.Duration, bool) {
+	if len(ds) == 0 {
+		return 0, false
+	}
+	ds = slices.Clone(ds)
+	slices.Sort(ds)
+	mid := len(ds) / 2
+	if len(ds)%2 == 1 {
+		return ds[mid], true
+	}
+	return (ds[mid-1] + ds[mid]) / 2, true
+}
@@ -0,0 +1,102 @@
+// Package blobstore keeps attachment content outside the task store.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+var _ task.BlobStore = (*Local)(nil)
+
+var errInvalidKey = errors.New("invalid blob key")
+
+// Local stores each blob as a file under a root directory, using the key as
+// a slash-separated relative path.
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+	return &Local{root: root}, nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers never
+// see partial content and a failed upload leaves nothing behind.
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("create blob dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("create temp blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("sync blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("close blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("rename blob: %w", err)
+	}
+	return n, nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, model.ErrNotFound
+	}
+	return f, err
+}
+
+// Delete also removes the key's parent directory once it is empty.
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != l.root {
+		_ = os.Remove(dir) // fails harmlessly while other blobs remain
+	}
+	return nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", fmt.Errorf("%w: %q", errInvalidKey, key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
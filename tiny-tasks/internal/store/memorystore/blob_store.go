@@ -0,0 +1,54 @@
+package memorystore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+var _ task.BlobStore = (*BlobStore)(nil)
+
+// BlobStore keeps attachment content in memory, alongside a TaskStore created
+// with NewTaskStore.
+type BlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewBlobStore() *BlobStore {
+	return &BlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *BlobStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *BlobStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
@@ -0,0 +1,91 @@
+package memorystore
+
+import (
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+func (s *TaskStore) Changes(since task.ChangeToken, userID string) (task.Changes, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// A token ahead of the store comes from before a reset of the data that
+	// kept the epoch, e.g. a data directory restored from a backup.
+	out := task.Changes{
+		Token: task.ChangeToken{Epoch: s.epoch, Seq: s.seq},
+		Reset: since.Epoch != s.epoch || since.Seq == 0 || since.Seq > s.seq ||
+			s.visibilityChangedLocked(userID, since.Seq),
+	}
+	for _, t := range s.tasks {
+		if out.Reset || t.Version > since.Seq {
+			out.Tasks = append(out.Tasks, t)
+		}
+	}
+	if out.Reset {
+		return out, nil
+	}
+	for _, ts := range s.tombstones {
+		if ts.Version > since.Seq {
+			out.Deleted = append(out.Deleted, ts)
+		}
+	}
+	return out, nil
+}
+
+// tombstone keeps what authorization needs to decide who hears about the
+// delete.
+func tombstone(t model.Task, seq uint64) task.Tombstone {
+	return task.Tombstone{
+		ID:      t.ID,
+		Version: seq,
+		Task:    model.Task{ID: t.ID, OwnerID: t.OwnerID, TeamID: t.TeamID, Assignee: t.Assignee},
+	}
+}
+
+// visibilityChangedLocked reports whether what userID may view changed after
+// seq in a way a delta cannot express.
+func (s *TaskStore) visibilityChangedLocked(userID string, seq uint64) bool {
+	if userID == "" {
+		// Anonymous callers only see unowned tasks, which never move.
+		return false
+	}
+	return seq < s.visibilityFloor || s.visibilityChanged[userID] > seq
+}
+
+// notePlacementLocked records, for a task written as t at seq, who can no
+// longer see it: a previous assignee and the members of a previous team.
+// Those who gain it get it as a change anyway.
+func (s *TaskStore) notePlacementLocked(old, t model.Task, seq uint64) {
+	lost := func(userID string) {
+		if userID == t.OwnerID || (userID == t.Assignee && t.TeamID == "") {
+			return
+		}
+		if _, ok := s.teams[t.TeamID].Members[userID]; ok && t.TeamID != "" {
+			return
+		}
+		s.visibilityChanged[userID] = seq
+	}
+	if old.Assignee != "" && old.Assignee != t.Assignee {
+		lost(old.Assignee)
+	}
+	if old.TeamID != "" && old.TeamID != t.TeamID {
+		for userID := range s.teams[old.TeamID].Members {
+			lost(userID)
+		}
+	}
+}
+
+// noteMembershipLocked records the users who joined or left a team at seq:
+// the former gain tasks changed long ago, the latter lose some.
+func (s *TaskStore) noteMembershipLocked(old, team model.Team, seq uint64) {
+	for userID := range old.Members {
+		if _, ok := team.Members[userID]; !ok {
+			s.visibilityChanged[userID] = seq
+		}
+	}
+	for userID := range team.Members {
+		if _, ok := old.Members[userID]; !ok {
+			s.visibilityChanged[userID] = seq
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package memorystore
+
+import (
+	"sort"
+	"time"
+
+	"tiny-tasks/internal/ids"
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+var (
+	_ task.CommentRepository    = (*TaskStore)(nil)
+	_ task.AttachmentRepository = (*TaskStore)(nil)
+)
+
+func (s *TaskStore) CreateComment(taskID, author, body string) (model.Comment, error) {
+	now := time.Now().UTC()
+	c := model.Comment{
+		ID:        ids.NewID(),
+		TaskID:    taskID,
+		Author:    author,
+		Body:      body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return model.Comment{}, model.ErrNotFound
+	}
+	if err := s.putCommentLocked(c); err != nil {
+		return model.Comment{}, err
+	}
+	return c, nil
+}
+
+func (s *TaskStore) ListComments(taskID string) ([]model.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.Comment, 0, len(s.comments[taskID]))
+	for _, c := range s.comments[taskID] {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *TaskStore) GetComment(taskID, id string) (model.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[taskID][id]
+	if !ok {
+		return model.Comment{}, model.ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *TaskStore) UpdateComment(taskID, id, body string) (model.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[taskID][id]
+	if !ok {
+		return model.Comment{}, model.ErrNotFound
+	}
+	c.Body = body
+	c.UpdatedAt = time.Now().UTC()
+	if err := s.putCommentLocked(c); err != nil {
+		return model.Comment{}, err
+	}
+	return c, nil
+}
+
+func (s *TaskStore) DeleteComment(taskID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[taskID][id]; !ok {
+		return model.ErrNotFound
+	}
+	if err := s.appendLocked(walRecord{Op: walOpCommentDelete, ID: id, TaskID: taskID}); err != nil {
+		return err
+	}
+	delete(s.comments[taskID], id)
+	return nil
+}
+
+func (s *TaskStore) CreateAttachment(a model.Attachment) (model.Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[a.TaskID]; !ok {
+		return model.Attachment{}, model.ErrNotFound
+	}
+	if err := s.appendLocked(walRecord{Op: walOpAttachmentPut, ID: a.ID, TaskID: a.TaskID, Attachment: &a}); err != nil {
+		return model.Attachment{}, err
+	}
+	s.storeAttachment(a)
+	return a, nil
+}
+
+func (s *TaskStore) ListAttachments(taskID string) ([]model.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.Attachment, 0, len(s.attachments[taskID]))
+	for _, a := range s.attachments[taskID] {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *TaskStore) GetAttachment(taskID, id string) (model.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.attachments[taskID][id]
+	if !ok {
+		return model.Attachment{}, model.ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *TaskStore) DeleteAttachment(taskID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.attachments[taskID][id]; !ok {
+		return model.ErrNotFound
+	}
+	if err := s.appendLocked(walRecord{Op: walOpAttachmentDelete, ID: id, TaskID: taskID}); err != nil {
+		return err
+	}
+	delete(s.attachments[taskID], id)
+	return nil
+}
+
+// putCommentLocked logs c and then stores it. Callers must hold s.mu for writing.
+func (s *TaskStore) putCommentLocked(c model.Comment) error {
+	if err := s.appendLocked(walRecord{Op: walOpCommentPut, ID: c.ID, TaskID: c.TaskID, Comment: &c}); err != nil {
+		return err
+	}
+	s.storeComment(c)
+	return nil
+}
+
+func (s *TaskStore) storeComment(c model.Comment) {
+	byID := s.comments[c.TaskID]
+	if byID == nil {
+		byID = make(map[string]model.Comment)
+		s.comments[c.TaskID] = byID
+	}
+	byID[c.ID] = c
+}
+
+func (s *TaskStore) storeAttachment(a model.Attachment) {
+	byID := s.attachments[a.TaskID]
+	if byID == nil {
+		byID = make(map[string]model.Attachment)
+		s.attachments[a.TaskID] = byID
+	}
+	byID[a.ID] = a
+}
@@ -0,0 +1,232 @@
+package memorystore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+// ErrClosed is returned by writes to a store after Close; they would
+// otherwise only live in memory and be lost.
+var ErrClosed = errors.New("task store is closed")
+
+const (
+	snapshotFileName = "tasks.snapshot"
+	walFileName      = "tasks.wal"
+	epochFileName    = "tasks.epoch"
+)
+
+// Open returns a TaskStore that survives restarts. State is loaded from the
+// snapshot in dir and every change recorded in the write-ahead log since that
+// snapshot is replayed on top of it.
+func Open(dir string) (*TaskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	snap, err := readSnapshot(filepath.Join(dir, snapshotFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	watermark, err := readWatermark(filepath.Join(dir, watermarkFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewTaskStore()
+	s.dir = dir
+	if s.epoch, err = loadEpoch(filepath.Join(dir, epochFileName), s.epoch); err != nil {
+		return nil, err
+	}
+	s.watermark = watermark
+	s.seq = snap.seq
+	for _, t := range snap.tasks {
+		s.tasks[t.ID] = t
+		s.noteClientIDLocked(t)
+	}
+	for _, team := range snap.teams {
+		s.teams[team.ID] = team
+	}
+	for _, c := range snap.comments {
+		s.storeComment(c)
+	}
+	for _, a := range snap.attachments {
+		s.storeAttachment(a)
+	}
+	for _, ts := range snap.tombstones {
+		s.tombstones[ts.ID] = ts
+	}
+	for userID, seq := range snap.visibility {
+		s.visibilityChanged[userID] = seq
+	}
+	s.visibilityFloor = snap.visibilityFloor
+
+	w, err := openWAL(filepath.Join(dir, walFileName))
+	if err != nil {
+		return nil, err
+	}
+	err = w.replay(func(rec walRecord) {
+		// Records at or below the snapshot sequence are already in it; they
+		// remain when a crash hits between the snapshot and the log reset.
+		if rec.Seq <= s.seq {
+			return
+		}
+		s.applyRecord(rec)
+	})
+	if err != nil {
+		w.close()
+		return nil, err
+	}
+
+	s.log = w
+	if err := s.initPositions(); err != nil {
+		w.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadEpoch returns the epoch stored at path, storing fresh there first if
+// the directory has none yet: its data starts a new history.
+func loadEpoch(path, fresh string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if epoch := strings.TrimSpace(string(data)); epoch != "" {
+			return epoch, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("read epoch: %w", err)
+	}
+	if err := writeFileAtomic(path, []byte(fresh+"\n"), "epoch"); err != nil {
+		return "", err
+	}
+	return fresh, nil
+}
+
+// initPositions finds where new tasks go and gives a position to tasks stored
+// before positions existed.
+func (s *TaskStore) initPositions() error {
+	s.rebuildIndexLocked()
+	for _, t := range s.tasks {
+		s.notePositionLocked(t.Position)
+	}
+	return s.assignMissingPositionsLocked()
+}
+
+func (s *TaskStore) applyRecord(rec walRecord) {
+	switch rec.Op {
+	case walOpPut:
+		if rec.Task != nil {
+			if old, ok := s.tasks[rec.ID]; ok {
+				s.notePlacementLocked(old, *rec.Task, rec.Seq)
+			}
+			s.tasks[rec.ID] = *rec.Task
+			s.noteClientIDLocked(*rec.Task)
+		}
+	case walOpDelete:
+		s.deleteTaskLocked(rec.ID, rec.Seq)
+	case walOpTeam:
+		if rec.Team != nil {
+			if old, ok := s.teams[rec.ID]; ok {
+				s.noteMembershipLocked(old, *rec.Team, rec.Seq)
+			}
+			s.teams[rec.ID] = *rec.Team
+		}
+	case walOpCommentPut:
+		if rec.Comment != nil {
+			s.storeComment(*rec.Comment)
+		}
+	case walOpCommentDelete:
+		delete(s.comments[rec.TaskID], rec.ID)
+	case walOpAttachmentPut:
+		if rec.Attachment != nil {
+			s.storeAttachment(*rec.Attachment)
+		}
+	case walOpAttachmentDelete:
+		delete(s.attachments[rec.TaskID], rec.ID)
+	}
+	s.seq = rec.Seq
+}
+
+// Snapshot writes the full state to disk and resets the write-ahead log.
+// It is a no-op for stores created with NewTaskStore.
+func (s *TaskStore) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.log == nil {
+		return nil
+	}
+
+	tasks := make([]model.Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, t)
+	}
+
+	teams := make([]model.Team, 0, len(s.teams))
+	for _, team := range s.teams {
+		teams = append(teams, team)
+	}
+
+	var comments []model.Comment
+	for _, byID := range s.comments {
+		for _, c := range byID {
+			comments = append(comments, c)
+		}
+	}
+
+	var attachments []model.Attachment
+	for _, byID := range s.attachments {
+		for _, a := range byID {
+			attachments = append(attachments, a)
+		}
+	}
+
+	tombstones := make([]task.Tombstone, 0, len(s.tombstones))
+	for _, ts := range s.tombstones {
+		tombstones = append(tombstones, ts)
+	}
+
+	snap := snapshot{
+		seq:         s.seq,
+		tasks:       tasks,
+		teams:       teams,
+		comments:    comments,
+		attachments: attachments,
+		tombstones:  tombstones,
+		visibility:  s.visibilityChanged,
+
+		visibilityFloor: s.visibilityFloor,
+	}
+	if err := writeSnapshot(filepath.Join(s.dir, snapshotFileName), snap); err != nil {
+		return err
+	}
+	return s.log.truncate(0)
+}
+
+// Close releases the write-ahead log. It does not take a snapshot. Reads
+// keep working; writes fail with ErrClosed.
+func (s *TaskStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.close()
+	s.log = nil
+	return err
+}
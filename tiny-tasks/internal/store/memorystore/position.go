@@ -0,0 +1,229 @@
+package memorystore
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/position"
+	"tiny-tasks/internal/task"
+)
+
+// maxPositionLen bounds key growth. Repeated inserts into the same gap add a
+// digit every few moves; past this length the moved task's list gets fresh,
+// short keys.
+const maxPositionLen = 32
+
+func (s *TaskStore) Move(id, beforeID, afterID string) (model.Task, []model.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[id]
+	if !ok {
+		return model.Task{}, nil, model.ErrNotFound
+	}
+
+	key, err := s.keyBetweenLocked(id, beforeID, afterID)
+	if err != nil {
+		return model.Task{}, nil, err
+	}
+	var shifted []model.Task
+	if len(key) > maxPositionLen {
+		// The gap is between the neighbours, which may sit in other lists.
+		lists := []model.Task{t}
+		for _, neighbour := range []string{beforeID, afterID} {
+			if n, ok := s.tasks[neighbour]; ok {
+				lists = append(lists, n)
+			}
+		}
+		if shifted, err = s.rebalanceLocked(id, lists); err != nil {
+			return model.Task{}, nil, err
+		}
+		if key, err = s.keyBetweenLocked(id, beforeID, afterID); err != nil {
+			return model.Task{}, nil, err
+		}
+	}
+
+	t = s.tasks[id]
+	t.Position = key
+	t.UpdatedAt = time.Now().UTC()
+	if err := s.putLocked(&t, t.UpdatedAt); err != nil {
+		return model.Task{}, nil, err
+	}
+	s.notePositionLocked(key)
+	return t, shifted, nil
+}
+
+// keyBetweenLocked finds the bounds for the moved task. With both neighbours
+// given this only looks at them; with one, the other side is its current
+// neighbour in the list, found in the position index.
+func (s *TaskStore) keyBetweenLocked(id, beforeID, afterID string) (string, error) {
+	var lo, hi string
+	if afterID != "" {
+		after, ok := s.tasks[afterID]
+		if !ok {
+			return "", model.ErrNotFound
+		}
+		lo = after.Position
+	}
+	if beforeID != "" {
+		before, ok := s.tasks[beforeID]
+		if !ok {
+			return "", model.ErrNotFound
+		}
+		hi = before.Position
+	}
+
+	switch {
+	case afterID == "":
+		lo = s.neighbourLocked(id, hi, false)
+	case beforeID == "":
+		hi = s.neighbourLocked(id, lo, true)
+	}
+
+	key, err := position.Between(lo, hi)
+	if err != nil {
+		return "", task.ErrInvalidMove
+	}
+	return key, nil
+}
+
+// neighbourLocked returns the closest position above (or below) pos, ignoring
+// the task being moved, or "" at the end of the list.
+func (s *TaskStore) neighbourLocked(skipID, pos string, above bool) string {
+	i, _ := slices.BinarySearchFunc(s.order, pos, func(e positionEntry, pos string) int {
+		return strings.Compare(e.pos, pos)
+	})
+	if above {
+		for ; i < len(s.order); i++ {
+			if e := s.order[i]; e.pos > pos && e.id != skipID {
+				return e.pos
+			}
+		}
+		return ""
+	}
+	for i--; i >= 0; i-- {
+		if e := s.order[i]; e.id != skipID {
+			return e.pos
+		}
+	}
+	return ""
+}
+
+// positionEntry is one task in the position index, which is sorted by
+// position and then id.
+type positionEntry struct {
+	pos string
+	id  string
+}
+
+func comparePositionEntries(a, b positionEntry) int {
+	if c := strings.Compare(a.pos, b.pos); c != 0 {
+		return c
+	}
+	return strings.Compare(a.id, b.id)
+}
+
+func (s *TaskStore) indexLocked(t model.Task) {
+	e := positionEntry{pos: t.Position, id: t.ID}
+	i, found := slices.BinarySearchFunc(s.order, e, comparePositionEntries)
+	if !found {
+		s.order = slices.Insert(s.order, i, e)
+	}
+}
+
+func (s *TaskStore) unindexLocked(t model.Task) {
+	e := positionEntry{pos: t.Position, id: t.ID}
+	if i, found := slices.BinarySearchFunc(s.order, e, comparePositionEntries); found {
+		s.order = slices.Delete(s.order, i, i+1)
+	}
+}
+
+func (s *TaskStore) rebuildIndexLocked() {
+	s.order = make([]positionEntry, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		s.order = append(s.order, positionEntry{pos: t.Position, id: t.ID})
+	}
+	slices.SortFunc(s.order, comparePositionEntries)
+}
+
+// nextPositionLocked returns a key after every existing task. position.After
+// keeps appended keys short, so creating a task never renumbers others.
+func (s *TaskStore) nextPositionLocked() (string, error) {
+	return position.After(s.lastPosition)
+}
+
+func (s *TaskStore) notePositionLocked(key string) {
+	if key > s.lastPosition {
+		s.lastPosition = key
+	}
+}
+
+// sameList reports whether a and b are ordered against each other: the
+// tasks of one team, or the personal tasks of one owner.
+func sameList(a, b model.Task) bool {
+	if a.TeamID != "" || b.TeamID != "" {
+		return a.TeamID == b.TeamID
+	}
+	return a.OwnerID == b.OwnerID
+}
+
+// rebalanceLocked gives every task in the lists of the given tasks a fresh,
+// evenly spaced key in the current order and returns those other than
+// skipID. Tasks of other lists keep their keys; keys only order tasks within
+// a list.
+func (s *TaskStore) rebalanceLocked(skipID string, lists []model.Task) ([]model.Task, error) {
+	inLists := func(t model.Task) bool {
+		return slices.ContainsFunc(lists, func(l model.Task) bool { return sameList(l, t) })
+	}
+	var list []model.Task
+	for _, e := range s.order {
+		if t := s.tasks[e.id]; inLists(t) {
+			list = append(list, t)
+		}
+	}
+
+	shifted := make([]model.Task, 0, len(list))
+	for i, key := range position.Spread(len(list)) {
+		t := list[i]
+		t.Position = key
+		if err := s.putLocked(&t, time.Time{}); err != nil {
+			return nil, err
+		}
+		s.notePositionLocked(key)
+		if t.ID != skipID {
+			shifted = append(shifted, t)
+		}
+	}
+	return shifted, nil
+}
+
+// assignMissingPositionsLocked appends tasks stored before positions existed
+// to the end, oldest first.
+func (s *TaskStore) assignMissingPositionsLocked() error {
+	var missing []model.Task
+	for _, t := range s.tasks {
+		if t.Position == "" {
+			missing = append(missing, t)
+		}
+	}
+	slices.SortFunc(missing, func(a, b model.Task) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	for _, t := range missing {
+		key, err := s.nextPositionLocked()
+		if err != nil {
+			return err
+		}
+		t.Position = key
+		if err := s.putLocked(&t, time.Time{}); err != nil {
+			return err
+		}
+		s.notePositionLocked(key)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package memorystore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The watermark changes on every scheduler pass, far more often than the
+// data, so it lives in its own small file rather than in the write-ahead log.
+const watermarkFileName = "reminders.watermark"
+
+// ReminderWatermark returns the time up to which reminders have been fired,
+// or zero if none was recorded.
+func (s *TaskStore) ReminderWatermark() (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.watermark, nil
+}
+
+// SetReminderWatermark records that reminders due up to t have been fired.
+func (s *TaskStore) SetReminderWatermark(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	t = t.UTC()
+	if s.dir != "" {
+		path := filepath.Join(s.dir, watermarkFileName)
+		if err := writeFileAtomic(path, []byte(t.Format(time.RFC3339Nano)+"\n"), "reminder watermark"); err != nil {
+			return err
+		}
+	}
+	s.watermark = t
+	return nil
+}
+
+// readWatermark returns zero when the file does not exist yet.
+func readWatermark(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("read reminder watermark: %w", err)
+	}
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse reminder watermark: %w", err)
+	}
+	return t, nil
+}
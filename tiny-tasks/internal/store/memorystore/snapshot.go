@@ -0,0 +1,224 @@
+package memorystore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+// Version 2 added teams, version 3 comments and attachments, version 4
+// tombstones, version 5 visibility changes and version 6 the visibility
+// floor; older files are still read.
+const snapshotVersion = 6
+
+var (
+	ErrSnapshotVersion  = errors.New("unsupported snapshot version")
+	ErrSnapshotChecksum = errors.New("snapshot checksum mismatch")
+)
+
+// snapshotFile is the on-disk layout. Checksum is the SHA-256 of the raw
+// Tasks, Teams, Comments, Attachments, Tombstones, Visibility and
+// VisibilityFloor bytes in that order, so the envelope can change without
+// invalidating older files.
+type snapshotFile struct {
+	Version     int             `json:"version"`
+	Seq         uint64          `json:"seq"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Checksum    string          `json:"checksum"`
+	Tasks       json.RawMessage `json:"tasks"`
+	Teams       json.RawMessage `json:"teams,omitempty"`
+	Comments    json.RawMessage `json:"comments,omitempty"`
+	Attachments json.RawMessage `json:"attachments,omitempty"`
+	Tombstones  json.RawMessage `json:"tombstones,omitempty"`
+	Visibility  json.RawMessage `json:"visibility,omitempty"`
+	// VisibilityFloor is only written when set.
+	VisibilityFloor json.RawMessage `json:"visibility_floor,omitempty"`
+}
+
+type snapshot struct {
+	seq         uint64
+	tasks       []model.Task
+	teams       []model.Team
+	comments    []model.Comment
+	attachments []model.Attachment
+	tombstones  []task.Tombstone
+	visibility  map[string]uint64
+	// visibilityFloor is set once a file predating the visibility section
+	// was loaded: every user's view may have changed up to its seq. Later
+	// snapshots carry it on.
+	visibilityFloor uint64
+}
+
+func snapshotChecksum(parts ...json.RawMessage) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeSnapshot(path string, snap snapshot) error {
+	tasks, err := json.Marshal(snap.tasks)
+	if err != nil {
+		return fmt.Errorf("encode tasks: %w", err)
+	}
+	teams, err := json.Marshal(snap.teams)
+	if err != nil {
+		return fmt.Errorf("encode teams: %w", err)
+	}
+	comments, err := json.Marshal(snap.comments)
+	if err != nil {
+		return fmt.Errorf("encode comments: %w", err)
+	}
+	attachments, err := json.Marshal(snap.attachments)
+	if err != nil {
+		return fmt.Errorf("encode attachments: %w", err)
+	}
+	tombstones, err := json.Marshal(snap.tombstones)
+	if err != nil {
+		return fmt.Errorf("encode tombstones: %w", err)
+	}
+	visibility, err := json.Marshal(snap.visibility)
+	if err != nil {
+		return fmt.Errorf("encode visibility: %w", err)
+	}
+	var floor json.RawMessage
+	if snap.visibilityFloor > 0 {
+		if floor, err = json.Marshal(snap.visibilityFloor); err != nil {
+			return fmt.Errorf("encode visibility floor: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(snapshotFile{
+		Version:     snapshotVersion,
+		Seq:         snap.seq,
+		CreatedAt:   time.Now().UTC(),
+		Checksum:    snapshotChecksum(tasks, teams, comments, attachments, tombstones, visibility, floor),
+		Tasks:       tasks,
+		Teams:       teams,
+		Comments:    comments,
+		Attachments: attachments,
+		Tombstones:  tombstones,
+		Visibility:  visibility,
+
+		VisibilityFloor: floor,
+	})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	return writeFileAtomic(path, data, "snapshot")
+}
+
+// writeFileAtomic replaces the file at path with data so that a crash leaves
+// either the old or the new contents. what names the file in errors.
+func writeFileAtomic(path string, data []byte, what string) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp %s: %w", what, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync %s: %w", what, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", what, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename %s: %w", what, err)
+	}
+	return syncDir(dir)
+}
+
+// readSnapshot returns an empty snapshot when the file does not exist yet.
+func readSnapshot(path string) (snapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot{}, nil
+	}
+	if err != nil {
+		return snapshot{}, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var f snapshotFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return snapshot{}, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if f.Version < 1 || f.Version > snapshotVersion {
+		return snapshot{}, fmt.Errorf("%w: %d", ErrSnapshotVersion, f.Version)
+	}
+	// Sections newer than the file's version would be unchecked.
+	if f.Version < 2 {
+		f.Teams = nil
+	}
+	if f.Version < 3 {
+		f.Comments, f.Attachments = nil, nil
+	}
+	if f.Version < 4 {
+		f.Tombstones = nil
+	}
+	if f.Version < 5 {
+		f.Visibility = nil
+	}
+	if f.Version < 6 {
+		f.VisibilityFloor = nil
+	}
+
+	if snapshotChecksum(f.Tasks, f.Teams, f.Comments, f.Attachments, f.Tombstones, f.Visibility, f.VisibilityFloor) != f.Checksum {
+		return snapshot{}, ErrSnapshotChecksum
+	}
+
+	var tasks []model.Task
+	if err := json.Unmarshal(f.Tasks, &tasks); err != nil {
+		return snapshot{}, fmt.Errorf("decode tasks: %w", err)
+	}
+	snap := snapshot{seq: f.Seq, tasks: tasks}
+	if f.Version < 5 {
+		snap.visibilityFloor = f.Seq
+	}
+	sections := []struct {
+		name string
+		raw  json.RawMessage
+		dst  any
+	}{
+		{"teams", f.Teams, &snap.teams},
+		{"comments", f.Comments, &snap.comments},
+		{"attachments", f.Attachments, &snap.attachments},
+		{"tombstones", f.Tombstones, &snap.tombstones},
+		{"visibility", f.Visibility, &snap.visibility},
+		{"visibility floor", f.VisibilityFloor, &snap.visibilityFloor},
+	}
+	for _, sec := range sections {
+		if len(sec.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(sec.raw, sec.dst); err != nil {
+			return snapshot{}, fmt.Errorf("decode %s: %w", sec.name, err)
+		}
+	}
+	return snap, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -1,6 +1,8 @@
 package memorystore
 
 import (
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,34 +17,122 @@ var _ task.TaskRepository = (*TaskStore)(nil)
 type TaskStore struct {
 	mu    sync.RWMutex
 	tasks map[string]model.Task
+	teams map[string]model.Team
+
+	// The highest task position handed out; new tasks go after it.
+	lastPosition string
+	// order indexes the tasks by position for moves next to one neighbour.
+	order []positionEntry
+
+	// Keyed by task id, then by record id.
+	comments    map[string]map[string]model.Comment
+	attachments map[string]map[string]model.Attachment
+
+	// seq counts writes; it is the change sequence behind task versions and
+	// sync tokens. Deleted tasks leave a tombstone carrying the seq of the
+	// delete.
+	seq        uint64
+	tombstones map[string]task.Tombstone
+	// visibilityChanged holds, per user, the seq of the last write that may
+	// have taken tasks out of their view or shown them tasks changed earlier:
+	// joining or leaving a team, or a task leaving their team or assignment.
+	// No delta can be computed for their tokens from before it. Snapshots
+	// older than this field leave visibilityFloor standing in for everyone.
+	visibilityChanged map[string]uint64
+	visibilityFloor   uint64
+	// clientIDs finds the task an owner created under a sync client id.
+	clientIDs map[clientKey]string
+	// epoch tells this store's seq apart from that of other stores; see
+	// task.ChangeToken.
+	epoch string
+
+	// watermark is how far the reminder scheduler has fired.
+	watermark time.Time
+
+	// Set only for stores returned by Open; nil means purely in-memory.
+	log *wal
+	dir string
+	// closed is set by Close; writes fail with ErrClosed from then on.
+	closed bool
 }
 
 func NewTaskStore() *TaskStore {
-	return &TaskStore{tasks: make(map[string]model.Task)}
+	return &TaskStore{
+		tasks:       make(map[string]model.Task),
+		teams:       make(map[string]model.Team),
+		comments:    make(map[string]map[string]model.Comment),
+		attachments: make(map[string]map[string]model.Attachment),
+		tombstones:  make(map[string]task.Tombstone),
+		epoch:       ids.NewID(),
+
+		visibilityChanged: make(map[string]uint64),
+		clientIDs:         make(map[clientKey]string),
+	}
 }
 
-func (s *TaskStore) Create(title string) (model.Task, error) {
+type clientKey struct {
+	ownerID  string
+	clientID string
+}
+
+// noteClientIDLocked indexes t by its client id, if it has one.
+func (s *TaskStore) noteClientIDLocked(t model.Task) {
+	if t.ClientID != "" {
+		s.clientIDs[clientKey{t.OwnerID, t.ClientID}] = t.ID
+	}
+}
+
+func (s *TaskStore) Create(in task.NewTask) (model.Task, error) {
 	now := time.Now().UTC()
 	t := model.Task{
-		ID:          ids.NewID(),
-		Title:       strings.TrimSpace(title),
-		CreatedAt:   now,
-		UpdatedAt:   now,
-		CompletedAt: nil,
+		ID:              ids.NewID(),
+		Title:           strings.TrimSpace(in.Title),
+		DueAt:           utcPtr(in.DueAt),
+		Tags:            slices.Clone(in.Tags),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		ReminderOffsets: slices.Clone(in.ReminderOffsets),
+		OwnerID:         in.OwnerID,
+		TeamID:          in.TeamID,
+		Assignee:        in.Assignee,
+		CompletedAt:     nil,
+		ClientID:        in.ClientID,
+	}
+	changedAt := now
+	if !in.ChangedAt.IsZero() {
+		changedAt = in.ChangedAt.UTC()
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.tasks[t.ID] = t
+
+	if in.ClientID != "" {
+		if id, ok := s.clientIDs[clientKey{in.OwnerID, in.ClientID}]; ok {
+			return s.tasks[id], task.ErrCreateReplayed
+		}
+	}
+
+	key, err := s.nextPositionLocked()
+	if err != nil {
+		return model.Task{}, err
+	}
+	t.Position = key
+	if err := s.putLocked(&t, changedAt, model.EditableFields...); err != nil {
+		return model.Task{}, err
+	}
+	s.notePositionLocked(key)
 	return t, nil
 }
 
-func (s *TaskStore) List() ([]model.Task, error) {
+func (s *TaskStore) List(filter task.Filter) ([]model.Task, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	out := make([]model.Task, 0, len(s.tasks))
 	for _, t := range s.tasks {
+		if filter != nil && !filter.Match(t) {
+			continue
+		}
 		out = append(out, t)
 	}
 	return out, nil
@@ -59,7 +149,7 @@ func (s *TaskStore) Get(id string) (model.Task, error) {
 	return t, nil
 }
 
-func (s *TaskStore) Update(id string, title *string, completed *bool) (model.Task, error) {
+func (s *TaskStore) Update(id string, upd task.TaskUpdate) (model.Task, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -67,13 +157,38 @@ func (s *TaskStore) Update(id string, title *string, completed *bool) (model.Tas
 	if !ok {
 		return model.Task{}, model.ErrNotFound
 	}
+	if upd.IfVersion != 0 && t.Version != upd.IfVersion {
+		return model.Task{}, task.ErrStaleVersion
+	}
+
+	if upd.Title != nil {
+		t.Title = strings.TrimSpace(*upd.Title)
+	}
 
-	if title != nil {
-		t.Title = strings.TrimSpace(*title)
+	if upd.ClearDueAt {
+		t.DueAt = nil
+	} else if upd.DueAt != nil {
+		t.DueAt = utcPtr(upd.DueAt)
 	}
 
-	if completed != nil {
-		if *completed {
+	if upd.Tags != nil {
+		t.Tags = slices.Clone(*upd.Tags)
+	}
+
+	if upd.ReminderOffsets != nil {
+		t.ReminderOffsets = slices.Clone(*upd.ReminderOffsets)
+	}
+
+	if upd.TeamID != nil {
+		t.TeamID = *upd.TeamID
+	}
+
+	if upd.Assignee != nil {
+		t.Assignee = *upd.Assignee
+	}
+
+	if upd.Completed != nil {
+		if *upd.Completed {
 			if t.CompletedAt == nil {
 				now := time.Now().UTC()
 				t.CompletedAt = &now
@@ -84,17 +199,98 @@ func (s *TaskStore) Update(id string, title *string, completed *bool) (model.Tas
 	}
 
 	t.UpdatedAt = time.Now().UTC()
-	s.tasks[id] = t
+	changedAt := upd.ChangedAt
+	if changedAt.IsZero() {
+		changedAt = t.UpdatedAt
+	}
+	if err := s.putLocked(&t, changedAt.UTC(), upd.Fields()...); err != nil {
+		return model.Task{}, err
+	}
 	return t, nil
 }
 
-func (s *TaskStore) Delete(id string) error {
+func (s *TaskStore) Delete(id string, ifVersion uint64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.tasks[id]; !ok {
+	t, ok := s.tasks[id]
+	if !ok {
 		return model.ErrNotFound
 	}
+	if ifVersion != 0 && t.Version != ifVersion {
+		return task.ErrStaleVersion
+	}
+	if err := s.appendLocked(walRecord{Op: walOpDelete, ID: id}); err != nil {
+		return err
+	}
+	s.deleteTaskLocked(id, s.seq)
+	return nil
+}
+
+// deleteTaskLocked drops a task and everything attached to it, leaving a
+// tombstone for sync.
+func (s *TaskStore) deleteTaskLocked(id string, seq uint64) {
+	if t, ok := s.tasks[id]; ok {
+		s.tombstones[id] = tombstone(t, seq)
+		s.unindexLocked(t)
+		delete(s.clientIDs, clientKey{t.OwnerID, t.ClientID})
+	}
 	delete(s.tasks, id)
+	delete(s.comments, id)
+	delete(s.attachments, id)
+}
+
+// putLocked versions t, stamps the clocks of the changed fields with at, logs
+// it and then stores it. Callers must hold s.mu for writing.
+func (s *TaskStore) putLocked(t *model.Task, at time.Time, fields ...string) error {
+	t.Version = s.seq + 1
+	if len(fields) > 0 {
+		// Earlier copies of t share the map.
+		t.FieldClocks = maps.Clone(t.FieldClocks)
+		if t.FieldClocks == nil {
+			t.FieldClocks = make(map[string]model.FieldClock, len(fields))
+		}
+		for _, f := range fields {
+			t.FieldClocks[f] = model.FieldClock{Version: t.Version, At: at}
+		}
+	}
+	if err := s.appendLocked(walRecord{Op: walOpPut, ID: t.ID, Task: t}); err != nil {
+		return err
+	}
+	old, ok := s.tasks[t.ID]
+	if ok {
+		s.notePlacementLocked(old, *t, s.seq)
+	}
+	if !ok || old.Position != t.Position {
+		if ok {
+			s.unindexLocked(old)
+		}
+		s.indexLocked(*t)
+	}
+	s.tasks[t.ID] = *t
+	s.noteClientIDLocked(*t)
+	return nil
+}
+
+// appendLocked takes the next sequence number for rec and logs it.
+func (s *TaskStore) appendLocked(rec walRecord) error {
+	if s.closed {
+		return ErrClosed
+	}
+	rec.Seq = s.seq + 1
+	if s.log != nil {
+		if err := s.log.append(rec); err != nil {
+			return err
+		}
+	}
+	s.seq = rec.Seq
 	return nil
 }
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
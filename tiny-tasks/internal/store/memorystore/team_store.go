@@ -0,0 +1,104 @@
+package memorystore
+
+import (
+	"maps"
+	"sort"
+	"time"
+
+	"tiny-tasks/internal/ids"
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/task"
+)
+
+var _ task.TeamRepository = (*TaskStore)(nil)
+
+func (s *TaskStore) CreateTeam(name string, adminID string) (model.Team, error) {
+	now := time.Now().UTC()
+	team := model.Team{
+		ID:        ids.NewID(),
+		Name:      name,
+		Members:   map[string]model.Role{adminID: model.RoleAdmin},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.putTeamLocked(team); err != nil {
+		return model.Team{}, err
+	}
+	return cloneTeam(team), nil
+}
+
+func (s *TaskStore) GetTeam(id string) (model.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	team, ok := s.teams[id]
+	if !ok {
+		return model.Team{}, model.ErrNotFound
+	}
+	return cloneTeam(team), nil
+}
+
+func (s *TaskStore) ListTeams(userID string) ([]model.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.Team, 0)
+	for _, team := range s.teams {
+		if _, ok := team.Members[userID]; ok {
+			out = append(out, cloneTeam(team))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *TaskStore) SetMember(teamID, userID string, role model.Role) (model.Team, error) {
+	return s.updateTeam(teamID, func(members map[string]model.Role) {
+		members[userID] = role
+	})
+}
+
+func (s *TaskStore) RemoveMember(teamID, userID string) (model.Team, error) {
+	return s.updateTeam(teamID, func(members map[string]model.Role) {
+		delete(members, userID)
+	})
+}
+
+func (s *TaskStore) updateTeam(id string, change func(map[string]model.Role)) (model.Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	team, ok := s.teams[id]
+	if !ok {
+		return model.Team{}, model.ErrNotFound
+	}
+	team = cloneTeam(team)
+	change(team.Members)
+	team.UpdatedAt = time.Now().UTC()
+
+	if err := s.putTeamLocked(team); err != nil {
+		return model.Team{}, err
+	}
+	return cloneTeam(team), nil
+}
+
+// putTeamLocked logs team and then stores it. Callers must hold s.mu for writing.
+func (s *TaskStore) putTeamLocked(team model.Team) error {
+	if err := s.appendLocked(walRecord{Op: walOpTeam, ID: team.ID, Team: &team}); err != nil {
+		return err
+	}
+	if old, ok := s.teams[team.ID]; ok {
+		s.noteMembershipLocked(old, team, s.seq)
+	}
+	s.teams[team.ID] = team
+	return nil
+}
+
+// cloneTeam keeps callers from mutating the stored members map.
+func cloneTeam(team model.Team) model.Team {
+	team.Members = maps.Clone(team.Members)
+	return team
+}
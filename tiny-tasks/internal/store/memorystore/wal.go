@@ -0,0 +1,136 @@
+package memorystore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"tiny-tasks/internal/model"
+)
+
+const (
+	walOpPut    = "put"
+	walOpDelete = "delete"
+	walOpTeam   = "team_put"
+
+	walOpCommentPut       = "comment_put"
+	walOpCommentDelete    = "comment_delete"
+	walOpAttachmentPut    = "attachment_put"
+	walOpAttachmentDelete = "attachment_delete"
+)
+
+var errCorruptWAL = errors.New("write-ahead log is corrupt")
+
+// walRecord is one mutation in the write-ahead log. Each record is stored as a
+// single line: "<crc32 hex> <json>\n".
+type walRecord struct {
+	Seq        uint64            `json:"seq"`
+	Op         string            `json:"op"`
+	ID         string            `json:"id"`
+	TaskID     string            `json:"task_id,omitempty"`
+	Task       *model.Task       `json:"task,omitempty"`
+	Team       *model.Team       `json:"team,omitempty"`
+	Comment    *model.Comment    `json:"comment,omitempty"`
+	Attachment *model.Attachment `json:"attachment,omitempty"`
+}
+
+type wal struct {
+	f *os.File
+}
+
+func openWAL(path string) (*wal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	return &wal{f: f}, nil
+}
+
+func (w *wal) append(rec walRecord) error {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode wal record: %w", err)
+	}
+	line := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(body), body)
+	if _, err := w.f.WriteString(line); err != nil {
+		return fmt.Errorf("write wal: %w", err)
+	}
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("sync wal: %w", err)
+	}
+	return nil
+}
+
+// replay calls apply for every record in the log. A damaged record at the very
+// end of the file is treated as a write torn by a crash: it is dropped and the
+// file is truncated back to the last good record. Damage anywhere else is an
+// error.
+func (w *wal) replay(apply func(walRecord)) error {
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek wal: %w", err)
+	}
+
+	r := bufio.NewReader(w.f)
+	var good int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return w.truncate(good)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read wal: %w", err)
+		}
+
+		rec, ok := decodeWALLine(line)
+		if !ok {
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				return w.truncate(good)
+			}
+			return fmt.Errorf("%w at offset %d", errCorruptWAL, good)
+		}
+
+		apply(rec)
+		good += int64(len(line))
+	}
+}
+
+func decodeWALLine(line []byte) (walRecord, bool) {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	sum, body, found := bytes.Cut(line, []byte(" "))
+	if !found {
+		return walRecord{}, false
+	}
+
+	var want uint32
+	if _, err := fmt.Sscanf(string(sum), "%08x", &want); err != nil {
+		return walRecord{}, false
+	}
+	if crc32.ChecksumIEEE(body) != want {
+		return walRecord{}, false
+	}
+
+	var rec walRecord
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return walRecord{}, false
+	}
+	return rec, true
+}
+
+func (w *wal) truncate(size int64) error {
+	if err := w.f.Truncate(size); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	return w.f.Sync()
+}
+
+func (w *wal) close() error {
+	return w.f.Close()
+}
@@ -0,0 +1,137 @@
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"tiny-tasks/internal/ids"
+	"tiny-tasks/internal/model"
+)
+
+// MaxAttachmentSize caps the content of a single attachment.
+const MaxAttachmentSize = 10 << 20
+
+const defaultContentType = "application/octet-stream"
+
+// Attach streams content into the blob store and records it on the task. The
+// size limit is enforced while reading, so oversized uploads are never held in
+// full.
+func (s *Service) Attach(ctx context.Context, taskID, name, contentType string, content io.Reader) (model.Attachment, error) {
+	uploader := UserFrom(ctx)
+	if uploader == "" {
+		return model.Attachment{}, ErrUnauthenticated
+	}
+	valid, err := ValidateFileName(name)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	if err := s.authorizeID(ctx, taskID, ActionEdit); err != nil {
+		return model.Attachment{}, err
+	}
+
+	contentType = strings.TrimSpace(contentType)
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	a := model.Attachment{
+		ID:          ids.NewID(),
+		TaskID:      taskID,
+		Name:        valid,
+		ContentType: contentType,
+		UploadedBy:  uploader,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	hash := sha256.New()
+	body := io.TeeReader(&limitedReader{r: content, n: MaxAttachmentSize}, hash)
+	size, err := s.blobs.Put(ctx, blobKey(a), body)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	a.Size = size
+	a.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	created, err := s.attachments.CreateAttachment(a)
+	if err != nil {
+		// The task may have been deleted while uploading.
+		return model.Attachment{}, errors.Join(err, s.blobs.Delete(ctx, blobKey(a)))
+	}
+	return created, nil
+}
+
+func (s *Service) ListAttachments(ctx context.Context, taskID string) ([]model.Attachment, error) {
+	if err := s.authorizeID(ctx, taskID, ActionView); err != nil {
+		return nil, err
+	}
+	return s.attachments.ListAttachments(taskID)
+}
+
+func (s *Service) GetAttachment(ctx context.Context, taskID, id string) (model.Attachment, error) {
+	if err := s.authorizeID(ctx, taskID, ActionView); err != nil {
+		return model.Attachment{}, err
+	}
+	return s.attachments.GetAttachment(taskID, id)
+}
+
+// OpenAttachment returns the attachment and its content; the caller closes it.
+func (s *Service) OpenAttachment(ctx context.Context, taskID, id string) (model.Attachment, io.ReadCloser, error) {
+	a, err := s.GetAttachment(ctx, taskID, id)
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+	content, err := s.blobs.Get(ctx, blobKey(a))
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+	return a, content, nil
+}
+
+func (s *Service) DeleteAttachment(ctx context.Context, taskID, id string) error {
+	if err := s.authorizeID(ctx, taskID, ActionEdit); err != nil {
+		return err
+	}
+	a, err := s.attachments.GetAttachment(taskID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.attachments.DeleteAttachment(taskID, id); err != nil {
+		return err
+	}
+	return s.blobs.Delete(ctx, blobKey(a))
+}
+
+func (s *Service) authorizeID(ctx context.Context, taskID string, action Action) error {
+	t, err := s.repo.Get(taskID)
+	if err != nil {
+		return err
+	}
+	return s.authorize(ctx, t, action)
+}
+
+func blobKey(a model.Attachment) string {
+	return "attachments/" + a.TaskID + "/" + a.ID
+}
+
+// limitedReader fails with ErrAttachmentTooLarge once more than n bytes have
+// been read, unlike io.LimitReader which silently stops.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return 0, ErrAttachmentTooLarge
+	}
+	return n, err
+}
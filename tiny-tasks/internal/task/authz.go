@@ -0,0 +1,180 @@
+package task
+
+import (
+	"context"
+	"slices"
+
+	"tiny-tasks/internal/model"
+)
+
+type Action string
+
+const (
+	ActionView     Action = "view"
+	ActionEdit     Action = "edit"
+	ActionComplete Action = "complete"
+	ActionDelete   Action = "delete"
+)
+
+var rolePermissions = map[model.Role][]Action{
+	model.RoleAdmin:  {ActionView, ActionEdit, ActionComplete, ActionDelete},
+	model.RoleMember: {ActionView, ActionEdit, ActionComplete},
+	model.RoleViewer: {ActionView},
+}
+
+var assigneePermissions = []Action{ActionView, ActionEdit, ActionComplete}
+
+type actorKey struct{}
+
+type actor struct {
+	userID string
+	system bool
+}
+
+// WithUser marks ctx as acting for userID. Transports call it with the
+// identity established by whatever authenticates requests in front of them.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor{userID: userID})
+}
+
+// AsSystem lets background jobs such as the reminder scheduler see every task.
+func AsSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor{system: true})
+}
+
+// UserFrom returns the user ctx acts for, or "" when anonymous.
+func UserFrom(ctx context.Context) string {
+	a, _ := ctx.Value(actorKey{}).(actor)
+	return a.userID
+}
+
+func actorFrom(ctx context.Context) actor {
+	a, _ := ctx.Value(actorKey{}).(actor)
+	return a
+}
+
+// can decides whether a may perform action on t. Tasks without an owner were
+// created anonymously and stay open to everyone.
+func (s *Service) can(a actor, t model.Task, action Action) (bool, error) {
+	if a.system || t.OwnerID == "" {
+		return true, nil
+	}
+	if a.userID == "" {
+		return false, nil
+	}
+	if t.OwnerID == a.userID {
+		return true, nil
+	}
+	assigned := t.Assignee == a.userID && slices.Contains(assigneePermissions, action)
+	if t.TeamID == "" {
+		return assigned, nil
+	}
+
+	// On a team task, assignees keep their rights only while they are
+	// members; someone removed from the team loses the task with it.
+	team, err := s.teams.GetTeam(t.TeamID)
+	if err != nil {
+		return false, err
+	}
+	role, ok := team.Members[a.userID]
+	return ok && (assigned || slices.Contains(rolePermissions[role], action)), nil
+}
+
+func (s *Service) authorize(ctx context.Context, t model.Task, action Action) error {
+	ok, err := s.can(actorFrom(ctx), t, action)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// visibility narrows listings to what the caller may view.
+func (s *Service) visibility(ctx context.Context) (Filter, error) {
+	a := actorFrom(ctx)
+	if a.system {
+		return nil, nil
+	}
+	v := VisibleTo{UserID: a.userID}
+	if a.userID == "" {
+		return v, nil
+	}
+
+	teams, err := s.teams.ListTeams(a.userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		v.TeamIDs = append(v.TeamIDs, team.ID)
+	}
+	return v, nil
+}
+
+// canPlace decides whether the caller may change the team or assignee of
+// current: moving a task or handing it over is up to its owner and the
+// admins of its team, not to everyone who may edit it.
+func (s *Service) canPlace(ctx context.Context, current model.Task) error {
+	a := actorFrom(ctx)
+	if a.system || a.userID == current.OwnerID {
+		return nil
+	}
+	if a.userID == "" {
+		return ErrUnauthenticated
+	}
+	if current.TeamID == "" {
+		return ErrForbidden
+	}
+	team, err := s.teams.GetTeam(current.TeamID)
+	if err != nil {
+		return err
+	}
+	if team.Members[a.userID] != model.RoleAdmin {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// checkPlacement validates the team and assignee a task of ownerID is about
+// to get: the caller must be able to write in the team, and the assignee
+// must belong to it or, on a personal task, share a team with the owner.
+func (s *Service) checkPlacement(ctx context.Context, ownerID, teamID, assignee string) error {
+	if teamID == "" {
+		return s.checkPersonalAssignee(ownerID, assignee)
+	}
+	team, err := s.teams.GetTeam(teamID)
+	if err != nil {
+		return err
+	}
+
+	a := actorFrom(ctx)
+	if !a.system {
+		role, ok := team.Members[a.userID]
+		if !ok || !slices.Contains(rolePermissions[role], ActionEdit) {
+			return ErrForbidden
+		}
+	}
+	if assignee != "" {
+		if _, ok := team.Members[assignee]; !ok {
+			return ErrAssigneeNotInTeam
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkPersonalAssignee(ownerID, assignee string) error {
+	if assignee == "" || assignee == ownerID {
+		return nil
+	}
+	teams, err := s.teams.ListTeams(ownerID)
+	if err != nil {
+		return err
+	}
+	for _, team := range teams {
+		if _, ok := team.Members[assignee]; ok {
+			return nil
+		}
+	}
+	return ErrAssigneeNotKnown
+}
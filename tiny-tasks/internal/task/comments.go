@@ -0,0 +1,77 @@
+package task
+
+import (
+	"context"
+
+	"tiny-tasks/internal/model"
+)
+
+// AddComment needs a user; anyone who can view the task may comment on it.
+func (s *Service) AddComment(ctx context.Context, taskID, body string) (model.Comment, error) {
+	author := UserFrom(ctx)
+	if author == "" {
+		return model.Comment{}, ErrUnauthenticated
+	}
+	valid, err := ValidateComment(body)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	if _, err := s.Get(ctx, taskID); err != nil {
+		return model.Comment{}, err
+	}
+	return s.comments.CreateComment(taskID, author, valid)
+}
+
+func (s *Service) ListComments(ctx context.Context, taskID string) ([]model.Comment, error) {
+	if _, err := s.Get(ctx, taskID); err != nil {
+		return nil, err
+	}
+	return s.comments.ListComments(taskID)
+}
+
+func (s *Service) GetComment(ctx context.Context, taskID, id string) (model.Comment, error) {
+	if _, err := s.Get(ctx, taskID); err != nil {
+		return model.Comment{}, err
+	}
+	return s.comments.GetComment(taskID, id)
+}
+
+// EditComment is reserved to the comment's author.
+func (s *Service) EditComment(ctx context.Context, taskID, id, body string) (model.Comment, error) {
+	valid, err := ValidateComment(body)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	current, err := s.GetComment(ctx, taskID, id)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	if !isAuthor(ctx, current) {
+		return model.Comment{}, ErrForbidden
+	}
+	return s.comments.UpdateComment(taskID, id, valid)
+}
+
+// DeleteComment is allowed to the author and to whoever may delete the task,
+// so moderators can remove comments.
+func (s *Service) DeleteComment(ctx context.Context, taskID, id string) error {
+	t, err := s.Get(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	current, err := s.comments.GetComment(taskID, id)
+	if err != nil {
+		return err
+	}
+	if !isAuthor(ctx, current) {
+		if err := s.authorize(ctx, t, ActionDelete); err != nil {
+			return err
+		}
+	}
+	return s.comments.DeleteComment(taskID, id)
+}
+
+func isAuthor(ctx context.Context, c model.Comment) bool {
+	a := actorFrom(ctx)
+	return a.system || (a.userID != "" && a.userID == c.Author)
+}
@@ -1,8 +1,84 @@
 package task
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"tiny-tasks/internal/model"
+)
 
 var (
-	ErrInvalidTitle    = errors.New("title must be at least 3 characters")
-	ErrNoFieldsToPatch = errors.New("provide at least one field: title or completed")
+	ErrInvalidTitle      = errors.New("title must be at least 3 characters")
+	ErrInvalidTag        = errors.New("tags must be 1-32 characters of letters, digits, '-' or '_'")
+	ErrInvalidReminder   = errors.New("reminder_offsets must hold at most 5 durations between 0 and 720h")
+	ErrNoFieldsToPatch   = errors.New("provide at least one field: title, completed, due_at, tags, reminder_offsets, team_id or assignee")
+	ErrInvalidTeamName   = errors.New("team name must be 1-64 characters")
+	ErrInvalidRole       = errors.New("role must be admin, member or viewer")
+	ErrInvalidMember     = errors.New("user id must not be empty")
+	ErrAssigneeNotInTeam = errors.New("assignee must be a member of the task's team")
+	ErrAssigneeNotKnown  = errors.New("assignee of a personal task must share a team with its owner")
+	ErrInvalidComment    = errors.New("comment body must be 1-10000 characters")
+	ErrInvalidFileName   = errors.New("attachment name must be 1-255 characters without slashes")
+	ErrInvalidMove       = errors.New("provide before and/or after: ids of other tasks, in list order")
+	ErrInvalidSort       = errors.New("sort must be position")
+	ErrInvalidMutation   = errors.New("op must be create, update or delete")
+
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the size limit")
+	ErrSyncBatchTooLarge  = fmt.Errorf("a sync batch holds at most %d mutations", MaxSyncBatch)
+
+	ErrUnauthenticated = errors.New("this operation requires a user")
+	ErrForbidden       = errors.New("not allowed")
+	ErrLastAdmin       = errors.New("a team needs at least one admin")
+	ErrStaleVersion    = errors.New("task changed since it was read")
+
+	// ErrCreateReplayed comes with the task a repeated create already made;
+	// the Service answers it as a success.
+	ErrCreateReplayed = errors.New("task already created with this client id")
 )
+
+// ErrorKind groups service errors so every transport maps them the same way.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindInvalid
+	KindNotFound
+	KindUnauthenticated
+	KindForbidden
+	KindConflict
+	KindTooLarge
+)
+
+func KindOf(err error) ErrorKind {
+	switch {
+	case errors.Is(err, ErrInvalidTitle),
+		errors.Is(err, ErrInvalidTag),
+		errors.Is(err, ErrInvalidReminder),
+		errors.Is(err, ErrNoFieldsToPatch),
+		errors.Is(err, ErrInvalidTeamName),
+		errors.Is(err, ErrInvalidRole),
+		errors.Is(err, ErrInvalidMember),
+		errors.Is(err, ErrAssigneeNotInTeam),
+		errors.Is(err, ErrAssigneeNotKnown),
+		errors.Is(err, ErrInvalidComment),
+		errors.Is(err, ErrInvalidFileName),
+		errors.Is(err, ErrInvalidMove),
+		errors.Is(err, ErrInvalidSort),
+		errors.Is(err, ErrInvalidMutation):
+		return KindInvalid
+	case errors.Is(err, model.ErrNotFound):
+		return KindNotFound
+	case errors.Is(err, ErrUnauthenticated):
+		return KindUnauthenticated
+	case errors.Is(err, ErrForbidden):
+		return KindForbidden
+	case errors.Is(err, ErrLastAdmin),
+		errors.Is(err, ErrStaleVersion):
+		return KindConflict
+	case errors.Is(err, ErrAttachmentTooLarge),
+		errors.Is(err, ErrSyncBatchTooLarge):
+		return KindTooLarge
+	default:
+		return KindInternal
+	}
+}
@@ -0,0 +1,148 @@
+package task
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"tiny-tasks/internal/model"
+)
+
+// Filter is a node of a task filter tree. Repositories either evaluate it with
+// Match or walk the concrete node types below, e.g. to build a SQL WHERE clause.
+// A nil Filter matches every task.
+type Filter interface {
+	Match(t model.Task) bool
+}
+
+type And []Filter
+
+func (f And) Match(t model.Task) bool {
+	for _, sub := range f {
+		if !sub.Match(t) {
+			return false
+		}
+	}
+	return true
+}
+
+type Or []Filter
+
+func (f Or) Match(t model.Task) bool {
+	for _, sub := range f {
+		if sub.Match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+type Not struct {
+	Filter Filter
+}
+
+func (f Not) Match(t model.Task) bool {
+	return !f.Filter.Match(t)
+}
+
+type Completed bool
+
+func (f Completed) Match(t model.Task) bool {
+	return (t.CompletedAt != nil) == bool(f)
+}
+
+// HasTag expects a normalized (lowercase) tag.
+type HasTag string
+
+func (f HasTag) Match(t model.Task) bool {
+	return slices.Contains(t.Tags, string(f))
+}
+
+// TitleContains matches case-insensitively.
+type TitleContains string
+
+func (f TitleContains) Match(t model.Task) bool {
+	return strings.Contains(strings.ToLower(t.Title), strings.ToLower(string(f)))
+}
+
+// Overdue matches open tasks whose due date is before Now.
+type Overdue struct {
+	Now time.Time
+}
+
+func (f Overdue) Match(t model.Task) bool {
+	return t.CompletedAt == nil && t.DueAt != nil && t.DueAt.Before(f.Now)
+}
+
+type TimeField string
+
+const (
+	FieldCreatedAt   TimeField = "created_at"
+	FieldUpdatedAt   TimeField = "updated_at"
+	FieldCompletedAt TimeField = "completed_at"
+	FieldDueAt       TimeField = "due_at"
+)
+
+// TimeRange matches tasks whose Field lies in [From, To). A nil bound is open;
+// tasks without a value for Field (e.g. not completed) never match.
+type TimeRange struct {
+	Field TimeField
+	From  *time.Time
+	To    *time.Time
+}
+
+func (f TimeRange) Match(t model.Task) bool {
+	v := timeField(t, f.Field)
+	if v == nil {
+		return false
+	}
+	if f.From != nil && v.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !v.Before(*f.To) {
+		return false
+	}
+	return true
+}
+
+func timeField(t model.Task, field TimeField) *time.Time {
+	switch field {
+	case FieldCreatedAt:
+		return &t.CreatedAt
+	case FieldUpdatedAt:
+		return &t.UpdatedAt
+	case FieldCompletedAt:
+		return t.CompletedAt
+	case FieldDueAt:
+		return t.DueAt
+	default:
+		return nil
+	}
+}
+
+type AssignedTo string
+
+func (f AssignedTo) Match(t model.Task) bool {
+	return t.Assignee == string(f)
+}
+
+// VisibleTo matches tasks a user may view: unowned tasks, their own, those
+// of their teams and personal tasks assigned to them. An empty UserID sees
+// only unowned tasks.
+type VisibleTo struct {
+	UserID  string
+	TeamIDs []string
+}
+
+func (f VisibleTo) Match(t model.Task) bool {
+	if t.OwnerID == "" {
+		return true
+	}
+	if f.UserID == "" {
+		return false
+	}
+	if t.TeamID != "" {
+		return t.OwnerID == f.UserID || slices.Contains(f.TeamIDs, t.TeamID)
+	}
+	return t.OwnerID == f.UserID || t.Assignee == f.UserID
+}
@@ -0,0 +1,308 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const maxFilterExprLen = 512
+
+// ParseFilterExpr parses the filter mini language of task lists into a
+// filter tree.
+//
+//	expr   = or
+//	or     = and { "OR" and }
+//	and    = unary { ["AND"] unary }
+//	unary  = "NOT" unary | "(" expr ")" | term
+//	term   = field op value
+//
+// Fields: completed, overdue (true/false); tag, title (":" only); created,
+// updated, completed, due (dates, with ":" meaning "on that day" or one of
+// > >= < <=). Values containing spaces can be double-quoted.
+// Example: completed:false AND (tag:work OR title:"buy milk").
+//
+// A timestamp offset like +02:00 sent unescaped in a query string arrives
+// as " 02:00"; it is read as +02:00, see parseTimestamp. Calendar days are
+// interpreted in loc and overdue is relative to now.
+func ParseFilterExpr(expr string, loc *time.Location, now time.Time) (Filter, error) {
+	if len(expr) > maxFilterExprLen {
+		return nil, fmt.Errorf("filter must be at most %d characters", maxFilterExprLen)
+	}
+	toks, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{toks: toks, loc: loc, now: now}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("filter: unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+type filterToken struct {
+	text   string
+	quoted bool // contains a quoted value, so never a keyword
+}
+
+func tokenizeFilter(s string) ([]filterToken, error) {
+	var toks []filterToken
+	var cur strings.Builder
+	quoted := false
+
+	flush := func() {
+		if cur.Len() > 0 || quoted {
+			toks = append(toks, filterToken{text: cur.String(), quoted: quoted})
+		}
+		cur.Reset()
+		quoted = false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' && isSpacedOffset(cur.String(), s[i+1:]):
+			cur.WriteByte('+')
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '(' || c == ')':
+			flush()
+			toks = append(toks, filterToken{text: string(c)})
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("filter: unterminated quote")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			quoted = true
+			i += end + 1
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+
+	if len(toks) == 0 {
+		return nil, errors.New("filter: empty expression")
+	}
+	return toks, nil
+}
+
+type filterParser struct {
+	toks []filterToken
+	pos  int
+	loc  *time.Location
+	now  time.Time
+}
+
+func (p *filterParser) done() bool { return p.pos >= len(p.toks) }
+
+func (p *filterParser) peek() filterToken { return p.toks[p.pos] }
+
+func (p *filterParser) isKeyword(kw string) bool {
+	if p.done() {
+		return false
+	}
+	t := p.peek()
+	return !t.quoted && strings.EqualFold(t.text, kw)
+}
+
+func (p *filterParser) isSymbol(sym string) bool {
+	return !p.done() && !p.peek().quoted && p.peek().text == sym
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	out := Or{first}
+	for p.isKeyword("OR") {
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next)
+	}
+	if len(out) == 1 {
+		return first, nil
+	}
+	return out, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	out := And{first}
+	for !p.done() && !p.isKeyword("OR") && !p.isSymbol(")") {
+		if p.isKeyword("AND") {
+			p.pos++
+		}
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next)
+	}
+	if len(out) == 1 {
+		return first, nil
+	}
+	return out, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.done() {
+		return nil, errors.New("filter: unexpected end of expression")
+	}
+	switch {
+	case p.isKeyword("NOT"):
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Filter: inner}, nil
+	case p.isSymbol("("):
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isSymbol(")") {
+			return nil, errors.New("filter: missing )")
+		}
+		p.pos++
+		return inner, nil
+	case p.isSymbol(")"), p.isKeyword("AND"), p.isKeyword("OR"):
+		return nil, fmt.Errorf("filter: unexpected %q", p.peek().text)
+	}
+
+	tok := p.peek()
+	p.pos++
+	return p.parseTerm(tok.text)
+}
+
+var filterOps = []string{">=", "<=", ":", ">", "<"}
+
+var filterTimeFields = map[string]TimeField{
+	"created":   FieldCreatedAt,
+	"updated":   FieldUpdatedAt,
+	"completed": FieldCompletedAt,
+	"due":       FieldDueAt,
+}
+
+func (p *filterParser) parseTerm(term string) (Filter, error) {
+	i := strings.IndexAny(term, ":<>")
+	if i <= 0 {
+		return nil, fmt.Errorf("filter: %q is not field:value", term)
+	}
+	field := strings.ToLower(term[:i])
+	var op string
+	for _, candidate := range filterOps {
+		if strings.HasPrefix(term[i:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	value := term[i+len(op):]
+	if value == "" {
+		return nil, fmt.Errorf("filter: missing value for %s", field)
+	}
+
+	switch field {
+	case "completed", "overdue":
+		if b, err := ParseBool(value); err == nil {
+			if op != ":" {
+				return nil, fmt.Errorf("filter: %s only supports ':'", field)
+			}
+			var f Filter = Completed(true)
+			if field == "overdue" {
+				f = Overdue{Now: p.now}
+			}
+			if !b {
+				f = Not{Filter: f}
+			}
+			return f, nil
+		}
+		if field == "overdue" {
+			return nil, errors.New("filter: overdue must be true or false")
+		}
+	case "tag":
+		if op != ":" {
+			return nil, errors.New("filter: tag only supports ':'")
+		}
+		return HasTag(strings.ToLower(value)), nil
+	case "title":
+		if op != ":" {
+			return nil, errors.New("filter: title only supports ':'")
+		}
+		return TitleContains(value), nil
+	}
+
+	tf, ok := filterTimeFields[field]
+	if !ok {
+		return nil, fmt.Errorf("filter: unknown field %q", field)
+	}
+	return p.parseTimeTerm(tf, field, op, value)
+}
+
+func (p *filterParser) parseTimeTerm(tf TimeField, field, op, value string) (Filter, error) {
+	// A calendar day covers [start, end); a timestamp is a single instant.
+	var start, end time.Time
+	if ts, err := parseTimestamp(value); err == nil {
+		start, end = ts, ts.Add(time.Nanosecond)
+	} else if s, e, err := ParseDayRange(value, p.loc); err == nil {
+		start, end = s, e
+	} else {
+		return nil, fmt.Errorf("filter: %s needs YYYY-MM-DD or RFC 3339, got %q", field, value)
+	}
+
+	r := TimeRange{Field: tf}
+	switch op {
+	case ":":
+		r.From, r.To = &start, &end
+	case ">":
+		r.From = &end
+	case ">=":
+		r.From = &start
+	case "<":
+		r.To = &start
+	case "<=":
+		r.To = &end
+	}
+	return r, nil
+}
+
+var (
+	timeOfDaySuffix = regexp.MustCompile(`T\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+	offsetPrefix    = regexp.MustCompile(`^\d{2}:\d{2}([\s()]|$)`)
+	spacedOffset    = regexp.MustCompile(`^(.*T\d{2}:\d{2}:\d{2}(?:\.\d+)?) (\d{2}:\d{2})$`)
+)
+
+// isSpacedOffset reports whether a space between before and after is the
+// "+" of a timestamp offset, lost to form decoding.
+func isSpacedOffset(before, after string) bool {
+	return timeOfDaySuffix.MatchString(before) && offsetPrefix.MatchString(after)
+}
+
+// parseTimestamp parses RFC 3339. A positive offset whose "+" was decoded
+// to a space, as happens to an unescaped "+02:00" in a query string, is
+// accepted too.
+func parseTimestamp(v string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if m := spacedOffset.FindStringSubmatch(v); m != nil {
+			return time.Parse(time.RFC3339, m[1]+"+"+m[2])
+		}
+	}
+	return t, err
+}
@@ -0,0 +1,158 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrAssigneeMeWithoutUser is returned for assignee=me from an anonymous
+// caller; transports say how to send a user.
+var ErrAssigneeMeWithoutUser = errors.New("assignee=me requires a user")
+
+// ListQuery holds the list filters every transport accepts, as sent.
+// Calendar days (CompletedOn, YYYY-MM-DD bounds, dates inside Expr) are
+// interpreted in TZ, which defaults to UTC. Lower bounds are inclusive,
+// upper bounds exclusive.
+type ListQuery struct {
+	TZ          string
+	Completed   *bool
+	CompletedOn string
+	// The bounds are YYYY-MM-DD or RFC 3339.
+	CompletedAfter  string
+	CompletedBefore string
+	CreatedAfter    string
+	CreatedBefore   string
+	UpdatedSince    string
+	// Assignee "me" refers to the caller.
+	Assignee string
+	// Expr is a filter expression, see ParseFilterExpr.
+	Expr string
+}
+
+// Filter turns q into a single filter for userID. A nil filter means
+// "everything".
+func (q ListQuery) Filter(userID string, now time.Time) (Filter, error) {
+	loc, err := ParseLocation(q.TZ)
+	if err != nil {
+		return nil, err
+	}
+
+	var filters And
+
+	if q.Completed != nil {
+		filters = append(filters, Completed(*q.Completed))
+	}
+
+	if q.CompletedOn != "" {
+		start, end, err := ParseDayRange(q.CompletedOn, loc)
+		if err != nil {
+			return nil, errors.New("completed_on must be YYYY-MM-DD")
+		}
+		filters = append(filters, TimeRange{Field: FieldCompletedAt, From: &start, To: &end})
+	}
+
+	bounds := []struct {
+		name  string
+		value string
+		field TimeField
+		upper bool
+	}{
+		{"completed_after", q.CompletedAfter, FieldCompletedAt, false},
+		{"completed_before", q.CompletedBefore, FieldCompletedAt, true},
+		{"created_after", q.CreatedAfter, FieldCreatedAt, false},
+		{"created_before", q.CreatedBefore, FieldCreatedAt, true},
+		{"updated_since", q.UpdatedSince, FieldUpdatedAt, false},
+	}
+	for _, b := range bounds {
+		if b.value == "" {
+			continue
+		}
+		ts, err := parseTimeBound(b.value, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be YYYY-MM-DD or RFC 3339", b.name)
+		}
+		r := TimeRange{Field: b.field}
+		if b.upper {
+			r.To = &ts
+		} else {
+			r.From = &ts
+		}
+		filters = append(filters, r)
+	}
+
+	if assignee := strings.TrimSpace(q.Assignee); assignee != "" {
+		if assignee == "me" {
+			if userID == "" {
+				return nil, ErrAssigneeMeWithoutUser
+			}
+			assignee = userID
+		}
+		filters = append(filters, AssignedTo(assignee))
+	}
+
+	if q.Expr != "" {
+		f, err := ParseFilterExpr(q.Expr, loc, now)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	switch len(filters) {
+	case 0:
+		return nil, nil
+	case 1:
+		return filters[0], nil
+	default:
+		return filters, nil
+	}
+}
+
+// ParseBool accepts only true or false, in any case.
+func ParseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, errors.New("not a bool")
+	}
+}
+
+// ParseDayRange returns [start, end) of the calendar day in loc. The day is
+// not always 24h long when loc observes DST.
+func ParseDayRange(day string, loc *time.Location) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", day, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end := start.AddDate(0, 0, 1)
+	return start, end, nil
+}
+
+// ParseLocation accepts an IANA zone name such as Europe/Warsaw and defaults
+// to UTC.
+func ParseLocation(tz string) (*time.Location, error) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, errors.New("tz must be an IANA time zone such as Europe/Warsaw")
+	}
+	return loc, nil
+}
+
+// parseTimeBound accepts an RFC 3339 timestamp or a calendar day, which
+// means midnight at the start of that day in loc.
+func parseTimeBound(v string, loc *time.Location) (time.Time, error) {
+	if t, err := parseTimestamp(v); err == nil {
+		return t, nil
+	}
+	start, _, err := ParseDayRange(v, loc)
+	return start, err
+}
@@ -0,0 +1,72 @@
+package task
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"tiny-tasks/internal/model"
+)
+
+type SortOrder string
+
+const (
+	SortNone     SortOrder = ""
+	SortPosition SortOrder = "position"
+)
+
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch order := SortOrder(strings.TrimSpace(s)); order {
+	case SortNone, SortPosition:
+		return order, nil
+	default:
+		return "", ErrInvalidSort
+	}
+}
+
+// Sort orders tasks in place.
+func Sort(tasks []model.Task, order SortOrder) {
+	if order == SortPosition {
+		slices.SortFunc(tasks, func(a, b model.Task) int {
+			if c := strings.Compare(a.Position, b.Position); c != 0 {
+				return c
+			}
+			return strings.Compare(a.ID, b.ID)
+		})
+	}
+}
+
+// Move places the task between its new neighbours. Usually only the moved
+// task changes; when its list runs out of room the others in it get new
+// positions too, and are published as updated. The caller needs edit rights
+// on the task and must be able to see the neighbours.
+func (s *Service) Move(ctx context.Context, id, beforeID, afterID string) (model.Task, error) {
+	beforeID, afterID = strings.TrimSpace(beforeID), strings.TrimSpace(afterID)
+	if (beforeID == "" && afterID == "") || beforeID == id || afterID == id || (beforeID != "" && beforeID == afterID) {
+		return model.Task{}, ErrInvalidMove
+	}
+
+	if err := s.authorizeID(ctx, id, ActionEdit); err != nil {
+		return model.Task{}, err
+	}
+	for _, neighbour := range []string{beforeID, afterID} {
+		if neighbour == "" {
+			continue
+		}
+		if err := s.authorizeID(ctx, neighbour, ActionView); err != nil {
+			return model.Task{}, err
+		}
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	moved, shifted, err := s.repo.Move(id, beforeID, afterID)
+	if err != nil {
+		return model.Task{}, err
+	}
+	for _, t := range shifted {
+		s.events.publish(Event{Type: EventUpdated, Task: t})
+	}
+	s.events.publish(Event{Type: EventUpdated, Task: moved})
+	return moved, nil
+}
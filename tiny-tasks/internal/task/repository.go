@@ -1,11 +1,195 @@
 package task
 
-import "tiny-tasks/internal/model"
+import (
+	"context"
+	"io"
+	"time"
+
+	"tiny-tasks/internal/model"
+)
+
+type NewTask struct {
+	Title           string
+	DueAt           *time.Time
+	Tags            []string
+	ReminderOffsets []model.Duration
+	OwnerID         string
+	TeamID          string
+	Assignee        string
+	// ClientID makes the create idempotent for its owner, see
+	// ErrCreateReplayed.
+	ClientID string
+	// ChangedAt is when the task was made, for the field clocks; zero
+	// means now.
+	ChangedAt time.Time
+}
+
+// TaskUpdate is a partial update: nil fields are left unchanged.
+type TaskUpdate struct {
+	Title           *string
+	Completed       *bool
+	DueAt           *time.Time
+	ClearDueAt      bool
+	Tags            *[]string
+	ReminderOffsets *[]model.Duration
+	TeamID          *string
+	Assignee        *string
+
+	// ChangedAt is when the change was made, for the field clocks; zero
+	// means now.
+	ChangedAt time.Time
+	// IfVersion, when set, applies the update only while the task is still
+	// at that version; otherwise it fails with ErrStaleVersion.
+	IfVersion uint64
+}
+
+func (u TaskUpdate) IsEmpty() bool {
+	return u.Title == nil && u.Completed == nil && u.DueAt == nil && !u.ClearDueAt &&
+		u.Tags == nil && u.ReminderOffsets == nil && u.TeamID == nil && u.Assignee == nil
+}
+
+// onlyCompletion reports whether u just toggles completion, which needs less
+// permission than other edits.
+func (u TaskUpdate) onlyCompletion() bool {
+	return u.Completed != nil && u.Title == nil && u.DueAt == nil && !u.ClearDueAt &&
+		u.Tags == nil && u.ReminderOffsets == nil && u.TeamID == nil && u.Assignee == nil
+}
+
+// Fields returns the model.Field* names of the fields u changes.
+func (u TaskUpdate) Fields() []string {
+	var fields []string
+	for _, f := range model.EditableFields {
+		if u.changes(f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func (u TaskUpdate) changes(field string) bool {
+	switch field {
+	case model.FieldTitle:
+		return u.Title != nil
+	case model.FieldCompleted:
+		return u.Completed != nil
+	case model.FieldDueAt:
+		return u.DueAt != nil || u.ClearDueAt
+	case model.FieldTags:
+		return u.Tags != nil
+	case model.FieldReminderOffsets:
+		return u.ReminderOffsets != nil
+	case model.FieldTeamID:
+		return u.TeamID != nil
+	case model.FieldAssignee:
+		return u.Assignee != nil
+	default:
+		return false
+	}
+}
+
+// without returns u minus the change to field.
+func (u TaskUpdate) without(field string) TaskUpdate {
+	switch field {
+	case model.FieldTitle:
+		u.Title = nil
+	case model.FieldCompleted:
+		u.Completed = nil
+	case model.FieldDueAt:
+		u.DueAt, u.ClearDueAt = nil, false
+	case model.FieldTags:
+		u.Tags = nil
+	case model.FieldReminderOffsets:
+		u.ReminderOffsets = nil
+	case model.FieldTeamID:
+		u.TeamID = nil
+	case model.FieldAssignee:
+		u.Assignee = nil
+	}
+	return u
+}
+
+// ChangeToken marks a point in a repository's change history. Epoch names
+// the history: a store that starts over with fresh sequence numbers, like an
+// in-memory store after a restart, also starts a new epoch, so older tokens
+// are recognised as foreign instead of being read against the wrong data.
+type ChangeToken struct {
+	Epoch string
+	Seq   uint64
+}
+
+// Changes is what a repository reports for a change token. Reset means the
+// token was empty or unknown and Tasks holds every task instead of a delta.
+type Changes struct {
+	Token   ChangeToken
+	Reset   bool
+	Tasks   []model.Task
+	Deleted []Tombstone
+}
+
+// Tombstone records a deleted task. Task keeps only the fields that decide
+// who may see it.
+type Tombstone struct {
+	ID      string     `json:"id"`
+	Version uint64     `json:"version"`
+	Task    model.Task `json:"task"`
+}
 
 type TaskRepository interface {
-	Create(title string) (model.Task, error)
-	List() ([]model.Task, error)
+	// Create stores a new task. If the owner already has a task created
+	// with in.ClientID, it returns that task instead, with
+	// ErrCreateReplayed.
+	Create(in NewTask) (model.Task, error)
+	List(filter Filter) ([]model.Task, error)
 	Get(id string) (model.Task, error)
-	Update(id string, title *string, completed *bool) (model.Task, error)
-	Delete(id string) error
+	Update(id string, upd TaskUpdate) (model.Task, error)
+	// Move gives the task a position between the tasks afterID and beforeID.
+	// With only one of them the task lands right next to it. New tasks are
+	// placed at the end. Tasks of the same lists given new positions to make
+	// room are returned after the moved one.
+	Move(id, beforeID, afterID string) (model.Task, []model.Task, error)
+	// Delete removes the task. A non-zero ifVersion is checked as in
+	// TaskUpdate.IfVersion.
+	Delete(id string, ifVersion uint64) error
+	// Changes returns the tasks written and deleted after the change token
+	// since, together with the current token. Every write, including moves,
+	// bumps the task's Version. It resets when what userID may view changed
+	// after since other than through those writes, e.g. by joining or
+	// leaving a team.
+	Changes(since ChangeToken, userID string) (Changes, error)
+}
+
+type TeamRepository interface {
+	CreateTeam(name string, adminID string) (model.Team, error)
+	GetTeam(id string) (model.Team, error)
+	// ListTeams returns the teams userID belongs to.
+	ListTeams(userID string) ([]model.Team, error)
+	SetMember(teamID, userID string, role model.Role) (model.Team, error)
+	RemoveMember(teamID, userID string) (model.Team, error)
+}
+
+// CommentRepository and AttachmentRepository store per-task records; deleting
+// a task through TaskRepository removes its records as well.
+type CommentRepository interface {
+	CreateComment(taskID, author, body string) (model.Comment, error)
+	// ListComments returns the comments of a task, oldest first.
+	ListComments(taskID string) ([]model.Comment, error)
+	GetComment(taskID, id string) (model.Comment, error)
+	UpdateComment(taskID, id, body string) (model.Comment, error)
+	DeleteComment(taskID, id string) error
+}
+
+type AttachmentRepository interface {
+	CreateAttachment(a model.Attachment) (model.Attachment, error)
+	// ListAttachments returns the attachments of a task, oldest first.
+	ListAttachments(taskID string) ([]model.Attachment, error)
+	GetAttachment(taskID, id string) (model.Attachment, error)
+	DeleteAttachment(taskID, id string) error
+}
+
+// BlobStore holds attachment content. Get returns model.ErrNotFound for an
+// unknown key; Delete of an unknown key is not an error.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
 }
@@ -1,57 +1,285 @@
 package task
 
-import "tiny-tasks/internal/model"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"tiny-tasks/internal/model"
+)
 
 type Service struct {
-	repo TaskRepository
+	repo        TaskRepository
+	teams       TeamRepository
+	comments    CommentRepository
+	attachments AttachmentRepository
+	blobs       BlobStore
+	events      *broker
+
+	// writeMu is held from a repository write until its event is published,
+	// so watchers see the changes to a task in the order they were made.
+	writeMu sync.Mutex
+}
+
+// Deps are the stores a Service works on. All of them are required; a single
+// store may well implement several of the repositories.
+type Deps struct {
+	Tasks       TaskRepository
+	Teams       TeamRepository
+	Comments    CommentRepository
+	Attachments AttachmentRepository
+	Blobs       BlobStore
 }
 
-func NewService(repo TaskRepository) *Service {
-	return &Service{repo: repo}
+func NewService(deps Deps) *Service {
+	return &Service{
+		repo:        deps.Tasks,
+		teams:       deps.Teams,
+		comments:    deps.Comments,
+		attachments: deps.Attachments,
+		blobs:       deps.Blobs,
+		events:      newBroker(),
+	}
 }
 
-func (s *Service) Create(title string) (model.Task, error) {
-	valid, err := ValidateTitle(title)
+func (s *Service) Create(ctx context.Context, in NewTask) (model.Task, error) {
+	valid, err := ValidateTitle(in.Title)
+	if err != nil {
+		return model.Task{}, err
+	}
+	in.Title = valid
+
+	if in.Tags != nil {
+		tags, err := ValidateTags(in.Tags)
+		if err != nil {
+			return model.Task{}, err
+		}
+		in.Tags = tags
+	}
+
+	if in.ReminderOffsets != nil {
+		offsets, err := ValidateReminderOffsets(in.ReminderOffsets)
+		if err != nil {
+			return model.Task{}, err
+		}
+		in.ReminderOffsets = offsets
+	}
+
+	in.OwnerID = UserFrom(ctx)
+	if (in.TeamID != "" || in.Assignee != "") && in.OwnerID == "" {
+		return model.Task{}, ErrUnauthenticated
+	}
+	if err := s.checkPlacement(ctx, in.OwnerID, in.TeamID, in.Assignee); err != nil {
+		return model.Task{}, err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	created, err := s.repo.Create(in)
+	if errors.Is(err, ErrCreateReplayed) {
+		return created, nil
+	}
 	if err != nil {
 		return model.Task{}, err
 	}
-	return s.repo.Create(valid)
+	s.events.publish(Event{Type: EventCreated, Task: created})
+	return created, nil
 }
 
-func (s *Service) List() ([]model.Task, error) {
-	return s.repo.List()
+// List returns the tasks matching filter that the caller may view.
+func (s *Service) List(ctx context.Context, filter Filter) ([]model.Task, error) {
+	visible, err := s.visibility(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case visible == nil:
+	case filter == nil:
+		filter = visible
+	default:
+		filter = And{visible, filter}
+	}
+	return s.repo.List(filter)
 }
 
-func (s *Service) Get(id string) (model.Task, error) {
-	return s.repo.Get(id)
+func (s *Service) Get(ctx context.Context, id string) (model.Task, error) {
+	found, err := s.repo.Get(id)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if err := s.authorize(ctx, found, ActionView); err != nil {
+		return model.Task{}, err
+	}
+	return found, nil
 }
 
-func (s *Service) Complete(id string) (model.Task, error) {
+func (s *Service) Complete(ctx context.Context, id string) (model.Task, error) {
 	completed := true
-	return s.repo.Update(id, nil, &completed)
+	return s.update(ctx, id, TaskUpdate{Completed: &completed})
 }
 
-func (s *Service) Undo(id string) (model.Task, error) {
+func (s *Service) Undo(ctx context.Context, id string) (model.Task, error) {
 	completed := false
-	return s.repo.Update(id, nil, &completed)
+	return s.update(ctx, id, TaskUpdate{Completed: &completed})
+}
+
+func (s *Service) Patch(ctx context.Context, id string, upd TaskUpdate) (model.Task, error) {
+	upd, err := validateUpdate(upd)
+	if err != nil {
+		return model.Task{}, err
+	}
+	return s.update(ctx, id, upd)
 }
 
-func (s *Service) Patch(id string, title *string, completed *bool) (model.Task, error) {
-	if title == nil && completed == nil {
-		return model.Task{}, ErrNoFieldsToPatch
+func validateUpdate(upd TaskUpdate) (TaskUpdate, error) {
+	if upd.IsEmpty() {
+		return TaskUpdate{}, ErrNoFieldsToPatch
+	}
+
+	if upd.Title != nil {
+		valid, err := ValidateTitle(*upd.Title)
+		if err != nil {
+			return TaskUpdate{}, err
+		}
+		upd.Title = &valid
+	}
+
+	if upd.Tags != nil {
+		tags, err := ValidateTags(*upd.Tags)
+		if err != nil {
+			return TaskUpdate{}, err
+		}
+		upd.Tags = &tags
 	}
 
-	if title != nil {
-		valid, err := ValidateTitle(*title)
+	if upd.ReminderOffsets != nil {
+		offsets, err := ValidateReminderOffsets(*upd.ReminderOffsets)
 		if err != nil {
+			return TaskUpdate{}, err
+		}
+		upd.ReminderOffsets = &offsets
+	}
+
+	return upd, nil
+}
+
+// update applies upd at upd.IfVersion or, when unset, at whatever version
+// is current, retrying while writes land between its check and its write.
+func (s *Service) update(ctx context.Context, id string, upd TaskUpdate) (model.Task, error) {
+	if upd.IfVersion != 0 {
+		return s.updateChecked(ctx, id, upd)
+	}
+	updated, _, err := retryStale(func() (model.Task, []Conflict, error) {
+		t, err := s.updateChecked(ctx, id, upd)
+		return t, nil, err
+	})
+	return updated, err
+}
+
+// updateChecked authorizes upd against the current task and writes it only
+// at the version that was checked.
+func (s *Service) updateChecked(ctx context.Context, id string, upd TaskUpdate) (model.Task, error) {
+	current, err := s.repo.Get(id)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if upd.IfVersion != 0 && current.Version != upd.IfVersion {
+		return model.Task{}, ErrStaleVersion
+	}
+
+	action := ActionEdit
+	if upd.onlyCompletion() {
+		action = ActionComplete
+	}
+	if err := s.authorize(ctx, current, action); err != nil {
+		return model.Task{}, err
+	}
+
+	if upd.TeamID != nil || upd.Assignee != nil {
+		if current.OwnerID == "" {
+			// Unowned tasks are shared with everyone; handing them to a
+			// team or person would hide them from their other users.
+			return model.Task{}, ErrForbidden
+		}
+		if err := s.canPlace(ctx, current); err != nil {
+			return model.Task{}, err
+		}
+		teamID, assignee := current.TeamID, current.Assignee
+		if upd.TeamID != nil {
+			teamID = *upd.TeamID
+		}
+		if upd.Assignee != nil {
+			assignee = *upd.Assignee
+		}
+		if err := s.checkPlacement(ctx, current.OwnerID, teamID, assignee); err != nil {
 			return model.Task{}, err
 		}
-		title = &valid
 	}
 
-	return s.repo.Update(id, title, completed)
+	upd.IfVersion = current.Version
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	updated, err := s.repo.Update(id, upd)
+	if err != nil {
+		return model.Task{}, err
+	}
+	s.events.publish(Event{Type: EventUpdated, Task: updated})
+	return updated, nil
+}
+
+// Delete removes the task together with its comments and attachments.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.delete(ctx, id, 0)
+}
+
+// delete is Delete, checking ifVersion as the repository does. Blobs normally
+// go first: deleting them is idempotent, so if one fails the task is kept and
+// the caller can simply retry. A versioned delete may still lose to a
+// concurrent write, so there the task goes first and blobs that then fail to
+// delete are only left behind.
+func (s *Service) delete(ctx context.Context, id string, ifVersion uint64) error {
+	current, err := s.repo.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, current, ActionDelete); err != nil {
+		return err
+	}
+
+	attachments, err := s.attachments.ListAttachments(id)
+	if err != nil {
+		return err
+	}
+	deleteBlobs := func() error {
+		for _, a := range attachments {
+			if err := s.blobs.Delete(ctx, blobKey(a)); err != nil {
+				return fmt.Errorf("delete attachment %s: %w", a.ID, err)
+			}
+		}
+		return nil
+	}
+
+	if ifVersion == 0 {
+		if err := deleteBlobs(); err != nil {
+			return err
+		}
+	}
+	if err := s.deleteTask(current, ifVersion); err != nil {
+		return err
+	}
+	if ifVersion != 0 {
+		return deleteBlobs()
+	}
+	return nil
 }
 
-func (s *Service) Delete(id string) error {
-	return s.repo.Delete(id)
+func (s *Service) deleteTask(current model.Task, ifVersion uint64) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.repo.Delete(current.ID, ifVersion); err != nil {
+		return err
+	}
+	s.events.publish(Event{Type: EventDeleted, Task: current})
+	return nil
 }
@@ -0,0 +1,283 @@
+package task
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"tiny-tasks/internal/model"
+)
+
+// MaxSyncBatch caps the mutations accepted by one Sync call.
+const MaxSyncBatch = 500
+
+// maxSyncAttempts bounds how often a mutation is settled again because the
+// task changed between reading its clocks and writing.
+const maxSyncAttempts = 5
+
+type MutationOp string
+
+const (
+	MutationCreate MutationOp = "create"
+	MutationUpdate MutationOp = "update"
+	MutationDelete MutationOp = "delete"
+)
+
+// Mutation is a change a client made while offline.
+type Mutation struct {
+	Op MutationOp
+	// ID names the task to update or delete.
+	ID string
+	// ClientID is echoed in the result so clients can match up creates. A
+	// create sent again with the same ClientID, e.g. after a timeout,
+	// returns the task the first one made.
+	ClientID string
+	// BaseVersion is the task version the client last saw.
+	BaseVersion uint64
+	// ChangedAt is when the client made the change. Zero or future times
+	// mean now.
+	ChangedAt time.Time
+	// Fields holds the new values; creates need at least a title.
+	Fields TaskUpdate
+}
+
+type MutationResult struct {
+	ClientID string
+	// Task is the task after the mutation, or zero when it was deleted or
+	// the mutation failed.
+	Task    model.Task
+	Deleted bool
+	Err     error
+}
+
+type Winner string
+
+const (
+	WinnerClient Winner = "client"
+	WinnerServer Winner = "server"
+)
+
+// Conflict is a field that both the client and, after the client's base
+// version, someone else changed. The later change wins; ties go to the
+// server.
+type Conflict struct {
+	TaskID          string
+	Field           string
+	Winner          Winner
+	ServerChangedAt time.Time
+	ClientChangedAt time.Time
+}
+
+// Changes returns the tasks the caller can view that were written or deleted
+// after the change token since, oldest change first.
+func (s *Service) Changes(ctx context.Context, since ChangeToken) (Changes, error) {
+	changes, err := s.repo.Changes(since, UserFrom(ctx))
+	if err != nil {
+		return Changes{}, err
+	}
+	// Read after the changes: a membership change in between then falls
+	// after the returned token and resets the next call.
+	visible, err := s.visibility(ctx)
+	if err != nil {
+		return Changes{}, err
+	}
+	if visible != nil {
+		changes.Tasks = slices.DeleteFunc(changes.Tasks, func(t model.Task) bool {
+			return !visible.Match(t)
+		})
+		changes.Deleted = slices.DeleteFunc(changes.Deleted, func(ts Tombstone) bool {
+			return !visible.Match(ts.Task)
+		})
+	}
+	slices.SortFunc(changes.Tasks, func(a, b model.Task) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	slices.SortFunc(changes.Deleted, func(a, b Tombstone) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return changes, nil
+}
+
+// Sync applies a batch of offline mutations in order. Each one succeeds or
+// fails on its own; the batch is not atomic. Fields changed concurrently are
+// settled per field by last writer wins, and every such decision is returned.
+func (s *Service) Sync(ctx context.Context, mutations []Mutation) ([]MutationResult, []Conflict, error) {
+	if len(mutations) > MaxSyncBatch {
+		return nil, nil, ErrSyncBatchTooLarge
+	}
+
+	results := make([]MutationResult, len(mutations))
+	var conflicts []Conflict
+	for i, m := range mutations {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		now := time.Now().UTC()
+		if m.ChangedAt.IsZero() || m.ChangedAt.After(now) {
+			m.ChangedAt = now
+		}
+		m.ChangedAt = m.ChangedAt.UTC()
+
+		res := MutationResult{ClientID: m.ClientID}
+		var found []Conflict
+		switch m.Op {
+		case MutationCreate:
+			res.Task, res.Err = s.syncCreate(ctx, m)
+		case MutationUpdate:
+			res.Task, found, res.Err = retryStale(func() (model.Task, []Conflict, error) { return s.syncUpdate(ctx, m) })
+		case MutationDelete:
+			res.Task, found, res.Err = retryStale(func() (model.Task, []Conflict, error) { return s.syncDelete(ctx, m) })
+			res.Deleted = res.Err == nil && res.Task.ID == ""
+		default:
+			res.Err = ErrInvalidMutation
+		}
+		results[i] = res
+		conflicts = append(conflicts, found...)
+	}
+	return results, conflicts, nil
+}
+
+// retryStale runs settle again while the task it read changed before its
+// write. The write only goes through at the version the conflicts were
+// decided on, so no concurrent change is overwritten unseen.
+func retryStale(settle func() (model.Task, []Conflict, error)) (model.Task, []Conflict, error) {
+	for attempt := 1; ; attempt++ {
+		t, conflicts, err := settle()
+		if !errors.Is(err, ErrStaleVersion) || attempt == maxSyncAttempts {
+			return t, conflicts, err
+		}
+	}
+}
+
+func (s *Service) syncCreate(ctx context.Context, m Mutation) (model.Task, error) {
+	f := m.Fields
+	if f.Title == nil {
+		return model.Task{}, ErrInvalidTitle
+	}
+	in := NewTask{Title: *f.Title, DueAt: f.DueAt, ClientID: m.ClientID, ChangedAt: m.ChangedAt}
+	if f.Tags != nil {
+		in.Tags = *f.Tags
+	}
+	if f.ReminderOffsets != nil {
+		in.ReminderOffsets = *f.ReminderOffsets
+	}
+	if f.TeamID != nil {
+		in.TeamID = *f.TeamID
+	}
+	if f.Assignee != nil {
+		in.Assignee = *f.Assignee
+	}
+
+	created, err := s.Create(ctx, in)
+	// A replayed create may have been completed the first time round.
+	if err != nil || f.Completed == nil || (created.CompletedAt != nil) == *f.Completed {
+		return created, err
+	}
+	return s.update(ctx, created.ID, TaskUpdate{Completed: f.Completed, ChangedAt: m.ChangedAt})
+}
+
+func (s *Service) syncUpdate(ctx context.Context, m Mutation) (model.Task, []Conflict, error) {
+	upd, err := validateUpdate(m.Fields)
+	if err != nil {
+		return model.Task{}, nil, err
+	}
+	current, err := s.repo.Get(m.ID)
+	if err != nil {
+		return model.Task{}, nil, err
+	}
+	// Check up front so conflicts are never reported to callers who could
+	// not have made the change.
+	action := ActionEdit
+	if upd.onlyCompletion() {
+		action = ActionComplete
+	}
+	if err := s.authorize(ctx, current, action); err != nil {
+		return model.Task{}, nil, err
+	}
+
+	var conflicts []Conflict
+	for _, field := range upd.Fields() {
+		c, concurrent := conflictOn(current, field, m)
+		if !concurrent {
+			continue
+		}
+		if c.Winner == WinnerServer {
+			upd = upd.without(field)
+		}
+		conflicts = append(conflicts, c)
+	}
+	if upd.IsEmpty() {
+		return current, conflicts, nil
+	}
+
+	upd.ChangedAt = m.ChangedAt
+	upd.IfVersion = current.Version
+	updated, err := s.update(ctx, m.ID, upd)
+	if err != nil {
+		return model.Task{}, nil, err
+	}
+	return updated, conflicts, nil
+}
+
+// syncDelete treats a delete as a change to every field: any later change
+// made on the server keeps the task.
+func (s *Service) syncDelete(ctx context.Context, m Mutation) (model.Task, []Conflict, error) {
+	current, err := s.repo.Get(m.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		return model.Task{}, nil, nil
+	}
+	if err != nil {
+		return model.Task{}, nil, err
+	}
+	if err := s.authorize(ctx, current, ActionDelete); err != nil {
+		return model.Task{}, nil, err
+	}
+
+	var conflicts []Conflict
+	keep := false
+	for _, field := range model.EditableFields {
+		c, concurrent := conflictOn(current, field, m)
+		if !concurrent {
+			continue
+		}
+		keep = keep || c.Winner == WinnerServer
+		conflicts = append(conflicts, c)
+	}
+	if keep {
+		for i := range conflicts {
+			conflicts[i].Winner = WinnerServer
+		}
+		return current, conflicts, nil
+	}
+
+	err = s.delete(ctx, m.ID, current.Version)
+	if errors.Is(err, model.ErrNotFound) {
+		return model.Task{}, nil, nil
+	}
+	if err != nil {
+		return model.Task{}, nil, err
+	}
+	return model.Task{}, conflicts, nil
+}
+
+// conflictOn reports whether field changed on the server after m's base
+// version and, if so, which side wins.
+func conflictOn(current model.Task, field string, m Mutation) (Conflict, bool) {
+	clock := current.FieldClocks[field]
+	if clock.Version <= m.BaseVersion {
+		return Conflict{}, false
+	}
+	c := Conflict{
+		TaskID:          current.ID,
+		Field:           field,
+		Winner:          WinnerServer,
+		ServerChangedAt: clock.At,
+		ClientChangedAt: m.ChangedAt,
+	}
+	if m.ChangedAt.After(clock.At) {
+		c.Winner = WinnerClient
+	}
+	return c, true
+}
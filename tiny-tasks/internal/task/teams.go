@@ -0,0 +1,112 @@
+package task
+
+import (
+	"context"
+	"strings"
+
+	"tiny-tasks/internal/model"
+)
+
+// CreateTeam makes the calling user the first admin of a new team.
+func (s *Service) CreateTeam(ctx context.Context, name string) (model.Team, error) {
+	userID := UserFrom(ctx)
+	if userID == "" {
+		return model.Team{}, ErrUnauthenticated
+	}
+	valid, err := ValidateTeamName(name)
+	if err != nil {
+		return model.Team{}, err
+	}
+	return s.teams.CreateTeam(valid, userID)
+}
+
+// ListTeams returns the teams the calling user belongs to.
+func (s *Service) ListTeams(ctx context.Context) ([]model.Team, error) {
+	userID := UserFrom(ctx)
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+	return s.teams.ListTeams(userID)
+}
+
+// GetTeam is limited to members of the team.
+func (s *Service) GetTeam(ctx context.Context, id string) (model.Team, error) {
+	team, err := s.teams.GetTeam(id)
+	if err != nil {
+		return model.Team{}, err
+	}
+	if err := requireRole(ctx, team, ""); err != nil {
+		return model.Team{}, err
+	}
+	return team, nil
+}
+
+// SetMember adds userID to the team or changes their role. Only admins may
+// manage members, and the last admin cannot demote themselves.
+func (s *Service) SetMember(ctx context.Context, teamID, userID string, role model.Role) (model.Team, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return model.Team{}, ErrInvalidMember
+	}
+	if err := ValidateRole(role); err != nil {
+		return model.Team{}, err
+	}
+
+	team, err := s.teams.GetTeam(teamID)
+	if err != nil {
+		return model.Team{}, err
+	}
+	if err := requireRole(ctx, team, model.RoleAdmin); err != nil {
+		return model.Team{}, err
+	}
+	if role != model.RoleAdmin && isLastAdmin(team, userID) {
+		return model.Team{}, ErrLastAdmin
+	}
+	return s.teams.SetMember(teamID, userID, role)
+}
+
+// RemoveMember leaves tasks assigned to userID untouched; they stay visible
+// to their owner and the rest of the team, but no longer to userID, whose
+// assignee rights end with the membership.
+func (s *Service) RemoveMember(ctx context.Context, teamID, userID string) (model.Team, error) {
+	team, err := s.teams.GetTeam(teamID)
+	if err != nil {
+		return model.Team{}, err
+	}
+	if err := requireRole(ctx, team, model.RoleAdmin); err != nil {
+		return model.Team{}, err
+	}
+	if isLastAdmin(team, userID) {
+		return model.Team{}, ErrLastAdmin
+	}
+	return s.teams.RemoveMember(teamID, userID)
+}
+
+// requireRole checks that the caller belongs to team and, when role is set,
+// holds it.
+func requireRole(ctx context.Context, team model.Team, role model.Role) error {
+	a := actorFrom(ctx)
+	if a.system {
+		return nil
+	}
+	if a.userID == "" {
+		return ErrUnauthenticated
+	}
+	got, ok := team.Members[a.userID]
+	if !ok || (role != "" && got != role) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func isLastAdmin(team model.Team, userID string) bool {
+	if team.Members[userID] != model.RoleAdmin {
+		return false
+	}
+	for id, role := range team.Members {
+		if id != userID && role == model.RoleAdmin {
+			return false
+		}
+	}
+	return true
+}
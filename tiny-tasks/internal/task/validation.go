@@ -1,6 +1,18 @@
 package task
 
-import "strings"
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"tiny-tasks/internal/model"
+)
+
+const (
+	maxTagLen         = 32
+	maxReminders      = 5
+	maxReminderOffset = 30 * 24 * time.Hour
+)
 
 func ValidateTitle(title string) (string, error) {
 	trimmed := strings.TrimSpace(title)
@@ -9,3 +21,87 @@ func ValidateTitle(title string) (string, error) {
 	}
 	return trimmed, nil
 }
+
+// ValidateTags lowercases and de-duplicates tags, keeping their first-seen order.
+func ValidateTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxTagLen || strings.IndexFunc(tag, invalidTagRune) >= 0 {
+			return nil, ErrInvalidTag
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out, nil
+}
+
+// ValidateReminderOffsets de-duplicates offsets and sorts them so the earliest
+// reminder (largest offset) comes first.
+func ValidateReminderOffsets(offsets []model.Duration) ([]model.Duration, error) {
+	out := slices.Clone(offsets)
+	slices.Sort(out)
+	out = slices.Compact(out)
+	if len(out) > maxReminders {
+		return nil, ErrInvalidReminder
+	}
+	for _, d := range out {
+		if d < 0 || time.Duration(d) > maxReminderOffset {
+			return nil, ErrInvalidReminder
+		}
+	}
+	slices.Reverse(out)
+	return out, nil
+}
+
+func invalidTagRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+		return false
+	default:
+		return true
+	}
+}
+
+const (
+	maxTeamNameLen    = 64
+	maxCommentLen     = 10000
+	maxAttachmentName = 255
+)
+
+func ValidateTeamName(name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" || len(trimmed) > maxTeamNameLen {
+		return "", ErrInvalidTeamName
+	}
+	return trimmed, nil
+}
+
+func ValidateRole(role model.Role) error {
+	if _, ok := rolePermissions[role]; !ok {
+		return ErrInvalidRole
+	}
+	return nil
+}
+
+func ValidateComment(body string) (string, error) {
+	trimmed := strings.TrimSpace(body)
+	if trimmed == "" || len(trimmed) > maxCommentLen {
+		return "", ErrInvalidComment
+	}
+	return trimmed, nil
+}
+
+// ValidateFileName rejects names that could be mistaken for paths when the
+// attachment is downloaded.
+func ValidateFileName(name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" || len(trimmed) > maxAttachmentName || strings.ContainsAny(trimmed, "/\\\x00") {
+		return "", ErrInvalidFileName
+	}
+	return trimmed, nil
+}
@@ -0,0 +1,102 @@
+package task
+
+import (
+	"context"
+	"sync"
+
+	"tiny-tasks/internal/model"
+)
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event describes one change. For EventDeleted Task is the task as it was
+// before deletion.
+type Event struct {
+	Type EventType
+	Task model.Task
+}
+
+const watchBuffer = 64
+
+type broker struct {
+	mu   sync.Mutex
+	subs map[chan Event]*subscription
+}
+
+type subscription struct {
+	want func(Event) bool
+	// gone is closed when the subscription ends, by either side.
+	gone chan struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[chan Event]*subscription)}
+}
+
+// publish never blocks: a subscriber whose buffer is full is dropped and its
+// channel closed, so it can tell it missed events.
+func (b *broker) publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, sub := range b.subs {
+		if !sub.want(ev) {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+			b.dropLocked(ch)
+		}
+	}
+}
+
+// subscribe delivers the events for which want returns true.
+func (b *broker) subscribe(want func(Event) bool) (chan Event, <-chan struct{}) {
+	ch := make(chan Event, watchBuffer)
+	sub := &subscription{want: want, gone: make(chan struct{})}
+	b.mu.Lock()
+	b.subs[ch] = sub
+	b.mu.Unlock()
+	return ch, sub.gone
+}
+
+func (b *broker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dropLocked(ch)
+}
+
+func (b *broker) dropLocked(ch chan Event) {
+	if sub, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+		close(sub.gone)
+	}
+}
+
+// Watch streams the changes made through s to tasks the caller may view, until
+// ctx is done. The channel is closed when ctx ends or when the caller falls
+// too far behind; either way the subscription is released, so a caller may
+// simply Watch again.
+func (s *Service) Watch(ctx context.Context) <-chan Event {
+	a := actorFrom(ctx)
+	ch, gone := s.events.subscribe(func(ev Event) bool {
+		ok, err := s.can(a, ev.Task, ActionView)
+		return ok && err == nil
+	})
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.events.unsubscribe(ch)
+		case <-gone:
+		}
+	}()
+	return ch
+}
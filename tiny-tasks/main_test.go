@@ -11,17 +11,25 @@ import (
 
 	"tiny-tasks/internal/httpapi"
 	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/reminder"
+	"tiny-tasks/internal/report"
 	"tiny-tasks/internal/store/memorystore"
 	"tiny-tasks/internal/task"
 )
 
 func newTestServer() *httptest.Server {
 	repo := memorystore.NewTaskStore()
-	service := task.NewService(repo)
-	srv := httpapi.NewServer(service)
+	service := newService(repo, memorystore.NewBlobStore())
+	reminders := reminder.NewScheduler(service, repo, reminder.LogNotifier{}, 0)
+	srv := httpapi.NewServer(service, report.NewReporter(service), reminders)
 	return httptest.NewServer(srv)
 }
 
+// newService builds a Service on repo, which implements every repository.
+func newService(repo *memorystore.TaskStore, blobs task.BlobStore) *task.Service {
+	return task.NewService(task.Deps{Tasks: repo, Teams: repo, Comments: repo, Attachments: repo, Blobs: blobs})
+}
+
 func doJSON(t *testing.T, client *http.Client, method, url string, body any) (*http.Response, []byte) {
 	t.Helper()
 
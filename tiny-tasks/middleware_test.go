@@ -0,0 +1,183 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"tiny-tasks/internal/httpapi"
+	"tiny-tasks/internal/reminder"
+	"tiny-tasks/internal/report"
+	"tiny-tasks/internal/store/memorystore"
+)
+
+func TestMethodNotAllowed_SetsAllow(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	cases := []struct {
+		method, path string
+		allow        []string
+	}{
+		{http.MethodPut, "/tasks/abc", []string{"GET", "PATCH", "DELETE"}},
+		{http.MethodPost, "/healthz", []string{"GET", "HEAD"}},
+		{http.MethodDelete, "/tasks", []string{"GET", "POST"}},
+	}
+	for _, tc := range cases {
+		resp, body := doJSON(t, ts.Client(), tc.method, ts.URL+tc.path, nil)
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatalf("%s %s: expected 405, got %d", tc.method, tc.path, resp.StatusCode)
+		}
+		allow := resp.Header.Get("Allow")
+		for _, m := range tc.allow {
+			if !strings.Contains(allow, m) {
+				t.Fatalf("%s %s: Allow %q lacks %s", tc.method, tc.path, allow, m)
+			}
+		}
+		if msg := decodeErr(t, body); msg == "" {
+			t.Fatalf("%s %s: expected a JSON error, got %s", tc.method, tc.path, string(body))
+		}
+	}
+
+	resp, body := doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/tasks/a/b", nil)
+	if resp.StatusCode != http.StatusNotFound || decodeErr(t, body) == "" {
+		t.Fatalf("unknown path: expected JSON 404, got %d %s", resp.StatusCode, string(body))
+	}
+}
+
+func TestCompression(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	for i := 0; i < 40; i++ {
+		createTask(t, ts.URL, ts.Client(), map[string]any{"title": "Compressible task title"})
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for _, tc := range []struct{ accept, want string }{
+		{"gzip", "gzip"},
+		{"gzip, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, *", "gzip"},
+		{"identity", ""},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/tasks", nil)
+		req.Header.Set("Accept-Encoding", tc.accept)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := resp.Header.Get("Content-Encoding")
+		if got != tc.want {
+			resp.Body.Close()
+			t.Fatalf("Accept-Encoding %q: expected %q, got %q", tc.accept, tc.want, got)
+		}
+		if !strings.Contains(resp.Header.Get("Vary"), "Accept-Encoding") {
+			t.Fatalf("Accept-Encoding %q: missing Vary", tc.accept)
+		}
+
+		var body io.Reader = resp.Body
+		if got != "" {
+			if body, err = decoders[got](resp.Body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var payload struct {
+			Count int `json:"count"`
+		}
+		err = json.NewDecoder(body).Decode(&payload)
+		resp.Body.Close()
+		if err != nil || payload.Count != 40 {
+			t.Fatalf("Accept-Encoding %q: decoded count=%d err=%v", tc.accept, payload.Count, err)
+		}
+	}
+
+	// Small responses are not worth compressing.
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/healthz", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Fatalf("small response should not be compressed, got %q", enc)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	service := newService(repo, memorystore.NewBlobStore())
+	srv := httpapi.NewServer(service, report.NewReporter(service), reminder.NewScheduler(service, repo, reminder.LogNotifier{}, 0),
+		httpapi.WithCORS(httpapi.CORSConfig{AllowedOrigins: []string{"https://app.example"}}))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	preflight := func(origin, method, headers string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodOptions, ts.URL+"/tasks/abc", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := preflight("https://app.example", http.MethodPatch, "content-type, x-user-id")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("preflight: expected 204, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Fatalf("preflight: unexpected Allow-Origin %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Methods"); !strings.Contains(got, "PATCH") {
+		t.Fatalf("preflight: unexpected Allow-Methods %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Max-Age"); got == "" {
+		t.Fatal("preflight: missing Max-Age")
+	}
+
+	if resp := preflight("https://evil.example", http.MethodGet, ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("preflight from unknown origin: expected 403, got %d", resp.StatusCode)
+	}
+	if resp := preflight("https://app.example", http.MethodGet, "x-secret"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("preflight with unknown header: expected 403, got %d", resp.StatusCode)
+	}
+
+	for origin, want := range map[string]string{"https://app.example": "https://app.example", "https://evil.example": "", "": ""} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/tasks", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", origin, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != want {
+			t.Fatalf("%s: expected Allow-Origin %q, got %q", origin, want, got)
+		}
+		if vary := strings.Join(resp.Header.Values("Vary"), ", "); !strings.Contains(vary, "Origin") {
+			t.Fatalf("%q: expected Vary: Origin, got %q", origin, vary)
+		}
+	}
+}
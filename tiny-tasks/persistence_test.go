@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tiny-tasks/internal/httpapi"
+	"tiny-tasks/internal/reminder"
+	"tiny-tasks/internal/report"
+	"tiny-tasks/internal/store/blobstore"
+	"tiny-tasks/internal/store/memorystore"
+	"tiny-tasks/internal/task"
+)
+
+func newPersistentTestServer(t *testing.T, dir string) (*httptest.Server, *memorystore.TaskStore) {
+	t.Helper()
+	repo, err := memorystore.Open(dir)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	blobs, err := blobstore.NewLocal(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatalf("open blob store: %v", err)
+	}
+	service := newService(repo, blobs)
+	reminders := reminder.NewScheduler(service, repo, reminder.LogNotifier{}, 0)
+	return httptest.NewServer(httpapi.NewServer(service, report.NewReporter(service), reminders)), repo
+}
+
+func TestPersistence_ReplaysWALWithoutSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	ts, repo := newPersistentTestServer(t, dir)
+
+	resp, body := doJSON(t, ts.Client(), http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Keep me"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	kept := decodeTask(t, body)
+
+	resp, body = doJSON(t, ts.Client(), http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Drop me"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	dropped := decodeTask(t, body)
+
+	resp, body = doJSON(t, ts.Client(), http.MethodPatch, ts.URL+"/tasks/"+kept.ID, map[string]any{"completed": true})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	resp, body = doJSON(t, ts.Client(), http.MethodDelete, ts.URL+"/tasks/"+dropped.ID, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	// Simulate a crash: no snapshot, just drop the process state.
+	ts.Close()
+	repo.Close()
+
+	ts, repo = newPersistentTestServer(t, dir)
+	defer ts.Close()
+	defer repo.Close()
+
+	_, body = doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/tasks", nil)
+	_, items := decodeList(t, body)
+	if len(items) != 1 {
+		t.Fatalf("expected 1 task after replay, got %d", len(items))
+	}
+	if items[0].ID != kept.ID || items[0].CompletedAt == nil {
+		t.Fatalf("unexpected task after replay: %+v", items[0])
+	}
+}
+
+func TestPersistence_SnapshotThenWAL(t *testing.T) {
+	dir := t.TempDir()
+	ts, repo := newPersistentTestServer(t, dir)
+
+	resp, body := doJSON(t, ts.Client(), http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Before snapshot"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	if err := repo.Snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	resp, body = doJSON(t, ts.Client(), http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "After snapshot"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	ts.Close()
+	repo.Close()
+
+	// A torn final write must not prevent startup.
+	f, err := os.OpenFile(filepath.Join(dir, "tasks.wal"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`0000abcd {"seq":99,"op":"pu`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	ts, repo = newPersistentTestServer(t, dir)
+	defer ts.Close()
+	defer repo.Close()
+
+	_, body = doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/tasks", nil)
+	count, _ := decodeList(t, body)
+	if count != 2 {
+		t.Fatalf("expected 2 tasks after restore, got %d", count)
+	}
+}
+
+func TestPersistence_KeepsTeams(t *testing.T) {
+	dir := t.TempDir()
+	ts, repo := newPersistentTestServer(t, dir)
+
+	team := setupTeam(t, ts.URL)
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	// This change lives only in the WAL.
+	resp, body := doAs(t, "alice", http.MethodPut, ts.URL+"/teams/"+team.ID+"/members/dave", map[string]any{"role": "viewer"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	ts.Close()
+	repo.Close()
+
+	ts, repo = newPersistentTestServer(t, dir)
+	defer ts.Close()
+	defer repo.Close()
+
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		resp, body := doAs(t, user, http.MethodGet, ts.URL+"/teams/"+team.ID, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s after restore: status=%d body=%s", user, resp.StatusCode, string(body))
+		}
+	}
+}
+
+func TestPersistence_RejectsCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := memorystore.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Create(task.NewTask{Title: "Some task"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close()
+
+	path := filepath.Join(dir, "tasks.snapshot")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a byte inside the task title.
+	for i := range data {
+		if data[i] == 'S' {
+			data[i] = 'X'
+			break
+		}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := memorystore.Open(dir); !errors.Is(err, memorystore.ErrSnapshotChecksum) {
+		t.Fatalf("expected ErrSnapshotChecksum, got %v", err)
+	}
+}
+
+func TestPersistence_WritesAfterCloseFail(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := memorystore.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := repo.Create(task.NewTask{Title: "Before close"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.Create(task.NewTask{Title: "After close"}); !errors.Is(err, memorystore.ErrClosed) {
+		t.Fatalf("create after close: expected ErrClosed, got %v", err)
+	}
+	if err := repo.Delete(kept.ID, 0); !errors.Is(err, memorystore.ErrClosed) {
+		t.Fatalf("delete after close: expected ErrClosed, got %v", err)
+	}
+	if err := repo.Snapshot(); !errors.Is(err, memorystore.ErrClosed) {
+		t.Fatalf("snapshot after close: expected ErrClosed, got %v", err)
+	}
+	if _, err := repo.Get(kept.ID); err != nil {
+		t.Fatalf("reads should keep working: %v", err)
+	}
+}
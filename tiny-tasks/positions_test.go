@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"tiny-tasks/internal/store/memorystore"
+	"tiny-tasks/internal/task"
+)
+
+func listOrder(t *testing.T, ts string, client *http.Client) []string {
+	t.Helper()
+	resp, body := doJSON(t, client, http.MethodGet, ts+"/tasks?sort=position", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: status=%d body=%s", resp.StatusCode, string(body))
+	}
+	_, items := decodeList(t, body)
+	ids := make([]string, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.ID)
+	}
+	return ids
+}
+
+func move(t *testing.T, ts string, client *http.Client, id string, body map[string]any) int {
+	t.Helper()
+	resp, _ := doJSON(t, client, http.MethodPost, ts+"/tasks/"+id+"/move", body)
+	return resp.StatusCode
+}
+
+func TestMoveTasks(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	client := ts.Client()
+
+	a := createTask(t, ts.URL, client, map[string]any{"title": "Task A"})
+	b := createTask(t, ts.URL, client, map[string]any{"title": "Task B"})
+	c := createTask(t, ts.URL, client, map[string]any{"title": "Task C"})
+
+	if got := listOrder(t, ts.URL, client); !slices.Equal(got, []string{a, b, c}) {
+		t.Fatalf("new tasks should be appended, got %v", got)
+	}
+
+	steps := []struct {
+		id   string
+		body map[string]any
+		want []string
+	}{
+		{c, map[string]any{"before": a}, []string{c, a, b}},
+		{c, map[string]any{"after": b}, []string{a, b, c}},
+		{a, map[string]any{"after": b, "before": c}, []string{b, a, c}},
+		{b, map[string]any{"after": c}, []string{a, c, b}},
+	}
+	for i, step := range steps {
+		if status := move(t, ts.URL, client, step.id, step.body); status != http.StatusOK {
+			t.Fatalf("step %d: expected 200, got %d", i, status)
+		}
+		if got := listOrder(t, ts.URL, client); !slices.Equal(got, step.want) {
+			t.Fatalf("step %d: expected %v, got %v", i, step.want, got)
+		}
+	}
+
+	for name, body := range map[string]map[string]any{
+		"no neighbours":  {},
+		"itself":         {"before": a},
+		"wrong order":    {"after": b, "before": c},
+		"same neighbour": {"after": c, "before": c},
+	} {
+		if status := move(t, ts.URL, client, a, body); status != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", name, status)
+		}
+	}
+	if status := move(t, ts.URL, client, a, map[string]any{"before": "missing"}); status != http.StatusNotFound {
+		t.Fatalf("missing neighbour: expected 404, got %d", status)
+	}
+
+	resp, _ := doJSON(t, client, http.MethodGet, ts.URL+"/tasks?"+url.Values{"sort": {"title"}}.Encode(), nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown sort: expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestMoveTasks_RebalancesLongKeys(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	client := ts.Client()
+
+	ids := []string{
+		createTask(t, ts.URL, client, map[string]any{"title": "First"}),
+		createTask(t, ts.URL, client, map[string]any{"title": "Second"}),
+		createTask(t, ts.URL, client, map[string]any{"title": "Third"}),
+	}
+
+	// Always dropping the last task right after the first one halves the same
+	// gap every time; without rebalancing keys would grow by a digit every few
+	// moves.
+	for i := 0; i < 300; i++ {
+		last := ids[2]
+		if status := move(t, ts.URL, client, last, map[string]any{"after": ids[0], "before": ids[1]}); status != http.StatusOK {
+			t.Fatalf("move %d: expected 200, got %d", i, status)
+		}
+		ids = []string{ids[0], last, ids[1]}
+	}
+
+	if got := listOrder(t, ts.URL, client); !slices.Equal(got, ids) {
+		t.Fatalf("expected %v, got %v", ids, got)
+	}
+	resp, body := doJSON(t, client, http.MethodGet, ts.URL+"/tasks", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: status=%d", resp.StatusCode)
+	}
+	_, items := decodeList(t, body)
+	for _, it := range items {
+		if len(it.Position) > 40 {
+			t.Fatalf("position key was never rebalanced: %q", it.Position)
+		}
+	}
+
+	// One-sided moves look their other bound up in the rebuilt index.
+	if status := move(t, ts.URL, client, ids[0], map[string]any{"after": ids[1]}); status != http.StatusOK {
+		t.Fatalf("move after rebalance: expected 200, got %d", status)
+	}
+	want := []string{ids[1], ids[0], ids[2]}
+	if got := listOrder(t, ts.URL, client); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMoveTasks_RebalancesOnlyTheListAndPublishesIt(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	service := newService(repo, memorystore.NewBlobStore())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	alice := task.WithUser(ctx, "alice")
+
+	var ids []string
+	for _, title := range []string{"First", "Second", "Third"} {
+		created, err := service.Create(alice, task.NewTask{Title: title})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ids = append(ids, created.ID)
+	}
+	unrelated, err := service.Create(task.WithUser(ctx, "bob"), task.NewTask{Title: "Bob's own"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// The watcher keeps the last position it was told about for each task.
+	// Events are published before Move returns, so each move's are buffered
+	// by then.
+	seen := map[string]string{}
+	events := service.Watch(alice)
+	drain := func() {
+		for {
+			select {
+			case ev := <-events:
+				seen[ev.Task.ID] = ev.Task.Position
+			default:
+				return
+			}
+		}
+	}
+
+	for i := 0; i < 300; i++ {
+		last := ids[2]
+		if _, err := service.Move(alice, last, ids[1], ids[0]); err != nil {
+			t.Fatalf("move %d: %v", i, err)
+		}
+		ids = []string{ids[0], last, ids[1]}
+		drain()
+	}
+
+	for _, id := range ids {
+		current, err := repo.Get(id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if seen[id] != current.Position {
+			t.Fatalf("%s: watcher saw position %q, task has %q", id, seen[id], current.Position)
+		}
+	}
+	if got, err := repo.Get(unrelated.ID); err != nil || got.Version != unrelated.Version {
+		t.Fatalf("another owner's task was rewritten: %+v, %v", got, err)
+	}
+}
+
+func TestMoveTasks_SkipsDeletedNeighbours(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	client := ts.Client()
+
+	a := createTask(t, ts.URL, client, map[string]any{"title": "Task A"})
+	b := createTask(t, ts.URL, client, map[string]any{"title": "Task B"})
+	c := createTask(t, ts.URL, client, map[string]any{"title": "Task C"})
+	d := createTask(t, ts.URL, client, map[string]any{"title": "Task D"})
+
+	resp, _ := doJSON(t, client, http.MethodDelete, ts.URL+"/tasks/"+c, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: status=%d", resp.StatusCode)
+	}
+	if status := move(t, ts.URL, client, a, map[string]any{"after": b}); status != http.StatusOK {
+		t.Fatalf("move after: expected 200, got %d", status)
+	}
+	if status := move(t, ts.URL, client, d, map[string]any{"before": b}); status != http.StatusOK {
+		t.Fatalf("move before: expected 200, got %d", status)
+	}
+	if got, want := listOrder(t, ts.URL, client), []string{d, b, a}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
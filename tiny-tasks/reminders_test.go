@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"tiny-tasks/internal/httpapi"
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/reminder"
+	"tiny-tasks/internal/report"
+	"tiny-tasks/internal/store/memorystore"
+	"tiny-tasks/internal/task"
+)
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []reminder.Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n reminder.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func (r *recordingNotifier) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sent)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func decodeReminders(t *testing.T, data []byte) []reminder.Reminder {
+	t.Helper()
+	var payload struct {
+		Items []reminder.Reminder `json:"items"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("unmarshal reminders: %v; body=%s", err, string(data))
+	}
+	return payload.Items
+}
+
+func TestReminders_FireAndReportStatus(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	service := newService(repo, memorystore.NewBlobStore())
+	notifier := &recordingNotifier{}
+	scheduler := reminder.NewScheduler(service, repo, notifier, 0)
+	ts := httptest.NewServer(httpapi.NewServer(service, report.NewReporter(service), scheduler))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	due := time.Now().UTC().Add(300 * time.Millisecond)
+	resp, body := doJSON(t, ts.Client(), http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title":            "Stand-up",
+		"due_at":           due,
+		"reminder_offsets": []string{"200ms", "1h"},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	created := decodeTask(t, body)
+
+	waitFor(t, func() bool { return notifier.count() == 1 })
+
+	resp, body = doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/tasks/"+created.ID+"/reminders", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	items := decodeReminders(t, body)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 reminders, got %+v", items)
+	}
+	// Largest offset first; its fire time was already past at creation.
+	if items[0].Status != reminder.StatusSkipped || items[1].Status != reminder.StatusSent {
+		t.Fatalf("unexpected statuses: %+v", items)
+	}
+
+	resp, body = doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/tasks/missing/reminders", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+}
+
+func TestReminders_RecomputedAfterRestart(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	service := newService(repo, memorystore.NewBlobStore())
+
+	// Fire time one second ago: a scheduler that was down at that moment
+	// should still deliver it when it starts within the catch-up window.
+	due := time.Now().UTC().Add(time.Hour - time.Second)
+	offsets := []model.Duration{model.Duration(time.Hour)}
+	if _, err := service.Create(context.Background(), task.NewTask{Title: "Pay rent", DueAt: &due, ReminderOffsets: offsets}); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &recordingNotifier{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reminder.NewScheduler(service, repo, notifier, reminder.DefaultCatchUp).Run(ctx)
+
+	waitFor(t, func() bool { return notifier.count() == 1 })
+}
+
+func TestReminders_NotResentAfterRestart(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	service := newService(repo, memorystore.NewBlobStore())
+
+	due := time.Now().UTC().Add(time.Hour - time.Second)
+	offsets := []model.Duration{model.Duration(time.Hour)}
+	if _, err := service.Create(context.Background(), task.NewTask{Title: "Pay rent", DueAt: &due, ReminderOffsets: offsets}); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &recordingNotifier{}
+	ctx, cancel := context.WithCancel(context.Background())
+	go reminder.NewScheduler(service, repo, notifier, reminder.DefaultCatchUp).Run(ctx)
+	waitFor(t, func() bool { return notifier.count() == 1 })
+	cancel()
+
+	// The restarted scheduler resumes from the stored watermark, which is
+	// already past the fire time.
+	before, err := repo.ReminderWatermark()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go reminder.NewScheduler(service, repo, notifier, reminder.DefaultCatchUp).Run(ctx)
+	waitFor(t, func() bool {
+		after, err := repo.ReminderWatermark()
+		return err == nil && after.After(before)
+	})
+	if n := notifier.count(); n != 1 {
+		t.Fatalf("sent %d reminders, want 1", n)
+	}
+}
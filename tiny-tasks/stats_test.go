@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type statsResponse struct {
+	TZ        string `json:"tz"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	Buckets   []struct {
+		Start     string `json:"start"`
+		Created   int    `json:"created"`
+		Completed int    `json:"completed"`
+	} `json:"buckets"`
+	MedianTimeToCompleteSeconds *float64 `json:"median_time_to_complete_seconds"`
+	Overdue                     int      `json:"overdue"`
+	Tags                        []struct {
+		Tag       string `json:"tag"`
+		Created   int    `json:"created"`
+		Completed int    `json:"completed"`
+		Overdue   int    `json:"overdue"`
+	} `json:"tags"`
+}
+
+func TestStats_CountsTagsAndOverdue(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	past := time.Now().UTC().Add(-time.Hour)
+
+	resp, body := doJSON(t, ts.Client(), http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title": "Late report", "tags": []string{"Work"}, "due_at": past,
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	late := decodeTask(t, body)
+	if len(late.Tags) != 1 || late.Tags[0] != "work" {
+		t.Fatalf("expected normalized tag, got %v", late.Tags)
+	}
+
+	resp, body = doJSON(t, ts.Client(), http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title": "Done thing", "tags": []string{"work", "home"},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	done := decodeTask(t, body)
+
+	resp, body = doJSON(t, ts.Client(), http.MethodPatch, ts.URL+"/tasks/"+done.ID, map[string]any{"completed": true})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	resp, body = doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/stats?tz=Europe/Warsaw&bucket=week", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	var stats statsResponse
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("unmarshal stats: %v; body=%s", err, string(body))
+	}
+
+	if stats.TZ != "Europe/Warsaw" {
+		t.Fatalf("tz=%q", stats.TZ)
+	}
+	if stats.Created != 2 || stats.Completed != 1 || stats.Overdue != 1 {
+		t.Fatalf("unexpected totals: %+v", stats)
+	}
+	if len(stats.Buckets) == 0 || stats.Buckets[len(stats.Buckets)-1].Created != 2 {
+		t.Fatalf("expected both tasks in the last bucket: %+v", stats.Buckets)
+	}
+	if stats.MedianTimeToCompleteSeconds == nil {
+		t.Fatalf("expected median time to complete")
+	}
+	if len(stats.Tags) != 2 || stats.Tags[0].Tag != "home" || stats.Tags[1].Tag != "work" {
+		t.Fatalf("unexpected tags: %+v", stats.Tags)
+	}
+	if stats.Tags[1].Created != 2 || stats.Tags[1].Completed != 1 || stats.Tags[1].Overdue != 1 {
+		t.Fatalf("unexpected work tag stats: %+v", stats.Tags[1])
+	}
+
+	// Clearing the due date removes the task from overdue.
+	resp, body = doJSON(t, ts.Client(), http.MethodPatch, ts.URL+"/tasks/"+late.ID, map[string]any{"due_at": nil})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+	if decodeTask(t, body).DueAt != nil {
+		t.Fatalf("expected due_at to be cleared")
+	}
+	_, body = doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/stats", nil)
+	stats = statsResponse{}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("unmarshal stats: %v; body=%s", err, string(body))
+	}
+	if stats.Overdue != 0 {
+		t.Fatalf("expected no overdue tasks, got %d", stats.Overdue)
+	}
+}
+
+func TestStats_RejectsBadParams(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	for _, q := range []string{
+		"tz=Mars/Olympus",
+		"bucket=month",
+		"from=2026-02-10&to=2026-02-01",
+		"from=2020-01-01&to=2026-01-01",
+	} {
+		resp, body := doJSON(t, ts.Client(), http.MethodGet, ts.URL+"/stats?"+q, nil)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d body=%s", q, resp.StatusCode, string(body))
+		}
+	}
+}
+
+func TestStats_OnlyCountsVisibleTasks(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	resp, body := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title": "Private plan", "tags": []string{"secret"},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	for _, user := range []string{"bob", ""} {
+		resp, body = doAs(t, user, http.MethodGet, ts.URL+"/stats", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%d body=%s", resp.StatusCode, string(body))
+		}
+		var stats statsResponse
+		if err := json.Unmarshal(body, &stats); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if stats.Created != 0 || len(stats.Tags) != 0 {
+			t.Fatalf("user %q sees alice's task in stats: %s", user, string(body))
+		}
+	}
+
+	resp, body = doAs(t, "alice", http.MethodGet, ts.URL+"/stats", nil)
+	var stats statsResponse
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || stats.Created != 1 {
+		t.Fatalf("alice: status=%d body=%s", resp.StatusCode, string(body))
+	}
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tiny-tasks/internal/model"
+	"tiny-tasks/internal/store/memorystore"
+	"tiny-tasks/internal/task"
+)
+
+type changesPayload struct {
+	Token   string       `json:"token"`
+	Reset   bool         `json:"reset"`
+	Tasks   []model.Task `json:"tasks"`
+	Deleted []struct {
+		ID      string `json:"id"`
+		Version uint64 `json:"version"`
+	} `json:"deleted"`
+}
+
+type syncPayload struct {
+	Results []struct {
+		ClientID string      `json:"client_id"`
+		Status   string      `json:"status"`
+		Task     *model.Task `json:"task"`
+		Deleted  bool        `json:"deleted"`
+		Code     int         `json:"code"`
+	} `json:"results"`
+	Conflicts []struct {
+		TaskID string `json:"task_id"`
+		Field  string `json:"field"`
+		Winner string `json:"winner"`
+	} `json:"conflicts"`
+}
+
+func getChanges(t *testing.T, userID, baseURL, token string) changesPayload {
+	t.Helper()
+	resp, data := doAs(t, userID, http.MethodGet, baseURL+"/sync?since="+token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /sync: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	var out changesPayload
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal changes: %v; body=%s", err, string(data))
+	}
+	return out
+}
+
+func postSync(t *testing.T, userID, baseURL string, mutations ...map[string]any) syncPayload {
+	t.Helper()
+	resp, data := doAs(t, userID, http.MethodPost, baseURL+"/sync", map[string]any{"mutations": mutations})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /sync: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	var out syncPayload
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal sync: %v; body=%s", err, string(data))
+	}
+	return out
+}
+
+func TestSync_ChangesSinceToken(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	var ids []string
+	for _, title := range []string{"Water plants", "Pay rent", "Call mom"} {
+		resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": title})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+		}
+		ids = append(ids, decodeTask(t, data).ID)
+	}
+
+	full := getChanges(t, "alice", ts.URL, "")
+	if !full.Reset || len(full.Tasks) != 3 || len(full.Deleted) != 0 {
+		t.Fatalf("expected a reset with 3 tasks, got %+v", full)
+	}
+
+	resp, _ := doAs(t, "alice", http.MethodPatch, ts.URL+"/tasks/"+ids[0], map[string]any{"completed": true})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d", resp.StatusCode)
+	}
+	resp, _ = doAs(t, "alice", http.MethodDelete, ts.URL+"/tasks/"+ids[1], nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", resp.StatusCode)
+	}
+
+	delta := getChanges(t, "alice", ts.URL, full.Token)
+	if delta.Reset || len(delta.Tasks) != 1 || delta.Tasks[0].ID != ids[0] || delta.Tasks[0].CompletedAt == nil {
+		t.Fatalf("expected only the completed task, got %+v", delta)
+	}
+	if len(delta.Deleted) != 1 || delta.Deleted[0].ID != ids[1] {
+		t.Fatalf("expected the deleted task, got %+v", delta.Deleted)
+	}
+	if delta.Tasks[0].Version <= full.Tasks[0].Version {
+		t.Fatalf("expected the version to grow past %d, got %d", full.Tasks[0].Version, delta.Tasks[0].Version)
+	}
+
+	if again := getChanges(t, "alice", ts.URL, delta.Token); len(again.Tasks)+len(again.Deleted) != 0 {
+		t.Fatalf("expected no changes since the latest token, got %+v", again)
+	}
+
+	// A token from before the store was reset forces a full resync.
+	epoch, rawSeq, _ := strings.Cut(delta.Token, ".")
+	seq, _ := strconv.ParseUint(rawSeq, 10, 64)
+	if ahead := getChanges(t, "alice", ts.URL, epoch+"."+strconv.FormatUint(seq+100, 10)); !ahead.Reset || len(ahead.Tasks) != 2 {
+		t.Fatalf("expected a reset for an unknown token, got %+v", ahead)
+	}
+
+	// So does one from another store, such as this one before a restart,
+	// even though its sequence number is in range.
+	other := newTestServer()
+	defer other.Close()
+	for i := uint64(0); i <= seq; i++ {
+		createTask(t, other.URL, other.Client(), map[string]any{"title": "Elsewhere"})
+	}
+	if foreign := getChanges(t, "", other.URL, delta.Token); !foreign.Reset || uint64(len(foreign.Tasks)) != seq+1 {
+		t.Fatalf("expected a reset for another store's token, got %+v", foreign)
+	}
+
+	resp, _ = doAs(t, "alice", http.MethodGet, ts.URL+"/sync?since=abc", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad token: expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestSync_OnlyVisibleChanges(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+	start := getChanges(t, "dave", ts.URL, "").Token
+
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Team secret", "team_id": team.ID})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	resp, _ = doAs(t, "alice", http.MethodDelete, ts.URL+"/tasks/"+decodeTask(t, data).ID, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", resp.StatusCode)
+	}
+
+	if got := getChanges(t, "dave", ts.URL, start); len(got.Tasks)+len(got.Deleted) != 0 {
+		t.Fatalf("outsider should see nothing, got %+v", got)
+	}
+	if got := getChanges(t, "carol", ts.URL, start); len(got.Deleted) != 1 {
+		t.Fatalf("team viewer should see the delete, got %+v", got)
+	}
+}
+
+func TestSync_VisibilityChangesReset(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+	teamURL := ts.URL + "/teams/" + team.ID
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Team plan", "team_id": team.ID})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	planID := decodeTask(t, data).ID
+
+	// Joining: the team's tasks are older than dave's token.
+	daveToken := getChanges(t, "dave", ts.URL, "").Token
+	resp, _ = doAs(t, "alice", http.MethodPut, teamURL+"/members/dave", map[string]any{"role": "viewer"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("add dave: status=%d", resp.StatusCode)
+	}
+	if got := getChanges(t, "dave", ts.URL, daveToken); !got.Reset || len(got.Tasks) != 1 || got.Tasks[0].ID != planID {
+		t.Fatalf("new member should resync with the team's tasks, got %+v", got)
+	}
+
+	// Leaving: nothing about the task changes, yet carol must drop it.
+	carolToken := getChanges(t, "carol", ts.URL, "").Token
+	resp, _ = doAs(t, "alice", http.MethodDelete, teamURL+"/members/carol", nil)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("remove carol: status=%d", resp.StatusCode)
+	}
+	if got := getChanges(t, "carol", ts.URL, carolToken); !got.Reset || len(got.Tasks) != 0 {
+		t.Fatalf("removed member should resync without the team's tasks, got %+v", got)
+	}
+
+	// Moving the task out of the team takes it away from dave and bob.
+	resp, data = doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Solo plan"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	bobToken := getChanges(t, "bob", ts.URL, "").Token
+	resp, data = doAs(t, "alice", http.MethodPatch, ts.URL+"/tasks/"+planID, map[string]any{"team_id": ""})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unassign team: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	if got := getChanges(t, "bob", ts.URL, bobToken); !got.Reset || len(got.Tasks) != 0 {
+		t.Fatalf("bob should resync without the moved task, got %+v", got)
+	}
+	if got := getChanges(t, "alice", ts.URL, bobToken); got.Reset || len(got.Tasks) != 1 {
+		t.Fatalf("alice still sees the task and needs only a delta, got %+v", got)
+	}
+}
+
+func TestSync_MutationsAndConflicts(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	out := postSync(t, "alice", ts.URL,
+		map[string]any{"op": "create", "client_id": "c1", "fields": map[string]any{"title": "Buy milk", "completed": true}},
+		map[string]any{"op": "create", "client_id": "c2", "fields": map[string]any{"title": "x"}},
+		map[string]any{"op": "rename", "client_id": "c3"},
+	)
+	if len(out.Results) != 3 {
+		t.Fatalf("expected 3 results, got %+v", out.Results)
+	}
+	created := out.Results[0]
+	if created.Status != "applied" || created.ClientID != "c1" || created.Task == nil || created.Task.CompletedAt == nil {
+		t.Fatalf("unexpected create result %+v", created)
+	}
+	if out.Results[1].Status != "rejected" || out.Results[1].Code != http.StatusBadRequest {
+		t.Fatalf("expected the short title to be rejected, got %+v", out.Results[1])
+	}
+	if out.Results[2].Status != "rejected" || out.Results[2].Code != http.StatusBadRequest {
+		t.Fatalf("expected the unknown op to be rejected, got %+v", out.Results[2])
+	}
+
+	id, base := created.Task.ID, created.Task.Version
+	offline := time.Now().UTC().Add(-time.Hour)
+
+	// Someone else renames the task after the client went offline.
+	resp, _ := doAs(t, "alice", http.MethodPatch, ts.URL+"/tasks/"+id, map[string]any{"title": "Buy oat milk"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d", resp.StatusCode)
+	}
+
+	// The older offline rename loses; the untouched tags still apply.
+	out = postSync(t, "alice", ts.URL, map[string]any{
+		"op": "update", "id": id, "base_version": base, "changed_at": offline,
+		"fields": map[string]any{"title": "Buy soy milk", "tags": []string{"errand"}},
+	})
+	res := out.Results[0]
+	if res.Status != "applied" || res.Task.Title != "Buy oat milk" || len(res.Task.Tags) != 1 {
+		t.Fatalf("unexpected update result %+v", res.Task)
+	}
+	if len(out.Conflicts) != 1 || out.Conflicts[0].Field != "title" || out.Conflicts[0].Winner != "server" {
+		t.Fatalf("expected a title conflict won by the server, got %+v", out.Conflicts)
+	}
+
+	// A newer offline rename wins.
+	out = postSync(t, "alice", ts.URL, map[string]any{
+		"op": "update", "id": id, "base_version": base,
+		"fields": map[string]any{"title": "Buy almond milk"},
+	})
+	if out.Results[0].Task.Title != "Buy almond milk" || len(out.Conflicts) != 1 || out.Conflicts[0].Winner != "client" {
+		t.Fatalf("expected the client to win, got %+v and %+v", out.Results[0].Task, out.Conflicts)
+	}
+	latest := out.Results[0].Task.Version
+
+	// A delete made before the latest change keeps the task.
+	out = postSync(t, "alice", ts.URL, map[string]any{
+		"op": "delete", "id": id, "base_version": base, "changed_at": offline,
+	})
+	if out.Results[0].Deleted || out.Results[0].Task == nil || len(out.Conflicts) == 0 || out.Conflicts[0].Winner != "server" {
+		t.Fatalf("expected the delete to lose, got %+v and %+v", out.Results[0], out.Conflicts)
+	}
+
+	// Based on the latest version there is nothing to resolve.
+	out = postSync(t, "alice", ts.URL, map[string]any{"op": "delete", "id": id, "base_version": latest})
+	if !out.Results[0].Deleted || len(out.Conflicts) != 0 {
+		t.Fatalf("expected a clean delete, got %+v and %+v", out.Results[0], out.Conflicts)
+	}
+	resp, _ = doAs(t, "alice", http.MethodGet, ts.URL+"/tasks/"+id, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the task to be gone, got %d", resp.StatusCode)
+	}
+}
+
+func TestSync_MutationsNeedPermission(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Rotate keys", "team_id": team.ID})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	id := decodeTask(t, data).ID
+
+	out := postSync(t, "carol", ts.URL,
+		map[string]any{"op": "update", "id": id, "fields": map[string]any{"title": "Rotate nothing"}},
+		map[string]any{"op": "delete", "id": id},
+	)
+	for _, res := range out.Results {
+		if res.Status != "rejected" || res.Code != http.StatusForbidden {
+			t.Fatalf("viewer mutation: expected 403, got %+v", res)
+		}
+	}
+	if len(out.Conflicts) != 0 {
+		t.Fatalf("rejected mutations must not report conflicts, got %+v", out.Conflicts)
+	}
+}
+
+func TestPersistence_KeepsTombstones(t *testing.T) {
+	dir := t.TempDir()
+	ts, repo := newPersistentTestServer(t, dir)
+
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Short-lived"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	id := decodeTask(t, data).ID
+	token := getChanges(t, "alice", ts.URL, "").Token
+
+	resp, _ = doAs(t, "alice", http.MethodDelete, ts.URL+"/tasks/"+id, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", resp.StatusCode)
+	}
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+	repo.Close()
+
+	ts, repo = newPersistentTestServer(t, dir)
+	defer ts.Close()
+	defer repo.Close()
+
+	got := getChanges(t, "alice", ts.URL, token)
+	if got.Reset || len(got.Deleted) != 1 || got.Deleted[0].ID != id {
+		t.Fatalf("expected the delete to survive a restart, got %+v", got)
+	}
+}
+
+func TestSync_WritesCheckTheVersionTheyDecidedOn(t *testing.T) {
+	repo := memorystore.NewTaskStore()
+	created, err := repo.Create(task.NewTask{Title: "Shared list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := "Renamed"
+	updated, err := repo.Update(created.ID, task.TaskUpdate{Title: &title, IfVersion: created.Version})
+	if err != nil {
+		t.Fatalf("update at current version: %v", err)
+	}
+
+	// A writer that read the task before the rename must not overwrite it.
+	stale := "Stale rename"
+	if _, err := repo.Update(created.ID, task.TaskUpdate{Title: &stale, IfVersion: created.Version}); !errors.Is(err, task.ErrStaleVersion) {
+		t.Fatalf("update at old version: expected ErrStaleVersion, got %v", err)
+	}
+	if err := repo.Delete(created.ID, created.Version); !errors.Is(err, task.ErrStaleVersion) {
+		t.Fatalf("delete at old version: expected ErrStaleVersion, got %v", err)
+	}
+	if err := repo.Delete(created.ID, updated.Version); err != nil {
+		t.Fatalf("delete at current version: %v", err)
+	}
+}
+
+func TestPersistence_KeepsVisibilityChanges(t *testing.T) {
+	dir := t.TempDir()
+	ts, repo := newPersistentTestServer(t, dir)
+
+	team := setupTeam(t, ts.URL)
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Team plan", "team_id": team.ID})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	token := getChanges(t, "carol", ts.URL, "").Token
+	resp, _ = doAs(t, "alice", http.MethodDelete, ts.URL+"/teams/"+team.ID+"/members/carol", nil)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("remove carol: status=%d", resp.StatusCode)
+	}
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+	repo.Close()
+
+	ts, repo = newPersistentTestServer(t, dir)
+	defer ts.Close()
+	defer repo.Close()
+
+	if got := getChanges(t, "carol", ts.URL, token); !got.Reset || len(got.Tasks) != 0 {
+		t.Fatalf("expected the removal to still reset after a restart, got %+v", got)
+	}
+	if got := getChanges(t, "bob", ts.URL, token); got.Reset {
+		t.Fatalf("bob's view did not change, got %+v", got)
+	}
+}
+
+// downgradeSnapshotToV4 rewrites the snapshot in dir as a version 4 file,
+// which has no visibility sections.
+func downgradeSnapshotToV4(t *testing.T, dir string) {
+	t.Helper()
+	path := filepath.Join(dir, "tasks.snapshot")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f map[string]json.RawMessage
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	delete(f, "visibility")
+	delete(f, "visibility_floor")
+	f["version"] = json.RawMessage("4")
+	h := sha256.New()
+	for _, section := range []string{"tasks", "teams", "comments", "attachments", "tombstones"} {
+		h.Write(f[section])
+	}
+	sum, _ := json.Marshal(hex.EncodeToString(h.Sum(nil)))
+	f["checksum"] = sum
+	if data, err = json.Marshal(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersistence_KeepsTheVisibilityFloorOfOldSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	ts, repo := newPersistentTestServer(t, dir)
+
+	team := setupTeam(t, ts.URL)
+	token := getChanges(t, "carol", ts.URL, "").Token
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Team plan", "team_id": team.ID})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+	repo.Close()
+	downgradeSnapshotToV4(t, dir)
+
+	// Loading the old file sets the floor; the next snapshot has to keep it.
+	ts, repo = newPersistentTestServer(t, dir)
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+	repo.Close()
+
+	ts, repo = newPersistentTestServer(t, dir)
+	defer ts.Close()
+	defer repo.Close()
+
+	if got := getChanges(t, "carol", ts.URL, token); !got.Reset {
+		t.Fatalf("expected a token from before the old snapshot to reset, got %+v", got)
+	}
+}
+
+func TestSync_CreatesAreIdempotentPerClientID(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	madeAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	create := map[string]any{
+		"op": "create", "client_id": "draft-1", "changed_at": madeAt,
+		"fields": map[string]any{"title": "Call the plumber", "completed": true},
+	}
+	first := postSync(t, "alice", ts.URL, create).Results[0]
+	if first.Status != "applied" || first.Task == nil {
+		t.Fatalf("unexpected create result %+v", first)
+	}
+	if at := first.Task.FieldClocks[model.FieldTitle].At; !at.Equal(madeAt) {
+		t.Fatalf("expected the title clock at %v, got %v", madeAt, at)
+	}
+
+	// The client timed out and sends the same create again.
+	again := postSync(t, "alice", ts.URL, create).Results[0]
+	if again.Status != "applied" || again.Task == nil || again.Task.ID != first.Task.ID || again.Task.Version != first.Task.Version {
+		t.Fatalf("expected the first task back unchanged, got %+v", again)
+	}
+
+	// Client ids are per user.
+	other := postSync(t, "bob", ts.URL, create).Results[0]
+	if other.Task == nil || other.Task.ID == first.Task.ID {
+		t.Fatalf("expected bob to get a task of his own, got %+v", other)
+	}
+
+	resp, data := doAs(t, "alice", http.MethodGet, ts.URL+"/tasks", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: status=%d", resp.StatusCode)
+	}
+	if count, _ := decodeList(t, data); count != 1 {
+		t.Fatalf("expected one task for alice, got %d", count)
+	}
+}
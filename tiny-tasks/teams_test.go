@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"tiny-tasks/internal/model"
+)
+
+// doAs is doJSON for requests made on behalf of userID.
+func doAs(t *testing.T, userID, method, url string, body any) (*http.Response, []byte) {
+	t.Helper()
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if userID != "" {
+		req.Header.Set("X-User-Id", userID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp, data
+}
+
+func setupTeam(t *testing.T, baseURL string) model.Team {
+	t.Helper()
+
+	resp, data := doAs(t, "alice", http.MethodPost, baseURL+"/teams", map[string]any{"name": "Platform"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create team: expected 201, got %d body=%s", resp.StatusCode, string(data))
+	}
+	var team model.Team
+	if err := json.Unmarshal(data, &team); err != nil {
+		t.Fatalf("unmarshal team: %v", err)
+	}
+
+	for user, role := range map[string]model.Role{"bob": model.RoleMember, "carol": model.RoleViewer} {
+		resp, data := doAs(t, "alice", http.MethodPut, baseURL+"/teams/"+team.ID+"/members/"+user, map[string]any{"role": role})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("add %s: expected 200, got %d body=%s", user, resp.StatusCode, string(data))
+		}
+	}
+	return team
+}
+
+func TestTeamTaskPermissions(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title": "Rotate certificates", "team_id": team.ID, "assignee": "bob",
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d body=%s", resp.StatusCode, string(data))
+	}
+	created := decodeTask(t, data)
+	if created.OwnerID != "alice" || created.Assignee != "bob" {
+		t.Fatalf("expected owner alice and assignee bob, got %q and %q", created.OwnerID, created.Assignee)
+	}
+	taskURL := ts.URL + "/tasks/" + created.ID
+
+	cases := []struct {
+		name   string
+		user   string
+		method string
+		body   any
+		want   int
+	}{
+		{"outsider cannot view", "dave", http.MethodGet, nil, http.StatusForbidden},
+		{"anonymous cannot view", "", http.MethodGet, nil, http.StatusForbidden},
+		{"viewer can view", "carol", http.MethodGet, nil, http.StatusOK},
+		{"viewer cannot edit", "carol", http.MethodPatch, map[string]any{"title": "Renamed"}, http.StatusForbidden},
+		{"viewer cannot complete", "carol", http.MethodPatch, map[string]any{"completed": true}, http.StatusForbidden},
+		{"assignee can complete", "bob", http.MethodPatch, map[string]any{"completed": true}, http.StatusOK},
+		{"assignee can edit", "bob", http.MethodPatch, map[string]any{"title": "Rotate TLS certificates"}, http.StatusOK},
+		{"member cannot delete", "bob", http.MethodDelete, nil, http.StatusForbidden},
+		{"outsider cannot delete", "dave", http.MethodDelete, nil, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, data := doAs(t, tc.user, tc.method, taskURL, tc.body)
+			if resp.StatusCode != tc.want {
+				t.Fatalf("expected %d, got %d body=%s", tc.want, resp.StatusCode, string(data))
+			}
+		})
+	}
+
+	resp, data = doAs(t, "alice", http.MethodDelete, taskURL, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("owner delete: expected 204, got %d body=%s", resp.StatusCode, string(data))
+	}
+}
+
+func TestListAssigneeMe(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+
+	var forBob, forAlice string
+	for assignee, id := range map[string]*string{"bob": &forBob, "alice": &forAlice} {
+		resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{
+			"title": "Task for " + assignee, "team_id": team.ID, "assignee": assignee,
+		})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create: expected 201, got %d body=%s", resp.StatusCode, string(data))
+		}
+		*id = decodeTask(t, data).ID
+	}
+	resp, data := doAs(t, "dave", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Private errand"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d body=%s", resp.StatusCode, string(data))
+	}
+
+	resp, data = doAs(t, "bob", http.MethodGet, ts.URL+"/tasks?assignee=me", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d body=%s", resp.StatusCode, string(data))
+	}
+	count, items := decodeList(t, data)
+	if count != 1 || items[0].ID != forBob {
+		t.Fatalf("expected only %s, got %+v", forBob, items)
+	}
+
+	// Without the assignee filter bob sees the whole team's tasks but not
+	// dave's private one.
+	resp, data = doAs(t, "bob", http.MethodGet, ts.URL+"/tasks", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d body=%s", resp.StatusCode, string(data))
+	}
+	if count, _ := decodeList(t, data); count != 2 {
+		t.Fatalf("expected 2 visible tasks, got %d body=%s", count, string(data))
+	}
+
+	resp, data = doAs(t, "dave", http.MethodGet, ts.URL+"/tasks?assignee=bob", nil)
+	if count, _ := decodeList(t, data); resp.StatusCode != http.StatusOK || count != 0 {
+		t.Fatalf("outsider should see no team tasks, got %d body=%s", resp.StatusCode, string(data))
+	}
+
+	resp, _ = doAs(t, "", http.MethodGet, ts.URL+"/tasks?assignee=me", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("anonymous assignee=me: expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestTeamMembershipRules(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+	teamURL := ts.URL + "/teams/" + team.ID
+
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title": "Hand to a stranger", "team_id": team.ID, "assignee": "dave",
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("assignee outside team: expected 400, got %d body=%s", resp.StatusCode, string(data))
+	}
+
+	resp, _ = doAs(t, "dave", http.MethodPost, ts.URL+"/tasks", map[string]any{"title": "Sneak in", "team_id": team.ID})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("outsider creating in team: expected 403, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doAs(t, "dave", http.MethodGet, teamURL, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("outsider reading team: expected 403, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doAs(t, "bob", http.MethodPut, teamURL+"/members/dave", map[string]any{"role": "member"})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("member adding members: expected 403, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doAs(t, "alice", http.MethodPut, teamURL+"/members/alice", map[string]any{"role": "member"})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("demoting the last admin: expected 409, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doAs(t, "alice", http.MethodPut, teamURL+"/members/bob", map[string]any{"role": "owner"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown role: expected 400, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doAs(t, "", http.MethodPost, ts.URL+"/teams", map[string]any{"name": "Nobody's"})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("anonymous team: expected 401, got %d", resp.StatusCode)
+	}
+
+	resp, data = doAs(t, "alice", http.MethodDelete, teamURL+"/members/carol", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("remove member: expected 200, got %d body=%s", resp.StatusCode, string(data))
+	}
+	resp, _ = doAs(t, "carol", http.MethodGet, teamURL, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("removed member: expected 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestTeamTaskPlacement(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+	// bob also belongs to a team of his own, where he could move tasks.
+	resp, data := doAs(t, "bob", http.MethodPost, ts.URL+"/teams", map[string]any{"name": "Bob's"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create team: expected 201, got %d body=%s", resp.StatusCode, string(data))
+	}
+	var other model.Team
+	if err := json.Unmarshal(data, &other); err != nil {
+		t.Fatalf("unmarshal team: %v", err)
+	}
+
+	resp, data = doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title": "Renew the domain", "team_id": team.ID, "assignee": "bob",
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d body=%s", resp.StatusCode, string(data))
+	}
+	taskURL := ts.URL + "/tasks/" + decodeTask(t, data).ID
+
+	cases := []struct {
+		name string
+		user string
+		body any
+		want int
+	}{
+		{"assignee cannot move it", "bob", map[string]any{"team_id": other.ID}, http.StatusForbidden},
+		{"assignee cannot hand it on", "bob", map[string]any{"assignee": "carol"}, http.StatusForbidden},
+		{"admin can reassign", "alice", map[string]any{"assignee": "carol"}, http.StatusOK},
+		{"personal assignee must share a team", "alice", map[string]any{"team_id": "", "assignee": "dave"}, http.StatusBadRequest},
+		{"owner can make it personal", "alice", map[string]any{"team_id": "", "assignee": "bob"}, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, data := doAs(t, tc.user, http.MethodPatch, taskURL, tc.body)
+			if resp.StatusCode != tc.want {
+				t.Fatalf("expected %d, got %d body=%s", tc.want, resp.StatusCode, string(data))
+			}
+		})
+	}
+}
+
+func TestRemovedMemberLosesAssignedTasks(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	team := setupTeam(t, ts.URL)
+	resp, data := doAs(t, "alice", http.MethodPost, ts.URL+"/tasks", map[string]any{
+		"title": "Patch the servers", "team_id": team.ID, "assignee": "bob",
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d body=%s", resp.StatusCode, string(data))
+	}
+	taskURL := ts.URL + "/tasks/" + decodeTask(t, data).ID
+
+	resp, data = doAs(t, "alice", http.MethodDelete, ts.URL+"/teams/"+team.ID+"/members/bob", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("remove member: expected 200, got %d body=%s", resp.StatusCode, string(data))
+	}
+
+	resp, _ = doAs(t, "bob", http.MethodGet, taskURL, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("removed assignee viewing: expected 403, got %d", resp.StatusCode)
+	}
+	resp, _ = doAs(t, "bob", http.MethodPatch, taskURL, map[string]any{"title": "Patched by bob"})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("removed assignee editing: expected 403, got %d", resp.StatusCode)
+	}
+	resp, data = doAs(t, "bob", http.MethodGet, ts.URL+"/tasks?assignee=me", nil)
+	if resp.StatusCode != http.StatusOK || bytes.Contains(data, []byte("Patch the servers")) {
+		t.Fatalf("removed assignee listing: got %d body=%s", resp.StatusCode, string(data))
+	}
+}
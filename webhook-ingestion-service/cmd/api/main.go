@@ -4,53 +4,105 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
 
+	"webhook-ingestion-service/internal/config"
 	"webhook-ingestion-service/internal/httpapi"
+	"webhook-ingestion-service/internal/observability/logging"
+	"webhook-ingestion-service/internal/observability/metrics"
+	"webhook-ingestion-service/internal/observability/tracing"
 	"webhook-ingestion-service/internal/store/postgres"
 	"webhook-ingestion-service/internal/task"
 )
 
 func main() {
-	dbURL := os.Getenv("DB_URL")
-	secret := os.Getenv("WEBHOOK_SECRET")
-	if dbURL == "" {
-		log.Fatal("DB_URL is required")
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("config", err)
+	}
+
+	logger, err := newLogger(cfg)
+	if err != nil {
+		fatal("logging", err)
+	}
+	// Also routes the standard log package, and so net/http's errors.
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TraceExporter)
+	if err != nil {
+		fatal("OTEL_TRACES_EXPORTER", err)
 	}
-	if secret == "" {
-		log.Fatal("WEBHOOK_SECRET is required")
+
+	providers := httpapi.NewProviderRegistry()
+	for _, p := range cfg.Providers {
+		if err := providers.RegisterScheme(p.Name, p.Scheme, p.Secret); err != nil {
+			fatal("provider", err, logging.KeyProvider, p.Name)
+		}
 	}
 
-	db, err := sql.Open("pgx", dbURL)
+	db, err := sql.Open("pgx", cfg.DBURL)
 	if err != nil {
-		log.Fatalf("open db: %v", err)
+		fatal("open db", err)
 	}
 	defer db.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	if err := db.PingContext(ctx); err != nil {
 		cancel()
-		log.Fatalf("db ping: %v", err)
+		fatal("db ping", err)
 	}
 	cancel()
 
 	repo := postgres.NewEventRepo(db)
 	svc := task.NewService(repo)
+	svc.SetLogger(logger)
+	svc.SetOrderingKeys(cfg.OrderingKeys)
+	svc.SetRedaction(task.Redaction{
+		Fields: append(slices.Clone(task.DefaultRedactFields), cfg.RedactFields...),
+		Paths:  cfg.RedactPaths,
+	})
+	if cfg.SchemasDir != "" {
+		schemas, err := loadSchemas(cfg)
+		if err != nil {
+			fatal("schemas", err)
+		}
+		svc.SetSchemas(schemas)
+		logger.Info("validating event data against schemas", "types", schemas.Types())
+	}
+	secrets := task.NewSecretService(postgres.NewSecretRepo(db), 10*time.Second)
+	deadLetters := task.NewDeadLetterService(repo)
+	eventAdmin := task.NewEventAdminService(repo)
+
+	unknown := task.UnknownSkip
+	if cfg.UnknownTypes != "" {
+		if unknown, err = task.ParseUnknownPolicy(cfg.UnknownTypes); err != nil {
+			fatal("WORKER_UNKNOWN_TYPES", err)
+		}
+	}
+	// Register processors per event type here, e.g.
+	// router.Handle("payment.*", payments, task.RouteOptions{Timeout: 10 * time.Second})
+	router := task.NewRouter(unknown)
 
 	// Worker deps
 	workerDeps := task.WorkerDeps{
 		Repo:      repo,
-		Processor: task.NoopProcessor{}, // podmień później na real processor
+		Processor: router,
 		Backoff:   task.DefaultBackoff(),
+		Retry:     task.RetryPolicy{MaxAttempts: cfg.MaxAttempts, ByType: cfg.MaxAttemptsByType},
 		Now:       func() time.Time { return time.Now().UTC() },
+		WorkerID:  task.NewWorkerID(),
+		Lease:     cfg.Lease,
 		// RNG optional
 	}
 
@@ -58,24 +110,86 @@ func main() {
 	rootCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// Start background worker
+	// New and requeued events wake the pool right away; polling only has to
+	// catch retries coming due.
+	listener := postgres.NewListener(cfg.DBURL, logger)
+
+	poolCfg := task.DefaultPoolConfig()
+	poolCfg.TypeLimits = cfg.TypeLimits
+	poolCfg.Wakeups = listener.C()
+	poolCfg.PollInterval = 2 * time.Second
+	if cfg.PollInterval > 0 {
+		poolCfg.PollInterval = cfg.PollInterval
+	}
+	if cfg.Workers > 0 {
+		poolCfg.Workers = cfg.Workers
+	}
+	if cfg.DrainTimeout > 0 {
+		poolCfg.DrainTimeout = cfg.DrainTimeout
+	}
+	pool := task.NewPool(workerDeps, poolCfg, logger)
+
+	metrics.Registry.MustRegister(
+		metrics.NewQueueCollector(repo, 2*time.Second),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "webhook", Name: "worker_busy", Help: "Workers processing an event.",
+		}, func() float64 { return float64(pool.Stats().Busy) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "webhook", Name: "worker_utilization", Help: "Share of worker time spent processing since start.",
+		}, func() float64 { return pool.Stats().Utilization }),
+	)
+
+	// Start background workers
 	var wg sync.WaitGroup
-	wg.Add(1)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		listener.Run(rootCtx)
+	}()
 	go func() {
 		defer wg.Done()
-		task.RunWorker(rootCtx, workerDeps, task.DefaultWorkerConfig(), log.Default())
+		pool.Run(rootCtx)
+	}()
+	// Returns events of crashed workers to the queue.
+	go func() {
+		defer wg.Done()
+		task.RunReaper(rootCtx, repo, cfg.Lease/2, logger)
 	}()
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", healthHandler())
 	mux.HandleFunc("/readyz", httpapi.ReadyzHandler(db))
-	mux.HandleFunc("/webhooks/provider", httpapi.WebhookProviderHandler(secret, nil, svc))
-	mux.HandleFunc("/events/", httpapi.GetEventHandler(svc))
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("/webhooks/{provider}", httpapi.WebhookHandler(providers, secrets, nil, svc))
+	// ?include=payload needs the admin token; without one it is refused.
+	mux.Handle("/events/", httpapi.RequireAdminForPayload(cfg.AdminToken)(httpapi.GetEventHandler(svc)))
 	mux.HandleFunc("/process/once", httpapi.ProcessOnceHandler(workerDeps))
 
-	handler := httpapi.WithRequestID(log.Default())(
-		httpapi.Logging(log.Default())(
-			mux,
+	if cfg.AdminToken != "" {
+		admin := httpapi.RequireAdmin(cfg.AdminToken)
+		mux.Handle("GET /admin/providers/{provider}/secrets", admin(httpapi.ListSecretsHandler(providers, secrets)))
+		mux.Handle("POST /admin/providers/{provider}/secrets", admin(httpapi.AddSecretHandler(providers, secrets)))
+		mux.Handle("POST /admin/providers/{provider}/secrets/{id}/retire", admin(httpapi.RetireSecretHandler(providers, secrets)))
+		mux.Handle("GET /events", admin(httpapi.ListEventsHandler(eventAdmin)))
+		mux.Handle("GET /events/{id}/attempts", admin(httpapi.ListEventAttemptsHandler(eventAdmin)))
+		mux.Handle("POST /events/{id}/replay", admin(httpapi.ReplayEventHandler(eventAdmin)))
+		mux.Handle("POST /events:replay", admin(httpapi.ReplayEventsHandler(eventAdmin)))
+		mux.Handle("GET /admin/worker", admin(httpapi.WorkerStatsHandler(pool)))
+		mux.Handle("GET /admin/dead-letters", admin(httpapi.ListDeadLettersHandler(deadLetters)))
+		mux.Handle("GET /admin/dead-letters/{id}", admin(httpapi.GetDeadLetterHandler(deadLetters)))
+		mux.Handle("POST /admin/dead-letters/requeue", admin(httpapi.RequeueDeadLettersHandler(deadLetters)))
+		mux.Handle("POST /admin/dead-letters/discard", admin(httpapi.DiscardDeadLettersHandler(deadLetters)))
+		mux.Handle("POST /admin/dead-letters/{id}/requeue", admin(httpapi.RequeueDeadLettersHandler(deadLetters)))
+		mux.Handle("POST /admin/dead-letters/{id}/discard", admin(httpapi.DiscardDeadLettersHandler(deadLetters)))
+	} else {
+		logger.Warn("ADMIN_TOKEN not set: admin endpoints disabled")
+	}
+
+	handler := httpapi.WithRequestID(
+		httpapi.Tracing(
+			httpapi.Logging(logger)(
+				mux,
+			),
 		),
 	)
 
@@ -86,26 +200,79 @@ func main() {
 	}
 
 	go func() {
-		log.Printf("listening on %s", srv.Addr)
+		logger.Info("listening", "addr", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("server: %v", err)
+			fatal("server", err)
 		}
 	}()
 
 	<-rootCtx.Done()
-	log.Printf("shutdown signal received")
+	logger.Info("shutdown signal received")
 
 	// Stop accepting new requests; wait for in-flight with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("http shutdown error: %v", err)
+		logger.Error("http shutdown", logging.KeyError, err)
 	}
 
-	// Wait for worker to stop (it stops because rootCtx is cancelled)
+	// Wait for the pool to drain (it stops because rootCtx is cancelled)
 	wg.Wait()
-	log.Printf("bye")
+
+	// Flush the spans of the last events.
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("tracing shutdown", logging.KeyError, err)
+	}
+	logger.Info("bye")
+}
+
+func newLogger(cfg config.Config) (*slog.Logger, error) {
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	logger, err := logging.New(os.Stderr, logging.Options{
+		Format: cfg.LogFormat,
+		Level:  level,
+		Sampling: logging.Sampling{
+			Initial:    cfg.LogSampleInitial,
+			Thereafter: cfg.LogSampleThereafter,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("LOG_FORMAT: %w", err)
+	}
+	return logger, nil
+}
+
+// fatal logs err with the default logger and exits.
+func fatal(msg string, err error, args ...any) {
+	slog.Error(msg, append([]any{logging.KeyError, err}, args...)...)
+	os.Exit(1)
+}
+
+func loadSchemas(cfg config.Config) (*task.Schemas, error) {
+	schemas, err := task.LoadSchemas(cfg.SchemasDir)
+	if err != nil {
+		return nil, fmt.Errorf("EVENT_SCHEMAS_DIR: %w", err)
+	}
+	policy := task.InvalidReject
+	if cfg.SchemaInvalid != "" {
+		if policy, err = task.ParseInvalidPayloadPolicy(cfg.SchemaInvalid); err != nil {
+			return nil, fmt.Errorf("EVENT_SCHEMA_INVALID: %w", err)
+		}
+	}
+	byType := map[string]task.InvalidPayloadPolicy{}
+	for eventType, v := range cfg.SchemaInvalidByType {
+		if byType[eventType], err = task.ParseInvalidPayloadPolicy(v); err != nil {
+			return nil, fmt.Errorf("EVENT_SCHEMA_INVALID_BY_TYPE: %s: %w", eventType, err)
+		}
+	}
+	schemas.SetPolicy(policy, byType)
+	return schemas, nil
 }
 
 func healthHandler() http.HandlerFunc {
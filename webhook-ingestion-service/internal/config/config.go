@@ -2,26 +2,314 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	DBURL         string
-	WebhookSecret string
+	DBURL     string
+	Providers []Provider
+	// AdminToken guards the /admin endpoints; they are disabled when empty.
+	AdminToken string
+	// MaxAttempts caps processing attempts before an event is
+	// dead-lettered; 0 retries forever. MaxAttemptsByType overrides it.
+	MaxAttempts       int
+	MaxAttemptsByType map[string]int
+	// UnknownTypes is what the worker does with event types no processor is
+	// registered for: skip (default), fail or dead.
+	UnknownTypes string
+	// Lease is how long a claimed event stays with a worker that stopped
+	// sending heartbeats; zero means the worker's default.
+	Lease time.Duration
+	// Workers is the size of the worker pool; TypeLimits caps how many
+	// events of a type are processed at once. Zero values mean defaults.
+	Workers      int
+	TypeLimits   map[string]int
+	DrainTimeout time.Duration
+	// PollInterval is how often idle workers look for due retries; new
+	// events wake them through LISTEN/NOTIFY without waiting.
+	PollInterval time.Duration
+	// OrderingKeys maps event types to the JSON path of their ordering key;
+	// events sharing a key are processed one at a time, in order.
+	OrderingKeys map[string]string
+	// RedactFields and RedactPaths are hidden, on top of the defaults, when
+	// an event's payload is shown.
+	RedactFields []string
+	RedactPaths  []string
+	// SchemasDir holds a JSON Schema per event type; empty disables
+	// validation. SchemaInvalid (reject or quarantine) is what happens to
+	// events failing theirs, overridden per type by SchemaInvalidByType.
+	SchemasDir          string
+	SchemaInvalid       string
+	SchemaInvalidByType map[string]string
+	// TraceExporter is where spans go: otlp, stdout, or none (default).
+	TraceExporter string
+	// LogFormat is text (default) or json; LogLevel debug, info (default),
+	// warn or error.
+	LogFormat string
+	LogLevel  string
+	// LogSampleInitial and LogSampleThereafter sample Info and Debug
+	// records per message and second: the first LogSampleInitial are
+	// logged, then every LogSampleThereafter-th. Warnings and errors are
+	// never sampled; LogSampleInitial 0 turns sampling off.
+	LogSampleInitial    int
+	LogSampleThereafter int
 }
 
+// Defaults of LOG_SAMPLE_INITIAL and LOG_SAMPLE_THEREAFTER.
+const (
+	DefaultLogSampleInitial    = 100
+	DefaultLogSampleThereafter = 100
+)
+
+// DefaultMaxAttempts applies when WORKER_MAX_ATTEMPTS is not set.
+const DefaultMaxAttempts = 10
+
+// Provider is one entry of WEBHOOK_PROVIDERS.
+type Provider struct {
+	Name   string
+	Scheme string
+	Secret string
+}
+
+// Load reads the environment.
+//
+// WEBHOOK_PROVIDERS lists the providers as "name[=scheme]" separated by
+// commas, e.g. "stripe,gh=github,acme=generic"; the scheme defaults to the
+// name. A provider's secret may come from WEBHOOK_SECRET_<NAME>, with the
+// name uppercased and '-' replaced by '_'; secrets can also be managed through
+// the admin API, which stores them in Postgres.
+//
+// Without WEBHOOK_PROVIDERS, WEBHOOK_SECRET configures the single "provider"
+// provider on the generic scheme, as before.
+//
+// WORKER_MAX_ATTEMPTS sets the attempts before an event is dead-lettered and
+// WORKER_MAX_ATTEMPTS_BY_TYPE overrides it per event type as "type=n" pairs
+// separated by commas, e.g. "invoice.paid=20,ping=1". WORKER_UNKNOWN_TYPES
+// chooses what happens to event types without a processor. WORKER_LEASE
+// (e.g. "30s") bounds how long a crashed worker holds on to an event.
+//
+// EVENT_ORDERING_KEYS lists "type=path" pairs separated by commas, e.g.
+// "payment.succeeded=data.object.id,charge.refunded=data.object.payment_intent";
+// the path is dot-separated and leads to a string or number in the payload.
+//
+// PAYLOAD_REDACT lists more payload fields to hide from GET /events/{id},
+// separated by commas: a plain name ("email") is hidden at any depth, as is
+// any field ending in a "*" pattern's suffix ("*_pin"), and a dot-separated
+// path ("data.object.customer.email") only there.
+//
+// EVENT_SCHEMAS_DIR points to "<event type>.json" schemas for the data of
+// incoming events. EVENT_SCHEMA_INVALID (reject or quarantine) handles
+// events failing theirs, and EVENT_SCHEMA_INVALID_BY_TYPE overrides it as
+// "type=policy" pairs.
+//
+// OTEL_TRACES_EXPORTER chooses where spans are exported: otlp, to the
+// collector set by the standard OTEL_EXPORTER_OTLP_* variables, stdout, or
+// none.
+//
+// LOG_FORMAT (text or json) and LOG_LEVEL configure logging, and
+// LOG_SAMPLE_INITIAL and LOG_SAMPLE_THEREAFTER the sampling of
+// high-volume records such as access logs and processed events.
 func Load() (Config, error) {
 	cfg := Config{
 		DBURL:         os.Getenv("DB_URL"),
-		WebhookSecret: os.Getenv("WEBHOOK_SECRET"),
+		AdminToken:    os.Getenv("ADMIN_TOKEN"),
+		MaxAttempts:   DefaultMaxAttempts,
+		UnknownTypes:  os.Getenv("WORKER_UNKNOWN_TYPES"),
+		SchemasDir:    strings.TrimSpace(os.Getenv("EVENT_SCHEMAS_DIR")),
+		SchemaInvalid: os.Getenv("EVENT_SCHEMA_INVALID"),
+		TraceExporter: strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER")),
+		LogFormat:     strings.TrimSpace(os.Getenv("LOG_FORMAT")),
+		LogLevel:      strings.TrimSpace(os.Getenv("LOG_LEVEL")),
+
+		LogSampleInitial:    DefaultLogSampleInitial,
+		LogSampleThereafter: DefaultLogSampleThereafter,
 	}
 	if cfg.DBURL == "" {
 		return Config{}, errors.New("DB_URL is required")
 	}
-	if cfg.WebhookSecret == "" {
-		// na start możesz pozwolić na empty i dodać później,
-		// ale w take-home lepiej wymusić
-		return Config{}, errors.New("WEBHOOK_SECRET is required")
+
+	if v := strings.TrimSpace(os.Getenv("WORKER_MAX_ATTEMPTS")); v != "" {
+		n, err := parseAttempts(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("WORKER_MAX_ATTEMPTS: %w", err)
+		}
+		cfg.MaxAttempts = n
+	}
+	for env, dst := range map[string]*int{
+		"LOG_SAMPLE_INITIAL":    &cfg.LogSampleInitial,
+		"LOG_SAMPLE_THEREAFTER": &cfg.LogSampleThereafter,
+	} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			n, err := parseAttempts(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", env, err)
+			}
+			*dst = n
+		}
+	}
+	lease, err := parseDuration("WORKER_LEASE")
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Lease = lease
+	byType, err := parseTypeCounts(os.Getenv("WORKER_MAX_ATTEMPTS_BY_TYPE"))
+	if err != nil {
+		return Config{}, fmt.Errorf("WORKER_MAX_ATTEMPTS_BY_TYPE: %w", err)
+	}
+	cfg.MaxAttemptsByType = byType
+
+	if v := strings.TrimSpace(os.Getenv("WORKER_CONCURRENCY")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Config{}, fmt.Errorf("WORKER_CONCURRENCY: %q is not a positive integer", v)
+		}
+		cfg.Workers = n
+	}
+	limits, err := parseTypeCounts(os.Getenv("WORKER_TYPE_LIMITS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("WORKER_TYPE_LIMITS: %w", err)
+	}
+	cfg.TypeLimits = limits
+	drain, err := parseDuration("WORKER_DRAIN_TIMEOUT")
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.DrainTimeout = drain
+	poll, err := parseDuration("WORKER_POLL_INTERVAL")
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.PollInterval = poll
+	ordering, err := parseTypePaths(os.Getenv("EVENT_ORDERING_KEYS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("EVENT_ORDERING_KEYS: %w", err)
+	}
+	cfg.OrderingKeys = ordering
+	invalid, err := parseTypeValues(os.Getenv("EVENT_SCHEMA_INVALID_BY_TYPE"), "type=policy")
+	if err != nil {
+		return Config{}, fmt.Errorf("EVENT_SCHEMA_INVALID_BY_TYPE: %w", err)
+	}
+	cfg.SchemaInvalidByType = invalid
+	for _, entry := range strings.Split(os.Getenv("PAYLOAD_REDACT"), ",") {
+		switch entry = strings.TrimSpace(entry); {
+		case entry == "":
+		case strings.Contains(entry, "."):
+			cfg.RedactPaths = append(cfg.RedactPaths, entry)
+		default:
+			cfg.RedactFields = append(cfg.RedactFields, entry)
+		}
+	}
+
+	list := strings.TrimSpace(os.Getenv("WEBHOOK_PROVIDERS"))
+	if list == "" {
+		secret := os.Getenv("WEBHOOK_SECRET")
+		if secret == "" {
+			return Config{}, errors.New("WEBHOOK_PROVIDERS or WEBHOOK_SECRET is required")
+		}
+		cfg.Providers = []Provider{{Name: "provider", Scheme: "generic", Secret: secret}}
+		return cfg, nil
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, scheme, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if !ok {
+			scheme = name
+		}
+
+		cfg.Providers = append(cfg.Providers, Provider{Name: name, Scheme: scheme, Secret: os.Getenv(SecretEnv(name))})
+	}
+	if len(cfg.Providers) == 0 {
+		return Config{}, errors.New("WEBHOOK_PROVIDERS lists no providers")
 	}
 	return cfg, nil
 }
+
+// SecretEnv is the variable holding a provider's secret.
+func SecretEnv(provider string) string {
+	return "WEBHOOK_SECRET_" + strings.ToUpper(strings.ReplaceAll(provider, "-", "_"))
+}
+
+// parseTypeCounts parses "type=n" pairs separated by commas.
+func parseTypeCounts(list string) (map[string]int, error) {
+	out := map[string]int{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eventType, v, ok := strings.Cut(entry, "=")
+		eventType = strings.TrimSpace(eventType)
+		if !ok || eventType == "" {
+			return nil, fmt.Errorf("%q is not type=n", entry)
+		}
+		n, err := parseAttempts(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", eventType, err)
+		}
+		out[eventType] = n
+	}
+	return out, nil
+}
+
+// parseTypePaths parses "type=path" pairs separated by commas.
+func parseTypePaths(list string) (map[string]string, error) {
+	out, err := parseTypeValues(list, "type=path")
+	if err != nil {
+		return nil, err
+	}
+	for eventType, path := range out {
+		if strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+			return nil, fmt.Errorf("%s: %q has an empty path segment", eventType, path)
+		}
+	}
+	return out, nil
+}
+
+// parseTypeValues parses "type=value" pairs separated by commas; form names
+// the pair in errors.
+func parseTypeValues(list string, form string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eventType, v, ok := strings.Cut(entry, "=")
+		eventType, v = strings.TrimSpace(eventType), strings.TrimSpace(v)
+		if !ok || eventType == "" || v == "" {
+			return nil, fmt.Errorf("%q is not %s", entry, form)
+		}
+		out[eventType] = v
+	}
+	return out, nil
+}
+
+func parseDuration(env string) (time.Duration, error) {
+	v := strings.TrimSpace(os.Getenv(env))
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s: %q is not a positive duration", env, v)
+	}
+	return d, nil
+}
+
+func parseAttempts(v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative integer", v)
+	}
+	return n, nil
+}
@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireAdmin guards admin endpoints with "Authorization: Bearer <token>".
+// It is separate from webhook signatures: providers never see the token.
+func RequireAdmin(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				writeError(w, http.StatusUnauthorized, "admin token required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAdminForPayload guards only requests asking for an event's payload
+// with ?include=payload, so payloads are never served without the admin
+// token while the rest of the event stays public.
+func RequireAdminForPayload(token string) func(http.Handler) http.Handler {
+	admin := RequireAdmin(token)
+	return func(next http.Handler) http.Handler {
+		guarded := admin(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Has("include") {
+				guarded.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+// ListDeadLettersHandler serves GET /admin/dead-letters with optional type,
+// provider and limit query parameters.
+func ListDeadLettersHandler(dead *task.DeadLetterService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit := 0
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		f := task.DeadLetterFilter{Type: q.Get("type"), Provider: q.Get("provider")}
+		events, err := dead.List(r.Context(), f, limit)
+		if err != nil {
+			writeDeadLetterError(w, err)
+			return
+		}
+		if events == nil {
+			events = []model.Event{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"events": events, "count": len(events)})
+	}
+}
+
+// GetDeadLetterHandler serves GET /admin/dead-letters/{id}; ?provider=
+// picks the event when several providers used the ID.
+func GetDeadLetterHandler(dead *task.DeadLetterService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ev, err := dead.Get(r.Context(), r.URL.Query().Get("provider"), r.PathValue("id"))
+		if err != nil {
+			writeDeadLetterError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ev)
+	}
+}
+
+// RequeueDeadLettersHandler serves POST /admin/dead-letters/{id}/requeue and,
+// for bulk requeues, POST /admin/dead-letters/requeue. The single-event
+// routes act on every provider's event with that ID unless ?provider= is
+// given.
+func RequeueDeadLettersHandler(dead *task.DeadLetterService) http.HandlerFunc {
+	return deadLetterAction(dead.Requeue, "requeued")
+}
+
+// DiscardDeadLettersHandler serves POST /admin/dead-letters/{id}/discard and,
+// for bulk discards, POST /admin/dead-letters/discard.
+func DiscardDeadLettersHandler(dead *task.DeadLetterService) http.HandlerFunc {
+	return deadLetterAction(dead.Discard, "discarded")
+}
+
+type deadLetterBulkRequest struct {
+	IDs      []string `json:"ids"`
+	Type     string   `json:"type"`
+	Provider string   `json:"provider"`
+	All      bool     `json:"all"`
+}
+
+func deadLetterAction(action func(context.Context, task.DeadLetterFilter) (int64, error), done string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		var f task.DeadLetterFilter
+		if id != "" {
+			f = task.DeadLetterFilter{IDs: []string{id}, Provider: r.URL.Query().Get("provider")}
+		} else {
+			var req deadLetterBulkRequest
+			if err := decodeAdminJSON(r, &req); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			f = task.DeadLetterFilter{IDs: req.IDs, Type: req.Type, Provider: req.Provider, All: req.All}
+		}
+
+		n, err := action(r.Context(), f)
+		if err != nil {
+			writeDeadLetterError(w, err)
+			return
+		}
+		if id != "" && n == 0 {
+			writeDeadLetterError(w, model.ErrNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{done: n})
+	}
+}
+
+func writeDeadLetterError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, task.ErrEmptyDeadLetterFilter),
+		errors.Is(err, task.ErrTooManyIDs):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		writeError(w, http.StatusNotFound, "dead-lettered event not found")
+	case errors.Is(err, task.ErrAmbiguousEventID):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,169 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+type fakeDeadLetterRepo struct {
+	mu     sync.Mutex
+	events map[string]model.Event
+}
+
+func (f *fakeDeadLetterRepo) matching(flt task.DeadLetterFilter) []string {
+	var ids []string
+	for id, e := range f.events {
+		if e.Status != model.StatusDead ||
+			(len(flt.IDs) > 0 && !slices.Contains(flt.IDs, id)) ||
+			(flt.Type != "" && e.Type != flt.Type) ||
+			(flt.Provider != "" && e.Provider != flt.Provider) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (f *fakeDeadLetterRepo) ListDead(ctx context.Context, flt task.DeadLetterFilter, limit int) ([]model.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []model.Event
+	for _, id := range f.matching(flt) {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, f.events[id])
+	}
+	return out, nil
+}
+
+func (f *fakeDeadLetterRepo) RequeueDead(ctx context.Context, flt task.DeadLetterFilter) (int64, error) {
+	return f.set(flt, model.StatusReceived)
+}
+
+func (f *fakeDeadLetterRepo) DiscardDead(ctx context.Context, flt task.DeadLetterFilter) (int64, error) {
+	return f.set(flt, model.StatusDiscarded)
+}
+
+func (f *fakeDeadLetterRepo) set(flt task.DeadLetterFilter, status model.EventStatus) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := f.matching(flt)
+	for _, id := range ids {
+		e := f.events[id]
+		e.Status = status
+		f.events[id] = e
+	}
+	return int64(len(ids)), nil
+}
+
+func deadLettersMux(repo *fakeDeadLetterRepo) http.Handler {
+	dead := task.NewDeadLetterService(repo)
+	admin := RequireAdmin(testAdminToken)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /admin/dead-letters", admin(ListDeadLettersHandler(dead)))
+	mux.Handle("GET /admin/dead-letters/{id}", admin(GetDeadLetterHandler(dead)))
+	mux.Handle("POST /admin/dead-letters/requeue", admin(RequeueDeadLettersHandler(dead)))
+	mux.Handle("POST /admin/dead-letters/discard", admin(DiscardDeadLettersHandler(dead)))
+	mux.Handle("POST /admin/dead-letters/{id}/requeue", admin(RequeueDeadLettersHandler(dead)))
+	mux.Handle("POST /admin/dead-letters/{id}/discard", admin(DiscardDeadLettersHandler(dead)))
+	return mux
+}
+
+func newDeadEvent(id, provider, eventType string) model.Event {
+	lastErr := "downstream unavailable"
+	now := time.Now().UTC()
+	return model.Event{ID: id, Provider: provider, Type: eventType, Status: model.StatusDead, Attempts: 10, LastError: &lastErr, DeadAt: &now}
+}
+
+func TestDeadLetters_ListInspectRequeueDiscard(t *testing.T) {
+	repo := &fakeDeadLetterRepo{events: map[string]model.Event{}}
+	for _, e := range []model.Event{
+		newDeadEvent("evt_1", "stripe", "invoice.paid"),
+		newDeadEvent("evt_2", "stripe", "invoice.paid"),
+		newDeadEvent("evt_3", "github", "push"),
+		newDeadEvent("evt_4", "github", "push"),
+	} {
+		repo.events[e.ID] = e
+	}
+	ok := repo.events["evt_1"]
+	ok.Status = model.StatusProcessed
+	repo.events["evt_ok"] = ok
+	h := deadLettersMux(repo)
+
+	w := adminRequest(t, h, http.MethodGet, "/admin/dead-letters?provider=stripe", testAdminToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status=%d body=%s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Count  int           `json:"count"`
+		Events []model.Event `json:"events"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != 2 || list.Events[0].ID != "evt_1" || list.Events[1].ID != "evt_2" {
+		t.Fatalf("unexpected list %+v", list)
+	}
+
+	w = adminRequest(t, h, http.MethodGet, "/admin/dead-letters/evt_3", testAdminToken, "")
+	var ev model.Event
+	if err := json.Unmarshal(w.Body.Bytes(), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || ev.LastError == nil || *ev.LastError != "downstream unavailable" {
+		t.Fatalf("inspect: status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	cases := []struct {
+		name string
+		path string
+		body string
+		want int
+		resp string
+	}{
+		{"requeue one", "/admin/dead-letters/evt_3/requeue", "", http.StatusOK, `{"requeued":1}`},
+		{"requeue again", "/admin/dead-letters/evt_3/requeue", "", http.StatusNotFound, ""},
+		{"discard non-dead", "/admin/dead-letters/evt_ok/discard", "", http.StatusNotFound, ""},
+		{"bulk without filter", "/admin/dead-letters/discard", `{}`, http.StatusBadRequest, ""},
+		{"bulk without body", "/admin/dead-letters/requeue", "", http.StatusBadRequest, ""},
+		{"bulk by type", "/admin/dead-letters/requeue", `{"type":"invoice.paid"}`, http.StatusOK, `{"requeued":2}`},
+		{"bulk all", "/admin/dead-letters/discard", `{"all":true}`, http.StatusOK, `{"discarded":1}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := adminRequest(t, h, http.MethodPost, tc.path, testAdminToken, tc.body)
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d body=%s", tc.want, w.Code, w.Body.String())
+			}
+			if tc.resp != "" && w.Body.String() != tc.resp+"\n" {
+				t.Fatalf("unexpected body %s", w.Body.String())
+			}
+		})
+	}
+
+	want := map[string]model.EventStatus{
+		"evt_1": model.StatusReceived, "evt_2": model.StatusReceived,
+		"evt_3": model.StatusReceived, "evt_4": model.StatusDiscarded, "evt_ok": model.StatusProcessed,
+	}
+	for id, status := range want {
+		if got := repo.events[id].Status; got != status {
+			t.Fatalf("%s: expected %s, got %s", id, status, got)
+		}
+	}
+
+	if w := adminRequest(t, h, http.MethodGet, "/admin/dead-letters", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,169 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+// ListEventsHandler serves GET /events. Filters are the query parameters
+// status (comma-separated), type, provider, created_after, created_before
+// (RFC 3339), min_attempts and error (a last_error substring); limit and
+// cursor page through the results, newest first.
+func ListEventsHandler(events *task.EventAdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f, err := eventFilterFromQuery(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit := 0
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		page, err := events.List(r.Context(), f, q.Get("cursor"), limit)
+		if err != nil {
+			writeEventAdminError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+// ReplayEventHandler serves POST /events/{id}/replay; ?provider= picks the
+// event when several providers used the ID.
+func ReplayEventHandler(events *task.EventAdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := events.ReplayOne(r.Context(), r.URL.Query().Get("provider"), r.PathValue("id")); err != nil {
+			writeEventAdminError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"replayed": 1})
+	}
+}
+
+// ListEventAttemptsHandler serves GET /events/{id}/attempts, with
+// ?provider= as for replays.
+func ListEventAttemptsHandler(events *task.EventAdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		attempts, err := events.Attempts(r.Context(), r.URL.Query().Get("provider"), id)
+		if err != nil {
+			writeEventAdminError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"event_id": id, "attempts": attempts})
+	}
+}
+
+type replayRequest struct {
+	IDs           []string   `json:"ids"`
+	Status        []string   `json:"status"`
+	Type          string     `json:"type"`
+	Provider      string     `json:"provider"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+	MinAttempts   int        `json:"min_attempts"`
+	Error         string     `json:"error"`
+	All           bool       `json:"all"`
+}
+
+// ReplayEventsHandler serves POST /events:replay. The body takes the filters
+// of GET /events plus ids; {"all":true} replays every event.
+func ReplayEventsHandler(events *task.EventAdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req replayRequest
+		if err := decodeAdminJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		f := task.EventFilter{
+			IDs:           req.IDs,
+			Type:          req.Type,
+			Provider:      req.Provider,
+			MinAttempts:   req.MinAttempts,
+			ErrorContains: req.Error,
+			All:           req.All,
+		}
+		for _, st := range req.Status {
+			f.Statuses = append(f.Statuses, model.EventStatus(st))
+		}
+		if req.CreatedAfter != nil {
+			f.CreatedAfter = *req.CreatedAfter
+		}
+		if req.CreatedBefore != nil {
+			f.CreatedBefore = *req.CreatedBefore
+		}
+
+		n, err := events.Replay(r.Context(), f)
+		if err != nil {
+			writeEventAdminError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"replayed": n})
+	}
+}
+
+func eventFilterFromQuery(q url.Values) (task.EventFilter, error) {
+	f := task.EventFilter{
+		Type:          q.Get("type"),
+		Provider:      q.Get("provider"),
+		ErrorContains: q.Get("error"),
+	}
+	for _, v := range q["status"] {
+		for _, st := range strings.Split(v, ",") {
+			if st = strings.TrimSpace(st); st != "" {
+				f.Statuses = append(f.Statuses, model.EventStatus(st))
+			}
+		}
+	}
+	for name, dst := range map[string]*time.Time{"created_after": &f.CreatedAfter, "created_before": &f.CreatedBefore} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return task.EventFilter{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get("min_attempts"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return task.EventFilter{}, errors.New("min_attempts must be a non-negative integer")
+		}
+		f.MinAttempts = n
+	}
+	return f, nil
+}
+
+func writeEventAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, task.ErrInvalidEventFilter),
+		errors.Is(err, task.ErrInvalidCursor),
+		errors.Is(err, task.ErrEmptyReplayFilter),
+		errors.Is(err, task.ErrTooManyIDs):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		writeError(w, http.StatusNotFound, "event not found")
+	case errors.Is(err, task.ErrAmbiguousEventID):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, model.ErrConflict):
+		writeError(w, http.StatusConflict, "event is being processed")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,269 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+type fakeEventAdminRepo struct {
+	mu       sync.Mutex
+	events   map[string]model.Event
+	attempts map[string][]model.EventAttempt
+}
+
+// matching returns the events of f, newest first.
+func (f *fakeEventAdminRepo) matching(flt task.EventFilter) []model.Event {
+	var out []model.Event
+	for id, e := range f.events {
+		if (len(flt.IDs) > 0 && !slices.Contains(flt.IDs, id)) ||
+			(len(flt.Statuses) > 0 && !slices.Contains(flt.Statuses, e.Status)) ||
+			(flt.Type != "" && e.Type != flt.Type) ||
+			(flt.Provider != "" && e.Provider != flt.Provider) ||
+			(!flt.CreatedAfter.IsZero() && e.CreatedAt.Before(flt.CreatedAfter)) ||
+			(!flt.CreatedBefore.IsZero() && !e.CreatedAt.Before(flt.CreatedBefore)) ||
+			e.Attempts < flt.MinAttempts ||
+			(flt.ErrorContains != "" && (e.LastError == nil || !strings.Contains(strings.ToLower(*e.LastError), strings.ToLower(flt.ErrorContains)))) {
+			continue
+		}
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+func (f *fakeEventAdminRepo) ListEvents(ctx context.Context, flt task.EventFilter, after *task.EventCursor, limit int) ([]model.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []model.Event
+	for _, e := range f.matching(flt) {
+		if after != nil && (e.CreatedAt.After(after.CreatedAt) || (e.CreatedAt.Equal(after.CreatedAt) && e.ID >= after.ID)) {
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (f *fakeEventAdminRepo) ReplayEvents(ctx context.Context, flt task.EventFilter) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	for _, e := range f.matching(flt) {
+		if e.Status == model.StatusProcessing {
+			continue
+		}
+		e.Status, e.Attempts = model.StatusReceived, 0
+		f.events[e.ID] = e
+		n++
+	}
+	return n, nil
+}
+
+func (f *fakeEventAdminRepo) GetByID(ctx context.Context, provider string, id string) (model.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.events[id]
+	if !ok || (provider != "" && e.Provider != provider) {
+		return model.Event{}, model.ErrNotFound
+	}
+	return e, nil
+}
+
+func (f *fakeEventAdminRepo) ListAttempts(ctx context.Context, key model.EventKey) ([]model.EventAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts[key.ID], nil
+}
+
+func eventAdminMux(repo *fakeEventAdminRepo) http.Handler {
+	events := task.NewEventAdminService(repo)
+	admin := RequireAdmin(testAdminToken)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events/", GetEventHandler(task.NewService(newFakeEventRepo())))
+	mux.Handle("GET /events", admin(ListEventsHandler(events)))
+	mux.Handle("GET /events/{id}/attempts", admin(ListEventAttemptsHandler(events)))
+	mux.Handle("POST /events/{id}/replay", admin(ReplayEventHandler(events)))
+	mux.Handle("POST /events:replay", admin(ReplayEventsHandler(events)))
+	return mux
+}
+
+func newAdminTestEvents(base time.Time) *fakeEventAdminRepo {
+	timeout := "context deadline exceeded"
+	repo := &fakeEventAdminRepo{events: map[string]model.Event{}}
+	for i, e := range []model.Event{
+		{ID: "evt_1", Provider: "stripe", Type: "invoice.paid", Status: model.StatusProcessed, Attempts: 1},
+		{ID: "evt_2", Provider: "stripe", Type: "invoice.paid", Status: model.StatusFailed, Attempts: 3, LastError: &timeout},
+		{ID: "evt_3", Provider: "github", Type: "push", Status: model.StatusDead, Attempts: 10, LastError: &timeout},
+		{ID: "evt_4", Provider: "stripe", Type: "invoice.paid", Status: model.StatusProcessing, Attempts: 1},
+		{ID: "evt_5", Provider: "github", Type: "push", Status: model.StatusReceived},
+	} {
+		e.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		repo.events[e.ID] = e
+	}
+	return repo
+}
+
+func TestListEvents_FiltersAndPages(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	h := eventAdminMux(newAdminTestEvents(base))
+
+	list := func(query string) task.EventPage {
+		t.Helper()
+		w := adminRequest(t, h, http.MethodGet, "/events"+query, testAdminToken, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status=%d body=%s", query, w.Code, w.Body.String())
+		}
+		var page task.EventPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+	ids := func(page task.EventPage) []string {
+		var out []string
+		for _, e := range page.Events {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+
+	filters := map[string][]string{
+		"?status=failed,dead":    {"evt_3", "evt_2"},
+		"?provider=stripe":       {"evt_4", "evt_2", "evt_1"},
+		"?type=push&status=dead": {"evt_3"},
+		"?min_attempts=3":        {"evt_3", "evt_2"},
+		"?error=DEADLINE":        {"evt_3", "evt_2"},
+		"?created_after=2026-03-01T12:01:00Z&created_before=2026-03-01T12:03:00Z": {"evt_3", "evt_2"},
+	}
+	for query, want := range filters {
+		if got := ids(list(query)); !slices.Equal(got, want) {
+			t.Fatalf("%s: expected %v, got %v", query, want, got)
+		}
+	}
+
+	var all []string
+	page := list("?limit=2")
+	for {
+		all = append(all, ids(page)...)
+		if page.Next == "" {
+			break
+		}
+		page = list("?limit=2&cursor=" + page.Next)
+	}
+	if want := []string{"evt_5", "evt_4", "evt_3", "evt_2", "evt_1"}; !slices.Equal(all, want) {
+		t.Fatalf("paging: expected %v, got %v", want, all)
+	}
+
+	for _, query := range []string{"?status=bogus", "?min_attempts=-1", "?created_after=yesterday", "?cursor=nope", "?limit=0",
+		"?created_after=2026-03-02T00:00:00Z&created_before=2026-03-01T00:00:00Z"} {
+		if w := adminRequest(t, h, http.MethodGet, "/events"+query, testAdminToken, ""); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d body=%s", query, w.Code, w.Body.String())
+		}
+	}
+	if w := adminRequest(t, h, http.MethodGet, "/events", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestReplayEvents(t *testing.T) {
+	repo := newAdminTestEvents(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	h := eventAdminMux(repo)
+
+	cases := []struct {
+		name string
+		path string
+		body string
+		want int
+		resp string
+	}{
+		{"one", "/events/evt_1/replay", "", http.StatusOK, `{"replayed":1}`},
+		{"one in processing", "/events/evt_4/replay", "", http.StatusConflict, ""},
+		{"unknown", "/events/evt_missing/replay", "", http.StatusNotFound, ""},
+		{"bulk without filter", "/events:replay", `{}`, http.StatusBadRequest, ""},
+		{"bulk without body", "/events:replay", "", http.StatusBadRequest, ""},
+		{"bulk bad status", "/events:replay", `{"status":["bogus"]}`, http.StatusBadRequest, ""},
+		{"bulk by error", "/events:replay", `{"status":["failed","dead"],"error":"deadline"}`, http.StatusOK, `{"replayed":2}`},
+		{"bulk all skips processing", "/events:replay", `{"all":true}`, http.StatusOK, `{"replayed":4}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := adminRequest(t, h, http.MethodPost, tc.path, testAdminToken, tc.body)
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d body=%s", tc.want, w.Code, w.Body.String())
+			}
+			if tc.resp != "" && w.Body.String() != tc.resp+"\n" {
+				t.Fatalf("unexpected body %s", w.Body.String())
+			}
+		})
+	}
+
+	for id, e := range repo.events {
+		want := model.StatusReceived
+		if id == "evt_4" {
+			want = model.StatusProcessing
+		}
+		if e.Status != want {
+			t.Fatalf("%s: expected %s, got %s", id, want, e.Status)
+		}
+	}
+	if w := adminRequest(t, h, http.MethodPost, "/events/evt_1/replay", "wrong", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestListEventAttempts(t *testing.T) {
+	repo := newAdminTestEvents(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	started := time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC)
+	worker, boom := "host-1-abc", "boom"
+	repo.attempts = map[string][]model.EventAttempt{"evt_2": {
+		{Attempt: 1, WorkerID: &worker, StartedAt: started, FinishedAt: started.Add(1500 * time.Millisecond), DurationMS: 1500, Outcome: "failed", Error: &boom},
+		{Attempt: 2, WorkerID: &worker, StartedAt: started.Add(time.Minute), FinishedAt: started.Add(time.Minute), Outcome: "released"},
+	}}
+	h := eventAdminMux(repo)
+
+	w := adminRequest(t, h, http.MethodGet, "/events/evt_2/attempts", testAdminToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		EventID  string               `json:"event_id"`
+		Attempts []model.EventAttempt `json:"attempts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.EventID != "evt_2" || len(resp.Attempts) != 2 || resp.Attempts[0].DurationMS != 1500 ||
+		*resp.Attempts[0].Error != "boom" || resp.Attempts[1].Outcome != "released" {
+		t.Fatalf("unexpected attempts %s", w.Body.String())
+	}
+
+	w = adminRequest(t, h, http.MethodGet, "/events/evt_5/attempts", testAdminToken, "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"attempts":[]`) {
+		t.Fatalf("never attempted: status=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := adminRequest(t, h, http.MethodGet, "/events/evt_missing/attempts", testAdminToken, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown event: expected 404, got %d", w.Code)
+	}
+	if w := adminRequest(t, h, http.MethodGet, "/events/evt_2/attempts", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: expected 401, got %d", w.Code)
+	}
+}
@@ -4,9 +4,17 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"webhook-ingestion-service/internal/observability/logging"
+	"webhook-ingestion-service/internal/observability/tracing"
 )
 
 type ctxKey string
@@ -25,31 +33,31 @@ func RequestIDFromContext(ctx context.Context) string {
 	return ""
 }
 
-func WithRequestID(logger *log.Logger) func(http.Handler) http.Handler {
-	if logger == nil {
-		logger = log.Default()
-	}
-
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			rid := r.Header.Get(RequestIDHeader)
-			if rid == "" {
-				rid = newRequestID()
-			}
-
-			// attach to context + response header
-			ctx := context.WithValue(r.Context(), requestIDKey, rid)
-			r = r.WithContext(ctx)
-			w.Header().Set(RequestIDHeader, rid)
-
-			next.ServeHTTP(w, r)
-		})
-	}
+// WithRequestID takes the request ID from X-Request-Id, or makes one up,
+// and echoes it. It is also added to the logging attributes of the request
+// context, so every record logged with it carries request_id.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rid := r.Header.Get(RequestIDHeader)
+		if rid == "" {
+			rid = newRequestID()
+		}
+
+		// attach to context + response header
+		ctx := context.WithValue(r.Context(), requestIDKey, rid)
+		ctx = logging.With(ctx, logging.KeyRequestID, rid)
+		r = r.WithContext(ctx)
+		w.Header().Set(RequestIDHeader, rid)
+
+		next.ServeHTTP(w, r)
+	})
 }
 
-func Logging(logger *log.Logger) func(http.Handler) http.Handler {
+// Logging writes an access log record per request: at Info for successes,
+// which sampling may thin out, at Warn for 4xx and Error for 5xx responses.
+func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	return func(next http.Handler) http.Handler {
@@ -59,22 +67,56 @@ func Logging(logger *log.Logger) func(http.Handler) http.Handler {
 
 			next.ServeHTTP(sw, r)
 
-			rid := RequestIDFromContext(r.Context())
-			dur := time.Since(start)
-
-			logger.Printf(
-				"rid=%s method=%s path=%s status=%d dur=%s ua=%q",
-				rid,
-				r.Method,
-				r.URL.Path,
-				sw.status,
-				dur,
-				r.UserAgent(),
+			level := slog.LevelInfo
+			switch {
+			case sw.status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case sw.status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			logger.LogAttrs(r.Context(), level, "http request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.status),
+				slog.Duration("dur", time.Since(start)),
+				slog.String("ua", r.UserAgent()),
 			)
 		})
 	}
 }
 
+// Tracing starts a server span per request, continuing the caller's trace
+// if it sent a traceparent header, and names it after the matched route.
+// Put it inside WithRequestID so the span carries the request ID.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				tracing.AttrRequestID.String(RequestIDFromContext(ctx)),
+			),
+		)
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, status: 200}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(sw, r)
+
+		// ServeMux sets the pattern on the request it was handed.
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+	})
+}
+
 type statusWriter struct {
 	http.ResponseWriter
 	status int
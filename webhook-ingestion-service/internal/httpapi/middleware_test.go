@@ -0,0 +1,36 @@
+package httpapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"webhook-ingestion-service/internal/observability/logging"
+)
+
+func TestLogging_LevelsAndRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/bad", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadRequest) })
+	mux.HandleFunc("/boom", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) })
+	h := WithRequestID(Logging(logger)(mux))
+
+	for path, level := range map[string]string{"/ok": "INFO", "/bad": "WARN", "/boom": "ERROR"} {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(RequestIDHeader, "req"+strings.ReplaceAll(path, "/", "-"))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		line := buf.String()
+		if !strings.Contains(line, "level="+level) || !strings.Contains(line, "request_id=req"+strings.ReplaceAll(path, "/", "-")) {
+			t.Fatalf("%s: %q", path, line)
+		}
+	}
+}
@@ -28,3 +28,10 @@ func ProcessOnceHandler(deps task.WorkerDeps) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// WorkerStatsHandler serves GET /admin/worker with the pool's utilization.
+func WorkerStatsHandler(pool *task.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, pool.Stats())
+	}
+}
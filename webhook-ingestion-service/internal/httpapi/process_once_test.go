@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"webhook-ingestion-service/internal/model"
 	"webhook-ingestion-service/internal/task"
 )
 
@@ -18,7 +19,7 @@ type fakeWorkerRepo struct {
 	markProc bool
 }
 
-func (f *fakeWorkerRepo) ClaimNextDue(ctx context.Context) (task.ClaimedEvent, bool, error) {
+func (f *fakeWorkerRepo) ClaimNextDue(ctx context.Context, c task.Claim) (task.ClaimedEvent, bool, error) {
 	if !f.ok {
 		return task.ClaimedEvent{}, false, nil
 	}
@@ -26,12 +27,36 @@ func (f *fakeWorkerRepo) ClaimNextDue(ctx context.Context) (task.ClaimedEvent, b
 	return task.ClaimedEvent{ID: "evt_1", Type: "t", Payload: json.RawMessage(`{}`), Attempts: 1}, true, nil
 }
 
-func (f *fakeWorkerRepo) MarkProcessed(ctx context.Context, id string) error {
+func (f *fakeWorkerRepo) ClaimBatch(ctx context.Context, c task.Claim, n int) ([]task.ClaimedEvent, error) {
+	return nil, nil
+}
+
+func (f *fakeWorkerRepo) MarkProcessedBatch(ctx context.Context, workerID string, keys []model.EventKey) ([]model.EventKey, error) {
+	return nil, nil
+}
+
+func (f *fakeWorkerRepo) MarkFailedBatch(ctx context.Context, workerID string, events []task.FailedEvent) ([]model.EventKey, error) {
+	return nil, nil
+}
+
+func (f *fakeWorkerRepo) ExtendLease(ctx context.Context, key model.EventKey, workerID string, lease time.Duration) error {
+	return nil
+}
+
+func (f *fakeWorkerRepo) MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error {
 	f.markProc = true
 	return nil
 }
 
-func (f *fakeWorkerRepo) MarkFailed(ctx context.Context, id string, lastErr string, nextRetryAt time.Time) error {
+func (f *fakeWorkerRepo) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
+	return nil
+}
+
+func (f *fakeWorkerRepo) ReleaseLease(ctx context.Context, key model.EventKey, workerID string) error {
+	return nil
+}
+
+func (f *fakeWorkerRepo) MarkDead(ctx context.Context, key model.EventKey, workerID string, lastErr string) error {
 	return nil
 }
 
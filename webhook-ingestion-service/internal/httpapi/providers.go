@@ -0,0 +1,208 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"webhook-ingestion-service/internal/httpapi/webhookauth"
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+// envKeyID identifies Provider.Secret among the keys tried for a delivery.
+const envKeyID = task.EnvSecretID
+
+// DefaultProvider is the provider served at the original /webhooks/provider
+// path when only WEBHOOK_SECRET is configured.
+const DefaultProvider = "provider"
+
+var errMissingEventID = errors.New("missing event id")
+
+// Scheme is how one vendor signs its webhooks and where it puts the event ID
+// and type.
+type Scheme struct {
+	Verifier webhookauth.Verifier
+	Extract  func(r *http.Request, body []byte) (id, eventType string, err error)
+}
+
+// Schemes are the built-in schemes, by name.
+var Schemes = map[string]Scheme{
+	"generic": {
+		Verifier: webhookauth.HexTimestamp{},
+		Extract:  extractGeneric,
+	},
+	"stripe": {
+		Verifier: webhookauth.Stripe{},
+		Extract:  extractStripe,
+	},
+	"github": {
+		Verifier: webhookauth.GitHub{},
+		Extract:  extractGitHub,
+	},
+	"slack": {
+		Verifier: webhookauth.Slack{},
+		Extract:  extractSlack,
+	},
+	"shopify": {
+		Verifier: webhookauth.Base64Body{Header: "X-Shopify-Hmac-Sha256"},
+		Extract:  extractShopify,
+	},
+}
+
+type Provider struct {
+	Name string
+	// Secret is the secret from the environment, if any. It is always valid
+	// and is used alongside the secrets stored through the admin API.
+	Secret string
+	Scheme Scheme
+}
+
+// ProviderRegistry maps the {provider} path segment to its configuration.
+type ProviderRegistry struct {
+	providers map[string]Provider
+}
+
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{providers: map[string]Provider{}}
+}
+
+var providerNameRE = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Register adds p. Names are lowercase path segments and must be unique.
+func (r *ProviderRegistry) Register(p Provider) error {
+	if !providerNameRE.MatchString(p.Name) {
+		return fmt.Errorf("provider %q: name must be lowercase letters, digits, '-' or '_'", p.Name)
+	}
+	if _, ok := r.providers[p.Name]; ok {
+		return fmt.Errorf("provider %q registered twice", p.Name)
+	}
+	if p.Scheme.Verifier == nil || p.Scheme.Extract == nil {
+		return fmt.Errorf("provider %q: scheme needs a verifier and an extractor", p.Name)
+	}
+	r.providers[p.Name] = p
+	return nil
+}
+
+// RegisterScheme is Register for a built-in scheme looked up by name.
+func (r *ProviderRegistry) RegisterScheme(name, scheme, secret string) error {
+	s, ok := Schemes[scheme]
+	if !ok {
+		return fmt.Errorf("provider %q: unknown scheme %q (known: %s)", name, scheme, strings.Join(schemeNames(), ", "))
+	}
+	return r.Register(Provider{Name: name, Secret: secret, Scheme: s})
+}
+
+func (r *ProviderRegistry) Lookup(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// KeySource supplies the secrets stored for a provider; *task.SecretService
+// implements it.
+type KeySource interface {
+	Keys(ctx context.Context, provider string) ([]model.WebhookSecret, error)
+	MarkUsed(ctx context.Context, id string) error
+}
+
+// keys returns every secret that may have signed a delivery for p.
+func keys(ctx context.Context, p Provider, source KeySource) ([]webhookauth.Key, error) {
+	var out []webhookauth.Key
+	if p.Secret != "" {
+		out = append(out, webhookauth.Key{ID: envKeyID, Secret: p.Secret})
+	}
+	if source == nil {
+		return out, nil
+	}
+	stored, err := source.Keys(ctx, p.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stored {
+		k := webhookauth.Key{ID: s.ID, Secret: s.Secret, NotBefore: s.NotBefore}
+		if s.NotAfter != nil {
+			k.NotAfter = *s.NotAfter
+		}
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+func schemeNames() []string {
+	names := make([]string, 0, len(Schemes))
+	for name := range Schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extractGeneric: X-Event-Id header, "type" in the body.
+func extractGeneric(r *http.Request, body []byte) (string, string, error) {
+	id := r.Header.Get("X-Event-Id")
+	if strings.TrimSpace(id) == "" {
+		return "", "", errMissingEventID
+	}
+	var in struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil {
+		return "", "", errInvalidPayload
+	}
+	return id, in.Type, nil
+}
+
+// extractStripe: "id" and "type" in the body.
+func extractStripe(_ *http.Request, body []byte) (string, string, error) {
+	var in struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil {
+		return "", "", errInvalidPayload
+	}
+	if strings.TrimSpace(in.ID) == "" {
+		return "", "", errMissingEventID
+	}
+	return in.ID, in.Type, nil
+}
+
+// extractGitHub: X-GitHub-Delivery and X-GitHub-Event headers.
+func extractGitHub(r *http.Request, _ []byte) (string, string, error) {
+	id := r.Header.Get("X-GitHub-Delivery")
+	if strings.TrimSpace(id) == "" {
+		return "", "", errMissingEventID
+	}
+	return id, r.Header.Get("X-GitHub-Event"), nil
+}
+
+// extractSlack: Events API envelope, "event_id" and "event.type".
+func extractSlack(_ *http.Request, body []byte) (string, string, error) {
+	var in struct {
+		EventID string `json:"event_id"`
+		Event   struct {
+			Type string `json:"type"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil {
+		return "", "", errInvalidPayload
+	}
+	if strings.TrimSpace(in.EventID) == "" {
+		return "", "", errMissingEventID
+	}
+	return in.EventID, in.Event.Type, nil
+}
+
+// extractShopify: X-Shopify-Webhook-Id and X-Shopify-Topic headers.
+func extractShopify(r *http.Request, _ []byte) (string, string, error) {
+	id := r.Header.Get("X-Shopify-Webhook-Id")
+	if strings.TrimSpace(id) == "" {
+		return "", "", errMissingEventID
+	}
+	return id, r.Header.Get("X-Shopify-Topic"), nil
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+func hmacHex(secret, msg string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookHandler_RoutesByProvider(t *testing.T) {
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
+	ts := strconvI64(now.Unix())
+	repo := newFakeEventRepo()
+	h := webhookMux(t, func() time.Time { return now }, task.NewService(repo),
+		[3]string{"stripe", "stripe", "stripe-secret"},
+		[3]string{"gh", "github", "github-secret"},
+		[3]string{"slack", "slack", "slack-secret"},
+	)
+
+	stripeBody := `{"id":"evt_1","type":"payment_intent.succeeded","data":{}}`
+	githubBody := `{"action":"opened"}`
+	slackBody := `{"event_id":"Ev1","event":{"type":"app_mention"}}`
+
+	cases := []struct {
+		name     string
+		path     string
+		body     string
+		header   map[string]string
+		want     int
+		id       string
+		provider string
+		typ      string
+	}{
+		{
+			name: "stripe", path: "/webhooks/stripe", body: stripeBody,
+			header: map[string]string{"Stripe-Signature": "t=" + ts + ",v1=" + hmacHex("stripe-secret", ts+"."+stripeBody)},
+			want:   http.StatusAccepted, id: "evt_1", provider: "stripe", typ: "payment_intent.succeeded",
+		},
+		{
+			name: "github", path: "/webhooks/gh", body: githubBody,
+			header: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hmacHex("github-secret", githubBody),
+				"X-GitHub-Delivery":   "d-1",
+				"X-GitHub-Event":      "pull_request",
+			},
+			want: http.StatusAccepted, id: "d-1", provider: "gh", typ: "pull_request",
+		},
+		{
+			name: "slack", path: "/webhooks/slack", body: slackBody,
+			header: map[string]string{
+				"X-Slack-Request-Timestamp": ts,
+				"X-Slack-Signature":         "v0=" + hmacHex("slack-secret", "v0:"+ts+":"+slackBody),
+			},
+			want: http.StatusAccepted, id: "Ev1", provider: "slack", typ: "app_mention",
+		},
+		{
+			name: "secret of another provider", path: "/webhooks/gh", body: githubBody,
+			header: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hmacHex("stripe-secret", githubBody),
+				"X-GitHub-Delivery":   "d-2",
+				"X-GitHub-Event":      "push",
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "missing event type", path: "/webhooks/gh", body: githubBody,
+			header: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hmacHex("github-secret", githubBody),
+				"X-GitHub-Delivery":   "d-3",
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "id used by another provider", path: "/webhooks/gh", body: githubBody,
+			header: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hmacHex("github-secret", githubBody),
+				"X-GitHub-Delivery":   "evt_1",
+				"X-GitHub-Event":      "push",
+			},
+			want: http.StatusAccepted, id: "evt_1", provider: "gh", typ: "push",
+		},
+		{name: "unknown provider", path: "/webhooks/acme", body: `{}`, want: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+			}
+			if tc.id == "" {
+				return
+			}
+			ev, ok := repo.inserted[model.EventKey{Provider: tc.provider, ID: tc.id}]
+			if !ok || ev.Provider != tc.provider || ev.Type != tc.typ {
+				t.Fatalf("expected %s event %q of type %q, got %+v", tc.provider, tc.id, tc.typ, ev)
+			}
+		})
+	}
+}
+
+func TestProviderRegistry_RejectsBadConfig(t *testing.T) {
+	reg := NewProviderRegistry()
+	if err := reg.RegisterScheme("stripe", "stripe", "s"); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range [][3]string{
+		{"stripe", "stripe", "s"}, // duplicate
+		{"Acme", "generic", "s"},  // not a lowercase path segment
+		{"acme", "paypal", "s"},   // unknown scheme
+		{"a/b", "generic", "s"},   // slash
+	} {
+		if err := reg.RegisterScheme(p[0], p[1], p[2]); err == nil {
+			t.Fatalf("expected %v to be rejected", p)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+// ListSecretsHandler serves GET /admin/providers/{provider}/secrets. Secret
+// values are never returned.
+func ListSecretsHandler(providers *ProviderRegistry, secrets *task.SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := adminProvider(w, r, providers)
+		if !ok {
+			return
+		}
+		list, err := secrets.List(r.Context(), provider)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if list == nil {
+			list = []model.WebhookSecret{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"secrets": list})
+	}
+}
+
+type addSecretRequest struct {
+	ID        string     `json:"id"`
+	Secret    string     `json:"secret"`
+	NotBefore *time.Time `json:"not_before"`
+	NotAfter  *time.Time `json:"not_after"`
+}
+
+// AddSecretHandler serves POST /admin/providers/{provider}/secrets.
+func AddSecretHandler(providers *ProviderRegistry, secrets *task.SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := adminProvider(w, r, providers)
+		if !ok {
+			return
+		}
+		var req addSecretRequest
+		if err := decodeAdminJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		in := task.NewSecret{Provider: provider, ID: req.ID, Secret: req.Secret, NotAfter: req.NotAfter}
+		if req.NotBefore != nil {
+			in.NotBefore = *req.NotBefore
+		}
+		created, err := secrets.Add(r.Context(), in)
+		if err != nil {
+			writeSecretError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+type retireSecretRequest struct {
+	At *time.Time `json:"at"`
+}
+
+// RetireSecretHandler serves POST /admin/providers/{provider}/secrets/{id}/retire.
+// The optional "at" lets the old secret overlap with its replacement.
+func RetireSecretHandler(providers *ProviderRegistry, secrets *task.SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := adminProvider(w, r, providers)
+		if !ok {
+			return
+		}
+		var req retireSecretRequest
+		if err := decodeAdminJSON(r, &req); err != nil && !errors.Is(err, errEmptyBody) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var at time.Time
+		if req.At != nil {
+			at = *req.At
+		}
+		retired, err := secrets.Retire(r.Context(), provider, r.PathValue("id"), at)
+		if err != nil {
+			writeSecretError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, retired)
+	}
+}
+
+func adminProvider(w http.ResponseWriter, r *http.Request, providers *ProviderRegistry) (string, bool) {
+	p, ok := providers.Lookup(r.PathValue("provider"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown provider")
+		return "", false
+	}
+	return p.Name, true
+}
+
+var errEmptyBody = errors.New("request body is empty")
+
+func decodeAdminJSON(r *http.Request, v any) error {
+	body, err := readBody(r, maxBodyBytes)
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return errEmptyBody
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.New("invalid JSON")
+	}
+	return nil
+}
+
+func writeSecretError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, task.ErrInvalidSecret),
+		errors.Is(err, task.ErrInvalidSecretID),
+		errors.Is(err, task.ErrReservedSecretID),
+		errors.Is(err, task.ErrInvalidWindow):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrConflict):
+		writeError(w, http.StatusConflict, "secret id already exists")
+	case errors.Is(err, model.ErrNotFound):
+		writeError(w, http.StatusNotFound, "secret not found")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,268 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/httpapi/webhookauth"
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+type fakeSecretRepo struct {
+	mu      sync.Mutex
+	secrets map[string]model.WebhookSecret
+}
+
+func newFakeSecretRepo() *fakeSecretRepo {
+	return &fakeSecretRepo{secrets: map[string]model.WebhookSecret{}}
+}
+
+func (f *fakeSecretRepo) InsertSecret(ctx context.Context, s model.WebhookSecret) (model.WebhookSecret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.secrets[s.ID]; ok {
+		return model.WebhookSecret{}, model.ErrConflict
+	}
+	s.CreatedAt = time.Now().UTC()
+	f.secrets[s.ID] = s
+	return s, nil
+}
+
+func (f *fakeSecretRepo) ListSecrets(ctx context.Context, provider string) ([]model.WebhookSecret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []model.WebhookSecret
+	for _, s := range f.secrets {
+		if s.Provider == provider {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NotBefore.After(out[j].NotBefore) })
+	return out, nil
+}
+
+func (f *fakeSecretRepo) RetireSecret(ctx context.Context, provider, id string, at time.Time) (model.WebhookSecret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.secrets[id]
+	if !ok || s.Provider != provider {
+		return model.WebhookSecret{}, model.ErrNotFound
+	}
+	if s.NotAfter == nil || at.Before(*s.NotAfter) {
+		s.NotAfter = &at
+	}
+	f.secrets[id] = s
+	return s, nil
+}
+
+func (f *fakeSecretRepo) TouchSecret(ctx context.Context, id string, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.secrets[id]
+	s.LastUsedAt = &at
+	f.secrets[id] = s
+	return nil
+}
+
+// stallingSecretRepo holds the next ListSecrets, after it has read the
+// secrets, until release is closed.
+type stallingSecretRepo struct {
+	*fakeSecretRepo
+	listed  chan struct{}
+	release chan struct{}
+}
+
+func (s *stallingSecretRepo) ListSecrets(ctx context.Context, provider string) ([]model.WebhookSecret, error) {
+	out, err := s.fakeSecretRepo.ListSecrets(ctx, provider)
+	if s.listed != nil {
+		close(s.listed)
+		s.listed = nil
+		<-s.release
+	}
+	return out, err
+}
+
+const testAdminToken = "admin-token"
+
+func secretsMux(t *testing.T, secrets *task.SecretService) http.Handler {
+	t.Helper()
+	reg := NewProviderRegistry()
+	if err := reg.RegisterScheme("acme", "generic", ""); err != nil {
+		t.Fatal(err)
+	}
+	admin := RequireAdmin(testAdminToken)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhooks/{provider}", WebhookHandler(reg, secrets, nil, task.NewService(newFakeEventRepo())))
+	mux.Handle("GET /admin/providers/{provider}/secrets", admin(ListSecretsHandler(reg, secrets)))
+	mux.Handle("POST /admin/providers/{provider}/secrets", admin(AddSecretHandler(reg, secrets)))
+	mux.Handle("POST /admin/providers/{provider}/secrets/{id}/retire", admin(RetireSecretHandler(reg, secrets)))
+	return mux
+}
+
+func adminRequest(t *testing.T, h http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func deliver(t *testing.T, h http.Handler, eventID, secret string) int {
+	t.Helper()
+	body := `{"type":"payment_succeeded"}`
+	ts := strconvI64(time.Now().Unix())
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/acme", strings.NewReader(body))
+	req.Header.Set("X-Event-Id", eventID)
+	req.Header.Set("X-Event-Timestamp", ts)
+	req.Header.Set("X-Signature", webhookauth.SignHex(secret, ts, []byte(body)))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSecretRotation_WithoutRejectedDeliveries(t *testing.T) {
+	h := secretsMux(t, task.NewSecretService(newFakeSecretRepo(), time.Minute))
+	const oldSecret, newSecret = "old-secret-0123456789", "new-secret-0123456789"
+
+	if code := deliver(t, h, "evt_0", oldSecret); code != http.StatusUnauthorized {
+		t.Fatalf("before any secret: expected 401, got %d", code)
+	}
+
+	w := adminRequest(t, h, http.MethodPost, "/admin/providers/acme/secrets", testAdminToken, `{"id":"old","secret":"`+oldSecret+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add old: status=%d body=%s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), oldSecret) {
+		t.Fatalf("secret value leaked: %s", w.Body.String())
+	}
+	if code := deliver(t, h, "evt_1", oldSecret); code != http.StatusAccepted {
+		t.Fatalf("old secret: expected 202, got %d", code)
+	}
+
+	// Both secrets work while the provider switches over.
+	w = adminRequest(t, h, http.MethodPost, "/admin/providers/acme/secrets", testAdminToken, `{"id":"new","secret":"`+newSecret+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add new: status=%d body=%s", w.Code, w.Body.String())
+	}
+	for i, secret := range []string{oldSecret, newSecret} {
+		if code := deliver(t, h, "evt_2"+strconvI64(int64(i)), secret); code != http.StatusAccepted {
+			t.Fatalf("overlap with secret %d: expected 202, got %d", i, code)
+		}
+	}
+
+	w = adminRequest(t, h, http.MethodPost, "/admin/providers/acme/secrets/old/retire", testAdminToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("retire: status=%d body=%s", w.Code, w.Body.String())
+	}
+	if code := deliver(t, h, "evt_3", oldSecret); code != http.StatusUnauthorized {
+		t.Fatalf("retired secret: expected 401, got %d", code)
+	}
+	if code := deliver(t, h, "evt_4", newSecret); code != http.StatusAccepted {
+		t.Fatalf("new secret: expected 202, got %d", code)
+	}
+
+	w = adminRequest(t, h, http.MethodGet, "/admin/providers/acme/secrets", testAdminToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status=%d body=%s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Secrets []model.WebhookSecret `json:"secrets"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Secrets) != 2 {
+		t.Fatalf("expected 2 secrets, got %+v", list.Secrets)
+	}
+	for _, s := range list.Secrets {
+		if s.LastUsedAt == nil {
+			t.Fatalf("expected last_used_at on %s", s.ID)
+		}
+		if (s.ID == "old") != (s.NotAfter != nil) {
+			t.Fatalf("unexpected not_after on %s: %v", s.ID, s.NotAfter)
+		}
+	}
+}
+
+func TestSecretAdmin_Errors(t *testing.T) {
+	h := secretsMux(t, task.NewSecretService(newFakeSecretRepo(), time.Minute))
+	valid := `{"id":"k1","secret":"0123456789abcdef"}`
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   string
+		want   int
+	}{
+		{"no token", http.MethodGet, "/admin/providers/acme/secrets", "", "", http.StatusUnauthorized},
+		{"wrong token", http.MethodGet, "/admin/providers/acme/secrets", "nope", "", http.StatusUnauthorized},
+		{"unknown provider", http.MethodGet, "/admin/providers/other/secrets", testAdminToken, "", http.StatusNotFound},
+		{"short secret", http.MethodPost, "/admin/providers/acme/secrets", testAdminToken, `{"secret":"short"}`, http.StatusBadRequest},
+		{"bad window", http.MethodPost, "/admin/providers/acme/secrets", testAdminToken,
+			`{"secret":"0123456789abcdef","not_before":"2026-03-01T00:00:00Z","not_after":"2026-02-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"add", http.MethodPost, "/admin/providers/acme/secrets", testAdminToken, valid, http.StatusCreated},
+		{"reserved id", http.MethodPost, "/admin/providers/acme/secrets", testAdminToken,
+			`{"id":"ENV","secret":"0123456789abcdef"}`, http.StatusBadRequest},
+		{"duplicate id", http.MethodPost, "/admin/providers/acme/secrets", testAdminToken, valid, http.StatusConflict},
+		{"retire unknown", http.MethodPost, "/admin/providers/acme/secrets/k2/retire", testAdminToken, "", http.StatusNotFound},
+		{"retire later", http.MethodPost, "/admin/providers/acme/secrets/k1/retire", testAdminToken, `{"at":"2030-01-01T00:00:00Z"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := adminRequest(t, h, tc.method, tc.path, tc.token, tc.body)
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d body=%s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSecretKeys_StaleLoadIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	repo := &stallingSecretRepo{
+		fakeSecretRepo: newFakeSecretRepo(),
+		listed:         make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+	secrets := task.NewSecretService(repo, time.Hour)
+	listed := repo.listed
+
+	done := make(chan []model.WebhookSecret)
+	go func() {
+		keys, err := secrets.Keys(ctx, "acme")
+		if err != nil {
+			t.Error(err)
+		}
+		done <- keys
+	}()
+	<-listed
+	if _, err := secrets.Add(ctx, task.NewSecret{Provider: "acme", ID: "k1", Secret: "0123456789abcdef"}); err != nil {
+		t.Fatal(err)
+	}
+	close(repo.release)
+	if keys := <-done; len(keys) != 0 {
+		t.Fatalf("expected the stalled load to miss k1, got %+v", keys)
+	}
+
+	keys, err := secrets.Keys(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ID != "k1" {
+		t.Fatalf("expected k1 after Add, got %+v", keys)
+	}
+}
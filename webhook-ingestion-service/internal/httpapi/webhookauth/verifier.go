@@ -0,0 +1,132 @@
+package webhookauth
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Request is what a Verifier gets to look at.
+type Request struct {
+	Header http.Header
+	Body   []byte
+	Keys   []Key
+	Now    time.Time
+}
+
+// Verifier checks one provider's signature scheme against every key valid
+// at req.Now and returns the key that matched.
+type Verifier interface {
+	Verify(req Request) (Key, error)
+}
+
+// HexTimestamp is the original scheme: X-Signature holds the hex HMAC-SHA256
+// of "<X-Event-Timestamp>.<body>".
+type HexTimestamp struct{}
+
+func (HexTimestamp) Verify(req Request) (Key, error) {
+	return Verify(Input{
+		Keys:            req.Keys,
+		TimestampHeader: req.Header.Get("X-Event-Timestamp"),
+		SignatureHeader: req.Header.Get("X-Signature"),
+		Body:            req.Body,
+		Now:             req.Now,
+	})
+}
+
+// Stripe checks "Stripe-Signature: t=<unix>,v1=<hex>[,v1=<hex>...]" over
+// "<t>.<body>". Any v1 entry may match; Stripe sends several while rolling
+// secrets.
+type Stripe struct{}
+
+func (Stripe) Verify(req Request) (Key, error) {
+	var ts string
+	var sigs [][]byte
+	for _, part := range strings.Split(req.Header.Get("Stripe-Signature"), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			if sig, err := hex.DecodeString(v); err == nil {
+				sigs = append(sigs, sig)
+			}
+		}
+	}
+	if err := checkTimestamp(ts, req.Now); err != nil {
+		return Key{}, err
+	}
+
+	return firstMatch(req.Keys, req.Now, func(secret string) bool {
+		expected := sign(secret, []byte(ts), []byte("."), req.Body)
+		for _, sig := range sigs {
+			if hmac.Equal(sig, expected) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// GitHub checks "X-Hub-Signature-256: sha256=<hex>" over the body alone.
+// GitHub signs no timestamp, so replays are only caught by event-ID dedup.
+type GitHub struct{}
+
+func (GitHub) Verify(req Request) (Key, error) {
+	hexSig, ok := strings.CutPrefix(strings.TrimSpace(req.Header.Get("X-Hub-Signature-256")), "sha256=")
+	if !ok {
+		return Key{}, ErrInvalidSignature
+	}
+	sig, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return Key{}, ErrInvalidSignature
+	}
+	return firstMatch(req.Keys, req.Now, func(secret string) bool {
+		return hmac.Equal(sig, sign(secret, req.Body))
+	})
+}
+
+// Slack checks "X-Slack-Signature: v0=<hex>" over the basestring
+// "v0:<X-Slack-Request-Timestamp>:<body>".
+type Slack struct{}
+
+func (Slack) Verify(req Request) (Key, error) {
+	ts := strings.TrimSpace(req.Header.Get("X-Slack-Request-Timestamp"))
+	if err := checkTimestamp(ts, req.Now); err != nil {
+		return Key{}, err
+	}
+
+	hexSig, ok := strings.CutPrefix(strings.TrimSpace(req.Header.Get("X-Slack-Signature")), "v0=")
+	if !ok {
+		return Key{}, ErrInvalidSignature
+	}
+	sig, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return Key{}, ErrInvalidSignature
+	}
+	return firstMatch(req.Keys, req.Now, func(secret string) bool {
+		return hmac.Equal(sig, sign(secret, []byte("v0:"+ts+":"), req.Body))
+	})
+}
+
+// Base64Body checks a base64 HMAC-SHA256 of the body carried in Header, as
+// sent by e.g. Shopify (X-Shopify-Hmac-Sha256).
+type Base64Body struct {
+	Header string
+}
+
+func (v Base64Body) Verify(req Request) (Key, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(req.Header.Get(v.Header)))
+	if err != nil {
+		return Key{}, ErrInvalidSignature
+	}
+	return firstMatch(req.Keys, req.Now, func(secret string) bool {
+		return hmac.Equal(sig, sign(secret, req.Body))
+	})
+}
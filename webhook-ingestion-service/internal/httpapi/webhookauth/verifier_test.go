@@ -0,0 +1,73 @@
+package webhookauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func hmacHex(secret, msg string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func header(kv ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+	return h
+}
+
+func TestVerifiers(t *testing.T) {
+	secret := "whsec_test"
+	body := `{"id":"evt_1","type":"payment.succeeded"}`
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
+	ts := itoa(now.Unix())
+	stale := itoa(now.Add(-(Window + time.Second)).Unix())
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	shopifySig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	cases := []struct {
+		name     string
+		verifier Verifier
+		header   http.Header
+		want     error
+	}{
+		{"hex ok", HexTimestamp{}, header("X-Event-Timestamp", ts, "X-Signature", SignHex(secret, ts, []byte(body))), nil},
+		{"hex wrong secret", HexTimestamp{}, header("X-Event-Timestamp", ts, "X-Signature", SignHex("other", ts, []byte(body))), ErrInvalidSignature},
+
+		{"stripe ok", Stripe{}, header("Stripe-Signature", "t="+ts+",v1="+hmacHex(secret, ts+"."+body)), nil},
+		{"stripe any v1 matches", Stripe{}, header("Stripe-Signature", "t="+ts+",v1="+hmacHex("old", ts+"."+body)+",v1="+hmacHex(secret, ts+"."+body)), nil},
+		{"stripe v0 ignored", Stripe{}, header("Stripe-Signature", "t="+ts+",v0="+hmacHex(secret, ts+"."+body)), ErrInvalidSignature},
+		{"stripe stale", Stripe{}, header("Stripe-Signature", "t="+stale+",v1="+hmacHex(secret, stale+"."+body)), ErrTimestampOutsideWindow},
+		{"stripe no timestamp", Stripe{}, header("Stripe-Signature", "v1="+hmacHex(secret, ts+"."+body)), ErrInvalidTimestamp},
+
+		{"github ok", GitHub{}, header("X-Hub-Signature-256", "sha256="+hmacHex(secret, body)), nil},
+		{"github missing prefix", GitHub{}, header("X-Hub-Signature-256", hmacHex(secret, body)), ErrInvalidSignature},
+		{"github over timestamp", GitHub{}, header("X-Hub-Signature-256", "sha256="+hmacHex(secret, ts+"."+body)), ErrInvalidSignature},
+
+		{"slack ok", Slack{}, header("X-Slack-Request-Timestamp", ts, "X-Slack-Signature", "v0="+hmacHex(secret, "v0:"+ts+":"+body)), nil},
+		{"slack stale", Slack{}, header("X-Slack-Request-Timestamp", stale, "X-Slack-Signature", "v0="+hmacHex(secret, "v0:"+stale+":"+body)), ErrTimestampOutsideWindow},
+		{"slack bad basestring", Slack{}, header("X-Slack-Request-Timestamp", ts, "X-Slack-Signature", "v0="+hmacHex(secret, ts+"."+body)), ErrInvalidSignature},
+
+		{"base64 ok", Base64Body{Header: "X-Shopify-Hmac-Sha256"}, header("X-Shopify-Hmac-Sha256", shopifySig), nil},
+		{"base64 given as hex", Base64Body{Header: "X-Shopify-Hmac-Sha256"}, header("X-Shopify-Hmac-Sha256", hmacHex(secret, body)), ErrInvalidSignature},
+		{"base64 missing", Base64Body{Header: "X-Shopify-Hmac-Sha256"}, header(), ErrInvalidSignature},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.verifier.Verify(Request{Header: tc.header, Body: []byte(body), Keys: []Key{{ID: "k1", Secret: secret}}, Now: now})
+			if err != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
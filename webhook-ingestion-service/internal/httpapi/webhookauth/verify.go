@@ -14,10 +14,30 @@ var (
 	ErrInvalidTimestamp       = errors.New("invalid timestamp")
 	ErrTimestampOutsideWindow = errors.New("timestamp outside allowed window")
 	ErrInvalidSignature       = errors.New("invalid signature")
+	ErrNoValidKey             = errors.New("no valid signing secret")
 )
 
+// Key is one signing secret. Several keys can be valid at once so that a
+// provider can move to a new secret without a window of rejected deliveries.
+type Key struct {
+	ID        string
+	Secret    string
+	NotBefore time.Time // zero: valid from the start
+	NotAfter  time.Time // zero: never expires
+}
+
+func (k Key) ValidAt(t time.Time) bool {
+	if !k.NotBefore.IsZero() && t.Before(k.NotBefore) {
+		return false
+	}
+	if !k.NotAfter.IsZero() && !t.Before(k.NotAfter) {
+		return false
+	}
+	return true
+}
+
 type Input struct {
-	Secret          string
+	Keys            []Key
 	TimestampHeader string
 	SignatureHeader string
 	Body            []byte
@@ -26,54 +46,72 @@ type Input struct {
 
 const Window = 5 * time.Minute
 
-func Verify(in Input) error {
+// Verify checks the original hex scheme against every key valid at in.Now
+// and returns the key that matched.
+func Verify(in Input) (Key, error) {
 	tsHeader := strings.TrimSpace(in.TimestampHeader)
 	sigHeader := strings.TrimSpace(in.SignatureHeader)
 
-	// 1) Parse timestamp
-	tsInt, err := strconv.ParseInt(tsHeader, 10, 64)
-	if err != nil {
-		return ErrInvalidTimestamp
-	}
-	ts := time.Unix(tsInt, 0).UTC()
-
-	// 2) Timestamp window check (replay protection)
-	now := in.Now.UTC()
-	if ts.Before(now.Add(-Window)) || ts.After(now.Add(Window)) {
-		return ErrTimestampOutsideWindow
+	// 1) Parse timestamp + window check (replay protection)
+	if err := checkTimestamp(tsHeader, in.Now); err != nil {
+		return Key{}, err
 	}
 
-	// 3) Decode provided signature (hex)
+	// 2) Decode provided signature (hex)
 	providedSig, err := hex.DecodeString(sigHeader)
 	if err != nil {
-		return ErrInvalidSignature
+		return Key{}, ErrInvalidSignature
 	}
 
-	// 4) Compute expected signature for "<ts>.<body>"
-	msg := make([]byte, 0, len(tsHeader)+1+len(in.Body))
-	msg = append(msg, []byte(tsHeader)...)
-	msg = append(msg, '.')
-	msg = append(msg, in.Body...)
+	// 3) Compute expected signature for "<ts>.<body>" + constant-time compare
+	return firstMatch(in.Keys, in.Now, func(secret string) bool {
+		return hmac.Equal(providedSig, sign(secret, []byte(tsHeader), []byte("."), in.Body))
+	})
+}
+
+// Helper for tests/tools: compute hex signature for "<ts>.<body>"
+func SignHex(secret string, timestampHeader string, body []byte) string {
+	return hex.EncodeToString(sign(secret, []byte(timestampHeader), []byte("."), body))
+}
+
+// firstMatch returns the first key valid at now whose secret matches.
+func firstMatch(keys []Key, now time.Time, matches func(secret string) bool) (Key, error) {
+	anyValid := false
+	for _, k := range keys {
+		if !k.ValidAt(now) {
+			continue
+		}
+		anyValid = true
+		if matches(k.Secret) {
+			return k, nil
+		}
+	}
+	if !anyValid {
+		return Key{}, ErrNoValidKey
+	}
+	return Key{}, ErrInvalidSignature
+}
 
-	mac := hmac.New(sha256.New, []byte(in.Secret))
-	_, _ = mac.Write(msg)
-	expectedSig := mac.Sum(nil)
+// checkTimestamp parses unix seconds and rejects values outside Window.
+func checkTimestamp(tsHeader string, now time.Time) error {
+	tsInt, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	ts := time.Unix(tsInt, 0).UTC()
 
-	// 5) Constant-time compare
-	if !hmac.Equal(providedSig, expectedSig) {
-		return ErrInvalidSignature
+	now = now.UTC()
+	if ts.Before(now.Add(-Window)) || ts.After(now.Add(Window)) {
+		return ErrTimestampOutsideWindow
 	}
 	return nil
 }
 
-// Helper for tests/tools: compute hex signature for "<ts>.<body>"
-func SignHex(secret string, timestampHeader string, body []byte) string {
-	msg := make([]byte, 0, len(timestampHeader)+1+len(body))
-	msg = append(msg, []byte(timestampHeader)...)
-	msg = append(msg, '.')
-	msg = append(msg, body...)
-
+// sign returns HMAC-SHA256 over the concatenated parts.
+func sign(secret string, parts ...[]byte) []byte {
 	mac := hmac.New(sha256.New, []byte(secret))
-	_, _ = mac.Write(msg)
-	return hex.EncodeToString(mac.Sum(nil))
+	for _, p := range parts {
+		_, _ = mac.Write(p)
+	}
+	return mac.Sum(nil)
 }
@@ -16,8 +16,8 @@ func TestVerify_OK(t *testing.T) {
 
 	sig := SignHex(secret, tsHeader, body)
 
-	err := Verify(Input{
-		Secret:          secret,
+	_, err := Verify(Input{
+		Keys:            []Key{{Secret: secret}},
 		TimestampHeader: tsHeader,
 		SignatureHeader: sig,
 		Body:            body,
@@ -29,8 +29,8 @@ func TestVerify_OK(t *testing.T) {
 }
 
 func TestVerify_InvalidTimestamp(t *testing.T) {
-	err := Verify(Input{
-		Secret:          "dev-secret",
+	_, err := Verify(Input{
+		Keys:            []Key{{Secret: "dev-secret"}},
 		TimestampHeader: "not-a-number",
 		SignatureHeader: "00",
 		Body:            []byte(`{}`),
@@ -51,8 +51,8 @@ func TestVerify_OutsideWindow_TooOld(t *testing.T) {
 
 	sig := SignHex(secret, tsHeader, body)
 
-	err := Verify(Input{
-		Secret:          secret,
+	_, err := Verify(Input{
+		Keys:            []Key{{Secret: secret}},
 		TimestampHeader: tsHeader,
 		SignatureHeader: sig,
 		Body:            body,
@@ -73,8 +73,8 @@ func TestVerify_OutsideWindow_TooFuture(t *testing.T) {
 
 	sig := SignHex(secret, tsHeader, body)
 
-	err := Verify(Input{
-		Secret:          secret,
+	_, err := Verify(Input{
+		Keys:            []Key{{Secret: secret}},
 		TimestampHeader: tsHeader,
 		SignatureHeader: sig,
 		Body:            body,
@@ -86,8 +86,8 @@ func TestVerify_OutsideWindow_TooFuture(t *testing.T) {
 }
 
 func TestVerify_InvalidSignature_BadHex(t *testing.T) {
-	err := Verify(Input{
-		Secret:          "dev-secret",
+	_, err := Verify(Input{
+		Keys:            []Key{{Secret: "dev-secret"}},
 		TimestampHeader: itoa(time.Now().Unix()),
 		SignatureHeader: "not-hex!!!",
 		Body:            []byte(`{}`),
@@ -107,8 +107,8 @@ func TestVerify_InvalidSignature_WrongSecret(t *testing.T) {
 
 	sig := SignHex("WRONG-SECRET", tsHeader, body)
 
-	err := Verify(Input{
-		Secret:          secret,
+	_, err := Verify(Input{
+		Keys:            []Key{{Secret: secret}},
 		TimestampHeader: tsHeader,
 		SignatureHeader: sig,
 		Body:            body,
@@ -122,3 +122,65 @@ func TestVerify_InvalidSignature_WrongSecret(t *testing.T) {
 func itoa(v int64) string {
 	return strconv.FormatInt(v, 10)
 }
+
+func TestVerify_RotationReportsMatchedKey(t *testing.T) {
+	body := []byte(`{"type":"payment_succeeded"}`)
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
+	tsHeader := itoa(now.Unix())
+
+	old := Key{ID: "old", Secret: "old-secret", NotAfter: now.Add(time.Hour)}
+	next := Key{ID: "new", Secret: "new-secret", NotBefore: now.Add(-time.Minute)}
+	keys := []Key{old, next}
+
+	for _, k := range keys {
+		got, err := Verify(Input{
+			Keys:            keys,
+			TimestampHeader: tsHeader,
+			SignatureHeader: SignHex(k.Secret, tsHeader, body),
+			Body:            body,
+			Now:             now,
+		})
+		if err != nil {
+			t.Fatalf("%s: expected nil, got %v", k.ID, err)
+		}
+		if got.ID != k.ID {
+			t.Fatalf("expected key %q to match, got %q", k.ID, got.ID)
+		}
+	}
+}
+
+func TestVerify_KeyValidityWindow(t *testing.T) {
+	body := []byte(`{"type":"payment_succeeded"}`)
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
+	tsHeader := itoa(now.Unix())
+
+	retired := Key{ID: "retired", Secret: "retired-secret", NotAfter: now}
+	future := Key{ID: "future", Secret: "future-secret", NotBefore: now.Add(time.Second)}
+	current := Key{ID: "current", Secret: "current-secret"}
+
+	cases := []struct {
+		name string
+		keys []Key
+		sign string
+		want error
+	}{
+		{"retired key rejected", []Key{retired, current}, retired.Secret, ErrInvalidSignature},
+		{"future key rejected", []Key{future, current}, future.Secret, ErrInvalidSignature},
+		{"no key valid", []Key{retired, future}, retired.Secret, ErrNoValidKey},
+		{"no keys at all", nil, current.Secret, ErrNoValidKey},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Verify(Input{
+				Keys:            tc.keys,
+				TimestampHeader: tsHeader,
+				SignatureHeader: SignHex(tc.sign, tsHeader, body),
+				Body:            body,
+				Now:             now,
+			})
+			if err != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -1,42 +1,73 @@
 package httpapi
 
 import (
+	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"webhook-ingestion-service/internal/httpapi/webhookauth"
 	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/logging"
+	"webhook-ingestion-service/internal/observability/metrics"
+	"webhook-ingestion-service/internal/observability/tracing"
 	"webhook-ingestion-service/internal/task"
 )
 
-func WebhookProviderHandler(secret string, now func() time.Time, svc *task.Service) http.HandlerFunc {
+var errInvalidPayload = errors.New("invalid event payload")
+
+// WebhookHandler serves /webhooks/{provider}: it verifies the delivery with
+// the provider's scheme against its environment secret and any stored secrets
+// from source (which may be nil), and stores it for processing.
+func WebhookHandler(providers *ProviderRegistry, source KeySource, now func() time.Time, svc *task.Service) http.HandlerFunc {
 	if now == nil {
 		now = func() time.Time { return time.Now().UTC() }
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		eventID := strings.TrimSpace(r.Header.Get("X-Event-Id"))
-		if eventID == "" {
-			writeError(w, http.StatusBadRequest, "missing X-Event-Id")
+		p, ok := providers.Lookup(r.PathValue("provider"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "unknown provider")
 			return
 		}
 
+		// The event type is only counted once the signature vouches for it.
+		count := func(eventType, outcome string) {
+			metrics.IngestTotal.WithLabelValues(p.Name, eventType, outcome).Inc()
+		}
+
 		body, err := readBody(r, maxBodyBytes)
 		if err != nil {
+			count("", metrics.BadPayload)
 			writeError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err = webhookauth.Verify(webhookauth.Input{
-			Secret:          secret,
-			TimestampHeader: r.Header.Get("X-Event-Timestamp"),
-			SignatureHeader: r.Header.Get("X-Signature"),
-			Body:            body,
-			Now:             now(),
+		// Nothing from the body is used, or logged, before the signature
+		// vouches for it.
+		r = r.WithContext(logging.With(r.Context(), logging.KeyProvider, p.Name))
+		ctx, span := tracing.Tracer.Start(r.Context(), "webhook.verify",
+			trace.WithAttributes(tracing.AttrProvider.String(p.Name)))
+		candidates, err := keys(ctx, p, source)
+		if err != nil {
+			tracing.End(span, err)
+			count("", metrics.Error)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		key, err := p.Scheme.Verifier.Verify(webhookauth.Request{
+			Header: r.Header,
+			Body:   body,
+			Keys:   candidates,
+			Now:    now(),
 		})
+		tracing.End(span, err)
 		if err != nil {
+			count("", metrics.BadSignature)
 			switch {
 			case errors.Is(err, webhookauth.ErrInvalidTimestamp),
 				errors.Is(err, webhookauth.ErrTimestampOutsideWindow):
@@ -46,24 +77,60 @@ func WebhookProviderHandler(secret string, now func() time.Time, svc *task.Servi
 			}
 			return
 		}
+		if source != nil && key.ID != envKeyID {
+			if err := source.MarkUsed(r.Context(), key.ID); err != nil {
+				slog.WarnContext(r.Context(), "mark secret used", "secret_id", key.ID, logging.KeyError, err)
+			}
+		}
 
-		created, err := svc.IngestWebhook(r.Context(), eventID, body)
+		eventID, eventType, err := p.Scheme.Extract(r, body)
 		if err != nil {
-			if errors.Is(err, task.ErrInvalidEvent) {
+			count("", metrics.BadPayload)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		r = r.WithContext(logging.With(r.Context(), logging.KeyEventID, eventID))
+
+		created, err := svc.IngestWebhook(r.Context(), task.Webhook{
+			Provider:  p.Name,
+			ID:        eventID,
+			Type:      eventType,
+			Payload:   body,
+			RequestID: RequestIDFromContext(r.Context()),
+		})
+		if err != nil {
+			var schemaErr *task.SchemaError
+			switch {
+			case errors.As(err, &schemaErr):
+				count(eventType, metrics.BadPayload)
+				writeJSON(w, http.StatusBadRequest, map[string]any{
+					"error":  task.ErrSchemaMismatch.Error(),
+					"fields": schemaErr.Fields,
+				})
+			case errors.Is(err, task.ErrInvalidEvent):
+				count(eventType, metrics.BadPayload)
 				writeError(w, http.StatusBadRequest, "invalid event payload")
-				return
+			default:
+				count(eventType, metrics.Error)
+				writeError(w, http.StatusInternalServerError, "internal error")
 			}
-			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
 
 		// Webhook-friendly: always 202 if accepted (even if duplicate)
 		// If you prefer: if !created { return 409 }
-		_ = created
+		if created {
+			count(eventType, metrics.Accepted)
+		} else {
+			count(eventType, metrics.Duplicate)
+		}
 		w.WriteHeader(http.StatusAccepted)
 	}
 }
 
+// GetEventHandler serves GET /events/{id}. ?provider= picks the event when
+// several providers used the ID. With ?include=payload the stored payload is
+// added, redacted; see RequireAdminForPayload.
 func GetEventHandler(svc *task.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := strings.TrimPrefix(r.URL.Path, "/events/")
@@ -72,17 +139,54 @@ func GetEventHandler(svc *task.Service) http.HandlerFunc {
 			writeError(w, http.StatusNotFound, "not found")
 			return
 		}
+		includePayload, err := wantsPayload(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
-		ev, err := svc.GetEvent(r.Context(), id)
+		ev, err := svc.GetEvent(r.Context(), r.URL.Query().Get("provider"), id)
 		if err != nil {
-			if errors.Is(err, model.ErrNotFound) {
+			switch {
+			case errors.Is(err, model.ErrNotFound):
 				writeError(w, http.StatusNotFound, "event not found")
-				return
+			case errors.Is(err, task.ErrAmbiguousEventID):
+				writeError(w, http.StatusConflict, err.Error())
+			default:
+				writeError(w, http.StatusInternalServerError, "internal error")
 			}
+			return
+		}
+
+		if !includePayload {
+			writeJSON(w, http.StatusOK, ev)
+			return
+		}
+		payload, err := svc.RedactedPayload(ev)
+		if err != nil {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
+		writeJSON(w, http.StatusOK, eventWithPayload{Event: ev, Payload: payload})
+	}
+}
 
-		writeJSON(w, http.StatusOK, ev)
+type eventWithPayload struct {
+	model.Event
+	Payload json.RawMessage `json:"payload"`
+}
+
+// wantsPayload reports whether ?include asks for the payload; it is the
+// only thing that can be included so far.
+func wantsPayload(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("include")
+	if v == "" {
+		return false, nil
+	}
+	for _, part := range strings.Split(v, ",") {
+		if strings.TrimSpace(part) != "payload" {
+			return false, errors.New("include supports only payload")
+		}
 	}
+	return true, nil
 }
@@ -5,35 +5,97 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"webhook-ingestion-service/internal/httpapi/webhookauth"
 	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/metrics"
 	"webhook-ingestion-service/internal/task"
 )
 
 type fakeEventRepo struct {
-	inserted map[string]bool
+	inserted map[model.EventKey]model.Event
+	origins  map[model.EventKey]model.Origin
 }
 
 func newFakeEventRepo() *fakeEventRepo {
-	return &fakeEventRepo{inserted: map[string]bool{}}
+	return &fakeEventRepo{inserted: map[model.EventKey]model.Event{}, origins: map[model.EventKey]model.Origin{}}
+}
+
+// defaultKey is the key of an event delivered to /webhooks/provider.
+func defaultKey(id string) model.EventKey {
+	return model.EventKey{Provider: DefaultProvider, ID: id}
+}
+
+func (f *fakeEventRepo) InsertReceived(ctx context.Context, provider string, id string, eventType string, orderingKey string, payload json.RawMessage, origin model.Origin) (bool, error) {
+	return f.insert(provider, id, eventType, orderingKey, payload, model.StatusReceived, nil, origin)
+}
+
+func (f *fakeEventRepo) InsertQuarantined(ctx context.Context, provider string, id string, eventType string, orderingKey string, payload json.RawMessage, reason string, origin model.Origin) (bool, error) {
+	return f.insert(provider, id, eventType, orderingKey, payload, model.StatusQuarantined, &reason, origin)
 }
 
-func (f *fakeEventRepo) InsertReceived(ctx context.Context, id string, eventType string, payload json.RawMessage) (bool, error) {
-	if f.inserted[id] {
+func (f *fakeEventRepo) insert(provider, id, eventType, orderingKey string, payload json.RawMessage, status model.EventStatus, lastErr *string, origin model.Origin) (bool, error) {
+	key := model.EventKey{Provider: provider, ID: id}
+	if _, ok := f.inserted[key]; ok {
 		return false, nil // duplicate
 	}
-	f.inserted[id] = true
+	ev := model.Event{ID: id, Provider: provider, Type: eventType, Payload: payload, Status: status, LastError: lastErr}
+	if orderingKey != "" {
+		ev.OrderingKey = &orderingKey
+	}
+	if origin.RequestID != "" {
+		ev.RequestID = &origin.RequestID
+	}
+	f.inserted[key] = ev
+	f.origins[key] = origin
 	return true, nil
 }
 
-func (f *fakeEventRepo) GetByID(ctx context.Context, id string) (model.Event, error) {
-	return model.Event{}, model.ErrNotFound
+func (f *fakeEventRepo) GetByID(ctx context.Context, provider string, id string) (model.Event, error) {
+	var found []model.Event
+	for key, ev := range f.inserted {
+		if key.ID == id && (provider == "" || key.Provider == provider) {
+			found = append(found, ev)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return model.Event{}, model.ErrNotFound
+	case 1:
+		return found[0], nil
+	default:
+		return model.Event{}, task.ErrAmbiguousEventID
+	}
+}
+
+// webhookMux routes /webhooks/{provider} to a registry holding the given
+// providers, each configured as "name", "scheme", "secret".
+func webhookMux(t *testing.T, now func() time.Time, svc *task.Service, providers ...[3]string) http.Handler {
+	t.Helper()
+	reg := NewProviderRegistry()
+	for _, p := range providers {
+		if err := reg.RegisterScheme(p[0], p[1], p[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhooks/{provider}", WebhookHandler(reg, nil, now, svc))
+	return mux
 }
+
+// singleProviderHandler is the original single-provider setup.
+func singleProviderHandler(t *testing.T, secret string, now func() time.Time, svc *task.Service) http.Handler {
+	return webhookMux(t, now, svc, [3]string{DefaultProvider, "generic", secret})
+}
+
 func TestWebhookProviderHandler_AcceptsValid(t *testing.T) {
 	secret := "dev-secret"
 	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
@@ -44,7 +106,7 @@ func TestWebhookProviderHandler_AcceptsValid(t *testing.T) {
 	repo := newFakeEventRepo()
 	svc := task.NewService(repo)
 
-	h := WebhookProviderHandler(secret, func() time.Time { return now }, svc)
+	h := singleProviderHandler(t, secret, func() time.Time { return now }, svc)
 
 	req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
 	req.Header.Set("X-Event-Id", "evt_123")
@@ -59,21 +121,98 @@ func TestWebhookProviderHandler_AcceptsValid(t *testing.T) {
 	}
 }
 
-func TestWebhookProviderHandler_MissingEventID(t *testing.T) {
+func TestWebhookProviderHandler_StoresOrderingKey(t *testing.T) {
+	secret := "dev-secret"
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
 	repo := newFakeEventRepo()
 	svc := task.NewService(repo)
-	h := WebhookProviderHandler("dev-secret", func() time.Time { return time.Now().UTC() }, svc)
+	svc.SetOrderingKeys(task.OrderingKeys{"payment_refunded": "data.payment_id"})
+	h := singleProviderHandler(t, secret, func() time.Time { return now }, svc)
 
-	req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(`{}`))
-	req.Header.Set("X-Event-Timestamp", strconvI64(time.Now().Unix()))
-	req.Header.Set("X-Signature", "00")
+	for id, body := range map[string]string{
+		"evt_ordered":   `{"type":"payment_refunded","data":{"payment_id":"pay_123"}}`,
+		"evt_unordered": `{"type":"payment_succeeded","data":{"payment_id":"pay_123"}}`,
+	} {
+		tsHeader := strconvI64(now.Unix())
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
+		req.Header.Set("X-Event-Id", id)
+		req.Header.Set("X-Event-Timestamp", tsHeader)
+		req.Header.Set("X-Signature", webhookauth.SignHex(secret, tsHeader, []byte(body)))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("%s: status=%d body=%s", id, w.Code, w.Body.String())
+		}
+	}
+
+	if key := repo.inserted[defaultKey("evt_ordered")].OrderingKey; key == nil || *key != "pay_123" {
+		t.Fatalf("expected ordering key pay_123, got %v", key)
+	}
+	if key := repo.inserted[defaultKey("evt_unordered")].OrderingKey; key != nil {
+		t.Fatalf("expected no ordering key for an unconfigured type, got %q", *key)
+	}
+}
+
+func TestWebhookProviderHandler_StoresOrigin(t *testing.T) {
+	secret := "dev-secret"
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
+	repo := newFakeEventRepo()
+	svc := task.NewService(repo)
+	h := WithRequestID(Tracing(singleProviderHandler(t, secret, func() time.Time { return now }, svc)))
 
+	body := `{"type":"payment_succeeded","data":{"x":1}}`
+	tsHeader := strconvI64(now.Unix())
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
+	req.Header.Set("X-Event-Id", "evt_traced")
+	req.Header.Set("X-Event-Timestamp", tsHeader)
+	req.Header.Set("X-Signature", webhookauth.SignHex(secret, tsHeader, []byte(body)))
+	req.Header.Set(RequestIDHeader, "req-123")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
 	w := httptest.NewRecorder()
 	h.ServeHTTP(w, req)
-
-	if w.Code != http.StatusBadRequest {
+	if w.Code != http.StatusAccepted {
 		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
 	}
+
+	origin := repo.origins[defaultKey("evt_traced")]
+	if origin.RequestID != "req-123" {
+		t.Fatalf("request id=%q", origin.RequestID)
+	}
+	// The caller's trace continues into the ingest span.
+	if tp := origin.TraceContext["traceparent"]; !strings.HasPrefix(tp, "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Fatalf("traceparent=%q", tp)
+	}
+	if rid := repo.inserted[defaultKey("evt_traced")].RequestID; rid == nil || *rid != "req-123" {
+		t.Fatalf("event request_id=%v", rid)
+	}
+}
+
+func TestWebhookProviderHandler_MissingEventID(t *testing.T) {
+	repo := newFakeEventRepo()
+	svc := task.NewService(repo)
+	h := singleProviderHandler(t, "dev-secret", func() time.Time { return time.Now().UTC() }, svc)
+
+	ts := strconvI64(time.Now().Unix())
+	for _, tc := range []struct {
+		name string
+		sig  string
+		want int
+	}{
+		{"signed", webhookauth.SignHex("dev-secret", ts, []byte(`{}`)), http.StatusBadRequest},
+		// The body is not looked at before the signature checks out.
+		{"unsigned", "00", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(`{}`))
+		req.Header.Set("X-Event-Timestamp", ts)
+		req.Header.Set("X-Signature", tc.sig)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != tc.want {
+			t.Fatalf("%s: status=%d body=%s", tc.name, w.Code, w.Body.String())
+		}
+	}
 }
 
 func TestWebhookProviderHandler_InvalidSignature(t *testing.T) {
@@ -86,7 +225,7 @@ func TestWebhookProviderHandler_InvalidSignature(t *testing.T) {
 	repo := newFakeEventRepo()
 	svc := task.NewService(repo)
 
-	h := WebhookProviderHandler(secret, func() time.Time { return now }, svc)
+	h := singleProviderHandler(t, secret, func() time.Time { return now }, svc)
 
 	req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
 	req.Header.Set("X-Event-Id", "evt_123")
@@ -112,7 +251,7 @@ func TestWebhookProviderHandler_TimestampOutsideWindow(t *testing.T) {
 	repo := newFakeEventRepo()
 	svc := task.NewService(repo)
 
-	h := WebhookProviderHandler(secret, func() time.Time { return now }, svc)
+	h := singleProviderHandler(t, secret, func() time.Time { return now }, svc)
 
 	req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
 	req.Header.Set("X-Event-Id", "evt_123")
@@ -129,3 +268,164 @@ func TestWebhookProviderHandler_TimestampOutsideWindow(t *testing.T) {
 
 // tiny helper (avoid importing strconv everywhere in this snippet)
 func strconvI64(v int64) string { return strconv.FormatInt(v, 10) }
+
+func TestGetEventHandler_IncludePayload(t *testing.T) {
+	repo := newFakeEventRepo()
+	repo.inserted[model.EventKey{Provider: "stripe", ID: "evt_1"}] = model.Event{ID: "evt_1", Provider: "stripe", Type: "customer.updated", Status: model.StatusProcessed,
+		Payload: []byte(`{"data":{"email":"a@example.com","password":"hunter2","cards":[{"cvc":"123","last4":"4242"}]}}`)}
+	svc := task.NewService(repo)
+	svc.SetRedaction(task.Redaction{Fields: task.DefaultRedactFields, Paths: []string{"data.email"}})
+
+	mux := http.NewServeMux()
+	mux.Handle("/events/", RequireAdminForPayload(testAdminToken)(GetEventHandler(svc)))
+
+	w := adminRequest(t, mux, http.MethodGet, "/events/evt_1", "", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "payload") {
+		t.Fatalf("without include: status=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := adminRequest(t, mux, http.MethodGet, "/events/evt_1?include=payload", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("payload without token: expected 401, got %d", w.Code)
+	}
+	if w := adminRequest(t, mux, http.MethodGet, "/events/evt_1?include=headers", testAdminToken, ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown include: expected 400, got %d", w.Code)
+	}
+
+	w = adminRequest(t, mux, http.MethodGet, "/events/evt_1?include=payload", testAdminToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		ID      string          `json:"id"`
+		Status  string          `json:"status"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"cards":[{"cvc":"[REDACTED]","last4":"4242"}],"email":"[REDACTED]","password":"[REDACTED]"}}`
+	if resp.ID != "evt_1" || resp.Status != "processed" || string(resp.Payload) != want {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
+
+func TestGetEventHandler_SharedIDNeedsProvider(t *testing.T) {
+	repo := newFakeEventRepo()
+	for _, provider := range []string{"stripe", "gh"} {
+		repo.inserted[model.EventKey{Provider: provider, ID: "evt_1"}] = model.Event{ID: "evt_1", Provider: provider, Status: model.StatusReceived}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/events/", GetEventHandler(task.NewService(repo)))
+
+	if w := adminRequest(t, mux, http.MethodGet, "/events/evt_1", "", ""); w.Code != http.StatusConflict {
+		t.Fatalf("without provider: expected 409, got %d", w.Code)
+	}
+	w := adminRequest(t, mux, http.MethodGet, "/events/evt_1?provider=gh", "", "")
+	var ev model.Event
+	if err := json.Unmarshal(w.Body.Bytes(), &ev); err != nil || w.Code != http.StatusOK || ev.Provider != "gh" {
+		t.Fatalf("with provider: status=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestWebhookProviderHandler_SchemaValidation(t *testing.T) {
+	dir := t.TempDir()
+	schema := `{"type":"object","required":["invoice_id"],"properties":{"amount":{"type":"integer","minimum":0}}}`
+	for _, name := range []string{"invoice.paid.json", "invoice.updated.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(schema), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schemas, err := task.LoadSchemas(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas.SetPolicy(task.InvalidReject, map[string]task.InvalidPayloadPolicy{"invoice.updated": task.InvalidQuarantine})
+
+	secret := "dev-secret"
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
+	repo := newFakeEventRepo()
+	svc := task.NewService(repo)
+	svc.SetSchemas(schemas)
+	h := singleProviderHandler(t, secret, func() time.Time { return now }, svc)
+	deliver := func(id, body string) *httptest.ResponseRecorder {
+		tsHeader := strconvI64(now.Unix())
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
+		req.Header.Set("X-Event-Id", id)
+		req.Header.Set("X-Event-Timestamp", tsHeader)
+		req.Header.Set("X-Signature", webhookauth.SignHex(secret, tsHeader, []byte(body)))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := deliver("evt_rejected", `{"type":"invoice.paid","data":{"amount":-5}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("reject: status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Error  string            `json:"error"`
+		Fields []task.FieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]bool{}
+	for _, f := range resp.Fields {
+		paths[f.Path] = true
+	}
+	if len(resp.Fields) != 2 || !paths["/data"] || !paths["/data/amount"] {
+		t.Fatalf("expected errors for /data and /data/amount, got %s", w.Body.String())
+	}
+	if _, ok := repo.inserted[defaultKey("evt_rejected")]; ok {
+		t.Fatal("rejected event was stored")
+	}
+
+	if w := deliver("evt_quarantined", `{"type":"invoice.updated","data":{"amount":"12"}}`); w.Code != http.StatusAccepted {
+		t.Fatalf("quarantine: status=%d body=%s", w.Code, w.Body.String())
+	}
+	ev := repo.inserted[defaultKey("evt_quarantined")]
+	if ev.Status != model.StatusQuarantined || ev.LastError == nil || !strings.Contains(*ev.LastError, "/data/amount") {
+		t.Fatalf("expected a quarantined event naming /data/amount, got %+v", ev)
+	}
+
+	for id, body := range map[string]string{
+		"evt_valid":     `{"type":"invoice.paid","data":{"invoice_id":"in_1","amount":5}}`,
+		"evt_no_schema": `{"type":"customer.created"}`,
+	} {
+		if w := deliver(id, body); w.Code != http.StatusAccepted {
+			t.Fatalf("%s: status=%d body=%s", id, w.Code, w.Body.String())
+		}
+		if st := repo.inserted[defaultKey(id)].Status; st != model.StatusReceived {
+			t.Fatalf("%s: expected received, got %s", id, st)
+		}
+	}
+}
+
+func TestWebhookProviderHandler_CountsOutcomes(t *testing.T) {
+	secret := "dev-secret"
+	now := time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC)
+	h := singleProviderHandler(t, secret, func() time.Time { return now }, task.NewService(newFakeEventRepo()))
+	counter := func(eventType, outcome string) float64 {
+		return testutil.ToFloat64(metrics.IngestTotal.WithLabelValues(DefaultProvider, eventType, outcome))
+	}
+	accepted, duplicate, badSig := counter("metrics.test", metrics.Accepted), counter("metrics.test", metrics.Duplicate), counter("", metrics.BadSignature)
+
+	body := `{"type":"metrics.test"}`
+	tsHeader := strconvI64(now.Unix())
+	for _, sig := range []string{webhookauth.SignHex(secret, tsHeader, []byte(body)), webhookauth.SignHex(secret, tsHeader, []byte(body)), "00"} {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/provider", strings.NewReader(body))
+		req.Header.Set("X-Event-Id", "evt_metrics")
+		req.Header.Set("X-Event-Timestamp", tsHeader)
+		req.Header.Set("X-Signature", sig)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := counter("metrics.test", metrics.Accepted) - accepted; got != 1 {
+		t.Fatalf("accepted: expected +1, got %+v", got)
+	}
+	if got := counter("metrics.test", metrics.Duplicate) - duplicate; got != 1 {
+		t.Fatalf("duplicate: expected +1, got %+v", got)
+	}
+	if got := counter("", metrics.BadSignature) - badSig; got != 1 {
+		t.Fatalf("bad signature: expected +1 without a type, got %+v", got)
+	}
+}
@@ -2,4 +2,8 @@ package model
 
 import "errors"
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the record exists in a state that clashes with the request.
+	ErrConflict = errors.New("conflict")
+)
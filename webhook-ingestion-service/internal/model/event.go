@@ -9,11 +9,19 @@ const (
 	StatusProcessing EventStatus = "processing"
 	StatusProcessed  EventStatus = "processed"
 	StatusFailed     EventStatus = "failed"
+	// StatusDead is terminal until an operator requeues or discards the event.
+	StatusDead      EventStatus = "dead"
+	StatusDiscarded EventStatus = "discarded"
+	// StatusQuarantined events failed their schema and wait for an operator
+	// to replay or discard them.
+	StatusQuarantined EventStatus = "quarantined"
 )
 
 type Event struct {
 	ID          string      `json:"id"`
+	Provider    string      `json:"provider"`
 	Type        string      `json:"type"`
+	OrderingKey *string     `json:"ordering_key,omitempty"`
 	Payload     []byte      `json:"-"` // raw JSON bytes; not returned directly
 	Status      EventStatus `json:"status"`
 	Attempts    int         `json:"attempts"`
@@ -22,4 +30,41 @@ type Event struct {
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
 	ProcessedAt *time.Time  `json:"processed_at,omitempty"`
+	DeadAt      *time.Time  `json:"dead_at,omitempty"`
+	// RequestID is the X-Request-Id of the delivery that stored the event.
+	RequestID *string `json:"request_id,omitempty"`
+}
+
+// Key returns the key e is stored under.
+func (e Event) Key() EventKey {
+	return EventKey{Provider: e.Provider, ID: e.ID}
+}
+
+// EventKey identifies a stored event. Providers choose their own event IDs,
+// so an ID is only unique together with its provider.
+type EventKey struct {
+	Provider string
+	ID       string
+}
+
+// Origin ties an event to the delivery that stored it, so its processing
+// can be correlated with the request: the request ID and the W3C trace
+// context (traceparent, tracestate) of the ingest span. Both may be empty.
+type Origin struct {
+	RequestID    string
+	TraceContext map[string]string
+}
+
+// EventAttempt is one processing attempt of an event, from its claim until
+// the event left processing.
+type EventAttempt struct {
+	Attempt    int       `json:"attempt"`
+	WorkerID   *string   `json:"worker_id,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	// Outcome is the status the event moved to, or "released" if it was
+	// handed back without counting the attempt.
+	Outcome string  `json:"outcome"`
+	Error   *string `json:"error,omitempty"`
 }
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// WebhookSecret is a signing secret stored for a provider. It is valid from
+// NotBefore until NotAfter; Secret itself is never serialized.
+type WebhookSecret struct {
+	ID         string     `json:"id"`
+	Provider   string     `json:"provider"`
+	Secret     string     `json:"-"`
+	NotBefore  time.Time  `json:"not_before"`
+	NotAfter   *time.Time `json:"not_after,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
@@ -0,0 +1,120 @@
+// Package logging builds the service's log/slog pipeline: a text or JSON
+// handler, attributes carried in the context (request ID, event ID), the
+// trace and span IDs of the current span, and sampling of high-volume
+// records.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats accepted by New.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Attribute keys used across the service.
+const (
+	KeyRequestID = "request_id"
+	KeyEventID   = "event_id"
+	KeyEventType = "event_type"
+	KeyProvider  = "provider"
+	KeyAttempt   = "attempt"
+	KeyError     = "error"
+)
+
+// Options configure New.
+type Options struct {
+	// Format is text (default) or json.
+	Format string
+	Level  slog.Level
+	// Sampling limits records below Warn; the zero value logs everything.
+	Sampling Sampling
+}
+
+// New returns a logger writing to w. Records carry the attributes added to
+// their context with With, which needs the *Context logging methods.
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	hopts := &slog.HandlerOptions{Level: opts.Level}
+	var h slog.Handler
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", FormatText:
+		h = slog.NewTextHandler(w, hopts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, hopts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want text or json)", opts.Format)
+	}
+	h = contextHandler{h}
+	if opts.Sampling.Initial > 0 {
+		h = newSampler(h, opts.Sampling, time.Now)
+	}
+	return slog.New(h), nil
+}
+
+// ParseLevel parses debug, info, warn or error; "" is info.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if strings.TrimSpace(s) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return l, nil
+}
+
+// Discard returns a logger that drops everything, for tests.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+type ctxKey struct{}
+
+// With returns ctx carrying args, as in slog.Logger.With, for every record
+// logged with it; attributes added later come last.
+func With(ctx context.Context, args ...any) context.Context {
+	prev, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	r := slog.Record{}
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, len(prev)+r.NumAttrs())
+	attrs = append(attrs, prev...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, ctxKey{}, attrs)
+}
+
+// contextHandler adds the attributes of With and the current span to records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if attrs, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+			r.AddAttrs(attrs...)
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
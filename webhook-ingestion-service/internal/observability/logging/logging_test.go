@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew_JSONCarriesContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := With(context.Background(), KeyRequestID, "req-1")
+	ctx = With(ctx, KeyEventID, "evt_1")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}})
+	logger.InfoContext(trace.ContextWithSpanContext(ctx, sc), "event stored", KeyEventType, "ping")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	for k, want := range map[string]string{
+		"msg":        "event stored",
+		KeyRequestID: "req-1",
+		KeyEventID:   "evt_1",
+		KeyEventType: "ping",
+		"trace_id":   sc.TraceID().String(),
+	} {
+		if rec[k] != want {
+			t.Fatalf("%s=%v want %q in %s", k, rec[k], want, buf.String())
+		}
+	}
+}
+
+func TestNew_Formats(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.InfoContext(With(context.Background(), KeyRequestID, "req-2"), "hello")
+	if !strings.Contains(buf.String(), "msg=hello request_id=req-2") {
+		t.Fatalf("text output %q", buf.String())
+	}
+	if _, err := New(&buf, Options{Format: "xml"}); err == nil {
+		t.Fatal("expected unknown format error")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{"": slog.LevelInfo, "debug": slog.LevelDebug, "WARN": slog.LevelWarn, "error": slog.LevelError} {
+		if got, err := ParseLevel(in); err != nil || got != want {
+			t.Fatalf("%q: got %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSampler_KeepsFailures(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	logger := slog.New(newSampler(slog.NewTextHandler(&buf, nil), Sampling{Initial: 2, Thereafter: 3}, func() time.Time { return now }))
+
+	for i := 0; i < 10; i++ {
+		logger.Info("event processed")
+		logger.Warn("event failed")
+	}
+	// 1, 2, then every third after those: 5, 8.
+	if n := strings.Count(buf.String(), "event processed"); n != 4 {
+		t.Fatalf("processed logged %d times", n)
+	}
+	if n := strings.Count(buf.String(), "event failed"); n != 10 {
+		t.Fatalf("failed logged %d times", n)
+	}
+
+	// The next second starts over, for derived loggers too.
+	buf.Reset()
+	now = now.Add(time.Second)
+	logger.With("worker", "w1").Info("event processed")
+	if !strings.Contains(buf.String(), "event processed") {
+		t.Fatal("expected a record in the new second")
+	}
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Sampling thins out records below Warn, such as access logs and processed
+// events, which can arrive by the thousand per second. Every second the
+// first Initial records with a given message are logged, then every
+// Thereafter-th; with Thereafter 0 the rest are dropped. Warnings and errors
+// are always logged.
+type Sampling struct {
+	Initial    int
+	Thereafter int
+}
+
+// sampler counts records per message in the current one-second tick. The
+// counts are shared by the handlers WithAttrs and WithGroup derive.
+type sampler struct {
+	slog.Handler
+	cfg   Sampling
+	state *sampleState
+}
+
+type sampleState struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	tick   time.Time
+	counts map[string]int
+}
+
+func newSampler(h slog.Handler, cfg Sampling, now func() time.Time) *sampler {
+	return &sampler{Handler: h, cfg: cfg, state: &sampleState{now: now, counts: map[string]int{}}}
+}
+
+func (s *sampler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn || s.state.keep(r.Message, s.cfg) {
+		return s.Handler.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (st *sampleState) keep(msg string, cfg Sampling) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if tick := st.now().Truncate(time.Second); !tick.Equal(st.tick) {
+		st.tick = tick
+		clear(st.counts)
+	}
+	st.counts[msg]++
+	n := st.counts[msg]
+	if n <= cfg.Initial {
+		return true
+	}
+	return cfg.Thereafter > 0 && (n-cfg.Initial)%cfg.Thereafter == 0
+}
+
+func (s *sampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampler{Handler: s.Handler.WithAttrs(attrs), cfg: s.cfg, state: s.state}
+}
+
+func (s *sampler) WithGroup(name string) slog.Handler {
+	return &sampler{Handler: s.Handler.WithGroup(name), cfg: s.cfg, state: s.state}
+}
@@ -0,0 +1,154 @@
+// Package metrics holds the service's Prometheus collectors. They live on
+// Registry rather than the global default registry, which Handler serves.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "webhook"
+
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Ingest outcomes.
+const (
+	Accepted     = "accepted"
+	Duplicate    = "duplicate"
+	BadSignature = "bad_signature"
+	BadPayload   = "bad_payload"
+	Error        = "error"
+)
+
+var (
+	// IngestTotal counts deliveries by provider, event type and outcome. The
+	// type is only known, and only trusted, once the signature checks out;
+	// it is empty otherwise, so forged requests cannot create label values.
+	IngestTotal = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_total",
+		Help:      "Webhook deliveries by provider, event type and outcome.",
+	}, []string{"provider", "type", "outcome"})
+
+	QuarantinedTotal = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_quarantined_total",
+		Help:      "Accepted events stored as quarantined because their data failed its schema.",
+	}, []string{"provider", "type"})
+
+	// ProcessingSeconds and Attempts are observed when an attempt finishes.
+	// Outcomes are processed, failed, dead, lease_lost and released.
+	ProcessingSeconds = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_processing_seconds",
+		Help:      "Time from claiming an event to recording its outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"type", "outcome"})
+
+	Attempts = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_attempts",
+		Help:      "Attempt number of finished attempts.",
+		Buckets:   []float64{1, 2, 3, 4, 5, 7, 10, 15, 20},
+	}, []string{"type", "outcome"})
+
+	ClaimSeconds = promauto.With(Registry).NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_claim_seconds",
+		Help:      "Duration of the worker's claim queries.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	ClaimBatchSize = promauto.With(Registry).NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_claim_batch_size",
+		Help:      "Events returned by a claim query; 0 means nothing was due.",
+		Buckets:   []float64{0, 1, 2, 4, 8, 16, 32, 64},
+	})
+
+	MarkFlushSeconds = promauto.With(Registry).NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_mark_flush_seconds",
+		Help:      "Duration of writing a batch of outcomes.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	IdleSeconds = promauto.With(Registry).NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_idle_seconds",
+		Help:      "Time the dispatcher waited for work after finding nothing to claim.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+)
+
+// Since observes the time elapsed since start in seconds.
+func Since(o prometheus.Observer, start time.Time) {
+	o.Observe(time.Since(start).Seconds())
+}
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// QueueSource reports the queue, e.g. from Postgres: events per status and
+// how long the oldest due event has been waiting (0 if none is due).
+type QueueSource interface {
+	QueueStats(ctx context.Context) (byStatus map[string]int64, oldestDue time.Duration, err error)
+}
+
+type queueCollector struct {
+	src     QueueSource
+	timeout time.Duration
+	depth   *prometheus.Desc
+	oldest  *prometheus.Desc
+	up      *prometheus.Desc
+}
+
+// NewQueueCollector queries src on every scrape, within timeout.
+func NewQueueCollector(src QueueSource, timeout time.Duration) prometheus.Collector {
+	return &queueCollector{
+		src:     src,
+		timeout: timeout,
+		depth: prometheus.NewDesc(prometheus.BuildFQName(namespace, "queue", "events"),
+			"Events per status.", []string{"status"}, nil),
+		oldest: prometheus.NewDesc(prometheus.BuildFQName(namespace, "queue", "oldest_due_age_seconds"),
+			"How long the oldest due event has been waiting to be claimed.", nil, nil),
+		up: prometheus.NewDesc(prometheus.BuildFQName(namespace, "queue", "stats_up"),
+			"Whether the last queue query succeeded.", nil, nil),
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.oldest
+	ch <- c.up
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	byStatus, oldest, err := c.src.QueueStats(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+	for status, n := range byStatus {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(n), status)
+	}
+	ch <- prometheus.MustNewConstMetric(c.oldest, prometheus.GaugeValue, oldest.Seconds())
+}
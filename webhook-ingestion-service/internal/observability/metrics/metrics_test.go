@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeQueue struct {
+	byStatus map[string]int64
+	oldest   time.Duration
+	err      error
+}
+
+func (f fakeQueue) QueueStats(ctx context.Context) (map[string]int64, time.Duration, error) {
+	return f.byStatus, f.oldest, f.err
+}
+
+func TestQueueCollector(t *testing.T) {
+	src := fakeQueue{byStatus: map[string]int64{"received": 12, "failed": 3}, oldest: 90 * time.Second}
+	want := `
+# HELP webhook_queue_events Events per status.
+# TYPE webhook_queue_events gauge
+webhook_queue_events{status="failed"} 3
+webhook_queue_events{status="received"} 12
+# HELP webhook_queue_oldest_due_age_seconds How long the oldest due event has been waiting to be claimed.
+# TYPE webhook_queue_oldest_due_age_seconds gauge
+webhook_queue_oldest_due_age_seconds 90
+# HELP webhook_queue_stats_up Whether the last queue query succeeded.
+# TYPE webhook_queue_stats_up gauge
+webhook_queue_stats_up 1
+`
+	if err := testutil.CollectAndCompare(NewQueueCollector(src, time.Second), strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	down := `
+# HELP webhook_queue_stats_up Whether the last queue query succeeded.
+# TYPE webhook_queue_stats_up gauge
+webhook_queue_stats_up 0
+`
+	c := NewQueueCollector(fakeQueue{err: errors.New("db down")}, time.Second)
+	if err := testutil.CollectAndCompare(c, strings.NewReader(down)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistry_Lints(t *testing.T) {
+	IngestTotal.WithLabelValues("stripe", "invoice.paid", Accepted).Inc()
+	ProcessingSeconds.WithLabelValues("invoice.paid", "processed").Observe(0.1)
+	problems, err := testutil.GatherAndLint(Registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		if strings.HasPrefix(p.Metric, namespace+"_") {
+			t.Errorf("%s: %s", p.Metric, p.Text)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started with
+// Tracer; Setup decides where they go. Events carry the trace context of the
+// request that delivered them, see Inject and LinkFrom, so the span of their
+// processing, which usually runs much later and on another instance, links
+// back to the ingest span.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "webhook-ingestion-service"
+
+// Tracer starts the service's spans. It follows the global provider, so
+// spans are dropped until Setup installs an exporter.
+var Tracer = otel.Tracer(serviceName)
+
+// Exporters accepted by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Attribute keys shared by the HTTP and worker spans.
+const (
+	AttrEventID   = attribute.Key("webhook.event.id")
+	AttrEventType = attribute.Key("webhook.event.type")
+	AttrProvider  = attribute.Key("webhook.provider")
+	AttrRequestID = attribute.Key("webhook.request_id")
+	AttrAttempt   = attribute.Key("webhook.event.attempt")
+)
+
+// Propagator reads and writes W3C trace context and baggage. Setup also
+// installs it globally.
+var Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Setup installs the global tracer provider and propagator for exporter:
+// otlp sends spans over OTLP/HTTP to the collector configured by the
+// standard OTEL_EXPORTER_OTLP_* variables (localhost:4318 by default),
+// stdout prints them, and none, or "", only propagates trace context.
+// The returned function flushes pending spans.
+func Setup(ctx context.Context, exporter string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(Propagator)
+
+	var exp sdktrace.SpanExporter
+	switch strings.ToLower(strings.TrimSpace(exporter)) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exp, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q (want otlp, stdout or none)", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Inject returns the trace context of ctx's span in its W3C form
+// (traceparent and, if set, tracestate and baggage), or nil if ctx has no
+// valid span.
+func Inject(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	Propagator.Inject(ctx, carrier)
+	return carrier
+}
+
+// LinkFrom links to the span whose context Inject returned. ok is false if
+// carrier holds no valid span context, e.g. for events stored before tracing
+// was set up.
+func LinkFrom(carrier map[string]string) (link trace.Link, ok bool) {
+	sc := trace.SpanContextFromContext(Propagator.Extract(context.Background(), propagation.MapCarrier(carrier)))
+	if !sc.IsValid() {
+		return trace.Link{}, false
+	}
+	return trace.Link{SpanContext: sc}, true
+}
+
+// End ends span, marking it as failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectLinkFrom_RoundTrip(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	carrier := Inject(trace.ContextWithSpanContext(context.Background(), sc))
+	if !strings.HasPrefix(carrier["traceparent"], "00-01020300") {
+		t.Fatalf("carrier=%v", carrier)
+	}
+
+	link, ok := LinkFrom(carrier)
+	if !ok {
+		t.Fatal("expected a link")
+	}
+	if link.SpanContext.TraceID() != sc.TraceID() || link.SpanContext.SpanID() != sc.SpanID() {
+		t.Fatalf("link=%v want %v", link.SpanContext, sc)
+	}
+}
+
+func TestInject_NoSpan(t *testing.T) {
+	if carrier := Inject(context.Background()); carrier != nil {
+		t.Fatalf("carrier=%v", carrier)
+	}
+	if _, ok := LinkFrom(nil); ok {
+		t.Fatal("nil carrier should not link")
+	}
+	if _, ok := LinkFrom(map[string]string{"traceparent": "garbage"}); ok {
+		t.Fatal("invalid traceparent should not link")
+	}
+}
+
+func TestSetup_Exporters(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Setup(context.Background(), "jaeger"); err == nil {
+		t.Fatal("expected unknown exporter error")
+	}
+}
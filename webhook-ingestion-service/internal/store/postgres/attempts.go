@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+// ListAttempts returns the recorded attempts of an event, oldest first.
+// Attempts are recorded by a trigger whenever an event leaves processing.
+func (r *EventRepo) ListAttempts(ctx context.Context, key model.EventKey) ([]model.EventAttempt, error) {
+	const q = `
+SELECT attempt, worker_id, started_at, finished_at,
+       (extract(epoch FROM finished_at - started_at) * 1000)::bigint,
+       outcome, error
+FROM event_attempts
+WHERE provider = $1 AND event_id = $2
+ORDER BY id;
+`
+	rows, err := r.db.QueryContext(ctx, q, key.Provider, key.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.EventAttempt
+	for rows.Next() {
+		var a model.EventAttempt
+		if err := rows.Scan(&a.Attempt, &a.WorkerID, &a.StartedAt, &a.FinishedAt, &a.DurationMS, &a.Outcome, &a.Error); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAttempts_RecordedOnEveryExitFromProcessing(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	id := insertFirstInLine(t, db, repo, "evt_attempts_")
+
+	mustClaim(t, repo, "w-1", time.Minute, id)
+	if err := repo.MarkFailed(ctx, testKey(id), "w-1", "boom", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	mustClaim(t, repo, "w-2", time.Minute, id)
+	if err := repo.ReleaseLease(ctx, testKey(id), "w-2"); err != nil {
+		t.Fatal(err)
+	}
+	mustClaim(t, repo, "w-3", time.Minute, id)
+	if err := repo.MarkProcessed(ctx, testKey(id), "w-3"); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts, err := repo.ListAttempts(ctx, testKey(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		attempt int
+		worker  string
+		outcome string
+		err     string
+	}{
+		{1, "w-1", "failed", "boom"},
+		{2, "w-2", "released", ""},
+		{2, "w-3", "processed", ""},
+	}
+	if len(attempts) != len(want) {
+		t.Fatalf("expected %d attempts, got %+v", len(want), attempts)
+	}
+	for i, w := range want {
+		a := attempts[i]
+		gotErr := ""
+		if a.Error != nil {
+			gotErr = *a.Error
+		}
+		if a.Attempt != w.attempt || a.WorkerID == nil || *a.WorkerID != w.worker || a.Outcome != w.outcome || gotErr != w.err {
+			t.Fatalf("attempt %d: expected %+v, got %+v", i, w, a)
+		}
+		if a.FinishedAt.Before(a.StartedAt) || a.DurationMS < 0 {
+			t.Fatalf("attempt %d: bad timing %+v", i, a)
+		}
+	}
+
+	ev, err := repo.GetByID(ctx, "provider", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ev.Payload) != `{}` {
+		t.Fatalf("expected the stored payload, got %q", ev.Payload)
+	}
+}
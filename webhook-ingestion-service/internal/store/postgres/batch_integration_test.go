@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+func TestClaimBatch_ClaimsAndMarksInBulk(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		id := insertFirstInLine(t, db, repo, fmt.Sprintf("evt_batch_%d_", i))
+		// Distinct ages, so the claim order is defined.
+		if _, err := db.ExecContext(ctx, `UPDATE events SET created_at = created_at + make_interval(secs => $2) WHERE id = $1`, id, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	skipped := insertFirstInLine(t, db, repo, "evt_batch_skipped_")
+	if _, err := db.ExecContext(ctx, `UPDATE events SET type = 'batch_skipped' WHERE id = $1`, skipped); err != nil {
+		t.Fatal(err)
+	}
+
+	c := task.Claim{WorkerID: "w-batch", Lease: time.Minute, SkipTypes: []string{"batch_skipped"}}
+	claimed, err := repo.ClaimBatch(ctx, c, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range claimed {
+		if e.Attempts != 1 {
+			t.Fatalf("%s: expected attempts=1, got %d", e.ID, e.Attempts)
+		}
+		got = append(got, e.ID)
+	}
+	// Oldest first.
+	if !slices.Equal(got, ids) {
+		t.Fatalf("expected %v, got %v", ids, got)
+	}
+	mustStatus(t, repo, skipped, model.StatusReceived)
+
+	// Rows held by one batch are invisible to the next.
+	again, err := repo.ClaimBatch(ctx, task.Claim{WorkerID: "w-other", Lease: time.Minute, SkipTypes: c.SkipTypes}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range again {
+		if slices.Contains(ids, e.ID) {
+			t.Fatalf("%s claimed twice", e.ID)
+		}
+	}
+
+	lost, err := repo.MarkProcessedBatch(ctx, "w-batch", []model.EventKey{testKey(ids[0]), testKey(ids[1]), testKey("evt_batch_missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(lost, []model.EventKey{testKey("evt_batch_missing")}) {
+		t.Fatalf("expected only the unknown id lost, got %v", lost)
+	}
+	next := time.Now().Add(time.Hour)
+	lost, err = repo.MarkFailedBatch(ctx, "w-batch", []task.FailedEvent{
+		{Key: testKey(ids[2]), LastError: "boom 2", NextRetryAt: next},
+		{Key: testKey(ids[3]), LastError: "boom 3", NextRetryAt: next},
+		{Key: testKey(ids[0]), LastError: "already processed", NextRetryAt: next},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(lost, []model.EventKey{testKey(ids[0])}) {
+		t.Fatalf("expected %s lost, got %v", ids[0], lost)
+	}
+
+	mustStatus(t, repo, ids[1], model.StatusProcessed)
+	ev, err := repo.GetByID(ctx, "provider", ids[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Status != model.StatusFailed || ev.LastError == nil || *ev.LastError != "boom 3" || ev.NextRetryAt.Before(next.Add(-time.Second)) {
+		t.Fatalf("unexpected failed event %+v", ev)
+	}
+}
+
+func TestClaimBatch_CapsTypesWithoutTouchingTheRest(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id := insertFirstInLine(t, db, repo, fmt.Sprintf("evt_capped_%d_", i))
+		// Older than anything else due, so the batch looks at these first.
+		if _, err := db.ExecContext(ctx, `UPDATE events SET type = 'batch_capped', created_at = now() - interval '10 years' + make_interval(secs => $2) WHERE id = $1`, id, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	c := task.Claim{WorkerID: "w-capped", Lease: time.Minute, TypeCaps: map[string]int{"batch_capped": 1}}
+	claimed, err := repo.ClaimBatch(ctx, c, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range claimed {
+		if e.Type == "batch_capped" {
+			got = append(got, e.ID)
+		}
+	}
+	if !slices.Equal(got, ids[:1]) {
+		t.Fatalf("expected only %s claimed, got %v", ids[0], got)
+	}
+	for _, id := range ids[1:] {
+		mustStatus(t, repo, id, model.StatusReceived)
+		attempts, err := repo.ListAttempts(ctx, testKey(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != 0 {
+			t.Fatalf("%s: expected no attempts, got %+v", id, attempts)
+		}
+	}
+}
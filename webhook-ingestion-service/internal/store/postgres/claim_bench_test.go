@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+// Compare with: DB_URL=... go test ./internal/store/postgres -run '^$' -bench Claim
+
+func benchRepo(b *testing.B) (*sql.DB, *EventRepo) {
+	b.Helper()
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		b.Skip("DB_URL not set (integration benchmark)")
+	}
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db, NewEventRepo(db)
+}
+
+// seed queues n due events ahead of everything else in the table.
+func seed(b *testing.B, db *sql.DB, repo *EventRepo, n int) {
+	b.Helper()
+	ctx := context.Background()
+	prefix := "evt_bench_" + time.Now().UTC().Format("20060102_150405.000000") + "_"
+	for i := 0; i < n; i++ {
+		if _, err := repo.InsertReceived(ctx, "provider", fmt.Sprintf("%s%d", prefix, i), "bench", "", json.RawMessage(`{}`), model.Origin{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE events SET created_at = '1970-01-01' WHERE id LIKE $1`, prefix+"%"); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkClaim_PerEvent(b *testing.B) {
+	db, repo := benchRepo(b)
+	seed(b, db, repo, b.N)
+	ctx := context.Background()
+	c := task.Claim{WorkerID: "bench", Lease: time.Minute}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e, ok, err := repo.ClaimNextDue(ctx, c)
+		if err != nil || !ok {
+			b.Fatalf("claim: ok=%v err=%v", ok, err)
+		}
+		if err := repo.MarkProcessed(ctx, e.Key(), c.WorkerID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkClaimBatch(b *testing.B, size int) {
+	db, repo := benchRepo(b)
+	seed(b, db, repo, b.N)
+	ctx := context.Background()
+	c := task.Claim{WorkerID: "bench", Lease: time.Minute}
+
+	b.ResetTimer()
+	for done := 0; done < b.N; {
+		events, err := repo.ClaimBatch(ctx, c, min(size, b.N-done))
+		if err != nil || len(events) == 0 {
+			b.Fatalf("claim: n=%d err=%v", len(events), err)
+		}
+		keys := make([]model.EventKey, len(events))
+		for i, e := range events {
+			keys[i] = e.Key()
+		}
+		if _, err := repo.MarkProcessedBatch(ctx, c.WorkerID, keys); err != nil {
+			b.Fatal(err)
+		}
+		done += len(events)
+	}
+}
+
+func BenchmarkClaim_Batch10(b *testing.B)  { benchmarkClaimBatch(b, 10) }
+func BenchmarkClaim_Batch100(b *testing.B) { benchmarkClaimBatch(b, 100) }
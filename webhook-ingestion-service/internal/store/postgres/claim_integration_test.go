@@ -10,6 +10,9 @@ import (
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
 )
 
 func TestClaimNextDue_OnlyOneWorkerClaims(t *testing.T) {
@@ -31,7 +34,7 @@ func TestClaimNextDue_OnlyOneWorkerClaims(t *testing.T) {
 
 	payload := json.RawMessage(`{"type":"payment_succeeded","data":{"x":1}}`)
 
-	created, err := repo.InsertReceived(ctx, id, "payment_succeeded", payload)
+	created, err := repo.InsertReceived(ctx, "provider", id, "payment_succeeded", "", payload, model.Origin{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -51,7 +54,7 @@ func TestClaimNextDue_OnlyOneWorkerClaims(t *testing.T) {
 	for i := 0; i < N; i++ {
 		go func() {
 			defer wg.Done()
-			e, ok, err := repo.ClaimNextDue(ctx)
+			e, ok, err := repo.ClaimNextDue(ctx, task.Claim{WorkerID: "worker", Lease: time.Minute})
 			if err != nil {
 				mu.Lock()
 				errors = append(errors, err)
@@ -80,7 +83,7 @@ func TestClaimNextDue_OnlyOneWorkerClaims(t *testing.T) {
 	}
 
 	// sanity: event status should now be processing, attempts=1
-	st, attempts, err := repo.GetStatus(ctx, id)
+	st, attempts, err := repo.GetStatus(ctx, testKey(id))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+func (r *EventRepo) ListDead(ctx context.Context, f task.DeadLetterFilter, limit int) ([]model.Event, error) {
+	where, args := deadWhere(f)
+	args = append(args, limit)
+	q := fmt.Sprintf(`SELECT %s FROM events WHERE %s ORDER BY dead_at DESC, id LIMIT $%d;`, eventColumns, where, len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// RequeueDead resets attempts so the max-attempts policy applies afresh;
+// last_error is kept until the next attempt overwrites it.
+func (r *EventRepo) RequeueDead(ctx context.Context, f task.DeadLetterFilter) (int64, error) {
+	where, args := deadWhere(f)
+	q := `
+UPDATE events
+SET status = 'received',
+    attempts = 0,
+    next_retry_at = now(),
+    dead_at = NULL
+WHERE ` + where + `;`
+	return r.exec(ctx, q, args...)
+}
+
+func (r *EventRepo) DiscardDead(ctx context.Context, f task.DeadLetterFilter) (int64, error) {
+	where, args := deadWhere(f)
+	q := `UPDATE events SET status = 'discarded' WHERE ` + where + `;`
+	return r.exec(ctx, q, args...)
+}
+
+func (r *EventRepo) exec(ctx context.Context, q string, args ...any) (int64, error) {
+	res, err := r.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func deadWhere(f task.DeadLetterFilter) (string, []any) {
+	where := `status = 'dead'`
+	var args []any
+	if len(f.IDs) > 0 {
+		args = append(args, f.IDs)
+		where += fmt.Sprintf(` AND id = ANY($%d)`, len(args))
+	}
+	if f.Type != "" {
+		args = append(args, f.Type)
+		where += fmt.Sprintf(` AND type = $%d`, len(args))
+	}
+	if f.Provider != "" {
+		args = append(args, f.Provider)
+		where += fmt.Sprintf(` AND provider = $%d`, len(args))
+	}
+	return where, args
+}
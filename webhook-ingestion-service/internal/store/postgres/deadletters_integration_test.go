@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+func TestEventRepo_DeadLetterLifecycle(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set (integration test)")
+	}
+
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repo := NewEventRepo(db)
+	ctx := context.Background()
+	suffix := time.Now().UTC().Format("20060102_150405.000000")
+	provider := "it_" + suffix
+	first, second := "evt_dead_1_"+suffix, "evt_dead_2_"+suffix
+
+	for _, id := range []string{first, second} {
+		if _, err := repo.InsertReceived(ctx, provider, id, "payment_succeeded", "", json.RawMessage(`{}`), model.Origin{}); err != nil {
+			t.Fatal(err)
+		}
+		// MarkDead needs the worker's lease; go straight to the end state.
+		if _, err := db.ExecContext(ctx, `UPDATE events SET status = 'dead', last_error = 'boom', dead_at = now() WHERE id = $1`, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dead, err := repo.ListDead(ctx, task.DeadLetterFilter{Provider: provider}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 2 || dead[0].DeadAt == nil || dead[0].LastError == nil || *dead[0].LastError != "boom" {
+		t.Fatalf("unexpected dead letters %+v", dead)
+	}
+
+	n, err := repo.RequeueDead(ctx, task.DeadLetterFilter{IDs: []string{first}})
+	if err != nil || n != 1 {
+		t.Fatalf("requeue: n=%d err=%v", n, err)
+	}
+	n, err = repo.DiscardDead(ctx, task.DeadLetterFilter{Provider: provider})
+	if err != nil || n != 1 {
+		t.Fatalf("discard: n=%d err=%v", n, err)
+	}
+
+	want := map[string]model.EventStatus{first: model.StatusReceived, second: model.StatusDiscarded}
+	for id, status := range want {
+		got, attempts, err := repo.GetStatus(ctx, model.EventKey{Provider: provider, ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != status || attempts != 0 {
+			t.Fatalf("%s: expected %s with 0 attempts, got %s/%d", id, status, got, attempts)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+func (r *EventRepo) ListEvents(ctx context.Context, f task.EventFilter, after *task.EventCursor, limit int) ([]model.Event, error) {
+	where, args := eventWhere(f)
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		where += fmt.Sprintf(` AND (created_at, id) < ($%d, $%d)`, len(args)-1, len(args))
+	}
+	args = append(args, limit)
+	q := fmt.Sprintf(`SELECT %s FROM events WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d;`, eventColumns, where, len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// ReplayEvents skips events in processing: their worker still holds the
+// lease and would process them a second time. As with RequeueDead,
+// last_error is kept until the next attempt overwrites it.
+func (r *EventRepo) ReplayEvents(ctx context.Context, f task.EventFilter) (int64, error) {
+	where, args := eventWhere(f)
+	q := `
+UPDATE events
+SET status = 'received',
+    attempts = 0,
+    next_retry_at = now(),
+    processed_at = NULL,
+    dead_at = NULL
+WHERE status <> 'processing' AND ` + where + `;`
+	return r.exec(ctx, q, args...)
+}
+
+func eventWhere(f task.EventFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if len(f.IDs) > 0 {
+		add(`id = ANY($%d)`, f.IDs)
+	}
+	if len(f.Statuses) > 0 {
+		statuses := make([]string, len(f.Statuses))
+		for i, st := range f.Statuses {
+			statuses[i] = string(st)
+		}
+		add(`status = ANY($%d)`, statuses)
+	}
+	if f.Type != "" {
+		add(`type = $%d`, f.Type)
+	}
+	if f.Provider != "" {
+		add(`provider = $%d`, f.Provider)
+	}
+	if !f.CreatedAfter.IsZero() {
+		add(`created_at >= $%d`, f.CreatedAfter)
+	}
+	if !f.CreatedBefore.IsZero() {
+		add(`created_at < $%d`, f.CreatedBefore)
+	}
+	if f.MinAttempts > 0 {
+		add(`attempts >= $%d`, f.MinAttempts)
+	}
+	if f.ErrorContains != "" {
+		add(`strpos(lower(last_error), lower($%d)) > 0`, f.ErrorContains)
+	}
+	if len(conds) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
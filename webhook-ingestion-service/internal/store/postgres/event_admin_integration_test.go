@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+func TestEventAdmin_ListAndReplay(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	run := time.Now().UTC().Format("20060102_150405.000000")
+	provider := "admin_" + run
+	t.Cleanup(func() { _, _ = db.ExecContext(ctx, `DELETE FROM events WHERE provider = $1`, provider) })
+
+	// Newest first: ids[0] is the oldest.
+	var ids []string
+	for i, st := range []model.EventStatus{model.StatusProcessed, model.StatusFailed, model.StatusDead, model.StatusProcessing} {
+		id := insertFirstInLine(t, db, repo, "evt_admin_"+string(st)+"_")
+		if _, err := db.ExecContext(ctx, `
+UPDATE events
+SET provider = $2, status = $3, attempts = $4, last_error = $5,
+    created_at = '2001-01-01'::timestamptz + make_interval(secs => $6)
+WHERE id = $1`, id, provider, st, i+1, "Upstream said: "+string(st), float64(i)); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	list := func(f task.EventFilter, after *task.EventCursor, limit int) []string {
+		t.Helper()
+		f.Provider = provider
+		events, err := repo.ListEvents(ctx, f, after, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range events {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+
+	if got := list(task.EventFilter{}, nil, 10); !slices.Equal(got, []string{ids[3], ids[2], ids[1], ids[0]}) {
+		t.Fatalf("expected newest first, got %v", got)
+	}
+	first, err := repo.ListEvents(ctx, task.EventFilter{Provider: provider}, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := &task.EventCursor{CreatedAt: first[1].CreatedAt, ID: first[1].ID}
+	if got := list(task.EventFilter{}, after, 10); !slices.Equal(got, []string{ids[1], ids[0]}) {
+		t.Fatalf("expected the page after %s, got %v", first[1].ID, got)
+	}
+	f := task.EventFilter{
+		Statuses:      []model.EventStatus{model.StatusFailed, model.StatusDead},
+		MinAttempts:   3,
+		ErrorContains: "UPSTREAM",
+		CreatedAfter:  time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedBefore: time.Date(2001, 1, 1, 0, 0, 3, 0, time.UTC),
+	}
+	if got := list(f, nil, 10); !slices.Equal(got, []string{ids[2]}) {
+		t.Fatalf("expected only %s, got %v", ids[2], got)
+	}
+
+	n, err := repo.ReplayEvents(ctx, task.EventFilter{Provider: provider})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 replayed, got %d", n)
+	}
+	for _, id := range ids[:3] {
+		st, attempts, err := repo.GetStatus(ctx, model.EventKey{Provider: provider, ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st != model.StatusReceived || attempts != 0 {
+			t.Fatalf("%s: expected received with 0 attempts, got %s/%d", id, st, attempts)
+		}
+	}
+	if st, _, err := repo.GetStatus(ctx, model.EventKey{Provider: provider, ID: ids[3]}); err != nil || st != model.StatusProcessing {
+		t.Fatalf("%s: expected processing to be left alone, got %s (%v)", ids[3], st, err)
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"time"
 
 	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
 )
 
 type EventRepo struct {
@@ -18,34 +18,97 @@ func NewEventRepo(db *sql.DB) *EventRepo {
 	return &EventRepo{db: db}
 }
 
-// Returns (created=true) if inserted, (created=false) if duplicate
-func (r *EventRepo) InsertReceived(ctx context.Context, id string, eventType string, payload json.RawMessage) (bool, error) {
+// Returns (created=true) if inserted, (created=false) if duplicate. origin
+// is stored with the event for tracing.
+// Event IDs are only unique per provider: the same ID from another provider
+// is another event.
+func (r *EventRepo) InsertReceived(ctx context.Context, provider string, id string, eventType string, orderingKey string, payload json.RawMessage, origin model.Origin) (bool, error) {
 	const q = `
-INSERT INTO events (id, type, payload, status, attempts, next_retry_at)
-VALUES ($1, $2, $3, 'received', 0, now())
-ON CONFLICT (id) DO NOTHING;
+INSERT INTO events (id, provider, type, ordering_key, payload, status, attempts, next_retry_at, request_id, trace_context)
+VALUES ($1, $2, $3, NULLIF($4, ''), $5, 'received', 0, now(), NULLIF($6, ''), $7)
+ON CONFLICT (provider, id) DO NOTHING;
 `
-	res, err := r.db.ExecContext(ctx, q, id, eventType, payload)
+	traceContext, err := traceContextParam(origin)
 	if err != nil {
 		return false, err
 	}
-	ra, err := res.RowsAffected()
+	return r.insert(ctx, q, id, provider, eventType, orderingKey, payload, origin.RequestID, traceContext)
+}
+
+// InsertQuarantined stores an event that failed its schema; reason becomes
+// its last_error. Duplicates are handled as in InsertReceived.
+func (r *EventRepo) InsertQuarantined(ctx context.Context, provider string, id string, eventType string, orderingKey string, payload json.RawMessage, reason string, origin model.Origin) (bool, error) {
+	const q = `
+INSERT INTO events (id, provider, type, ordering_key, payload, status, attempts, next_retry_at, request_id, trace_context, last_error)
+VALUES ($1, $2, $3, NULLIF($4, ''), $5, 'quarantined', 0, now(), NULLIF($6, ''), $7, $8)
+ON CONFLICT (provider, id) DO NOTHING;
+`
+	traceContext, err := traceContextParam(origin)
 	if err != nil {
 		return false, err
 	}
-	return ra == 1, nil
+	return r.insert(ctx, q, id, provider, eventType, orderingKey, payload, origin.RequestID, traceContext, reason)
 }
 
-func (r *EventRepo) GetByID(ctx context.Context, id string) (model.Event, error) {
-	const q = `
-SELECT id, type, status, attempts, next_retry_at, last_error, created_at, updated_at, processed_at
-FROM events
-WHERE id = $1;
-`
+// traceContextParam is the trace_context value of origin: JSON, or NULL
+// when there is none.
+func traceContextParam(origin model.Origin) (any, error) {
+	if len(origin.TraceContext) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(origin.TraceContext)
+}
+
+// insert runs an INSERT ... ON CONFLICT DO NOTHING query and reports
+// whether it stored the row.
+func (r *EventRepo) insert(ctx context.Context, q string, args ...any) (bool, error) {
+	n, err := r.exec(ctx, q, args...)
+	return n == 1, err
+}
+
+const eventColumns = `id, provider, type, ordering_key, status, attempts, next_retry_at, last_error, created_at, updated_at, processed_at, dead_at, request_id`
+
+// GetByID also loads the payload, which lists leave out. provider "" finds
+// the event of any provider, as long as only one used the ID.
+func (r *EventRepo) GetByID(ctx context.Context, provider string, id string) (model.Event, error) {
+	q := `SELECT ` + eventColumns + `, payload FROM events WHERE id = $1 AND ($2 = '' OR provider = $2) LIMIT 2;`
+	rows, err := r.db.QueryContext(ctx, q, id, provider)
+	if err != nil {
+		return model.Event{}, err
+	}
+	defer rows.Close()
+
+	var found []model.Event
+	for rows.Next() {
+		var payload []byte
+		e, err := scanEvent(rows, &payload)
+		if err != nil {
+			return model.Event{}, err
+		}
+		e.Payload = payload
+		found = append(found, e)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Event{}, err
+	}
+	switch len(found) {
+	case 0:
+		return model.Event{}, model.ErrNotFound
+	case 1:
+		return found[0], nil
+	default:
+		return model.Event{}, task.ErrAmbiguousEventID
+	}
+}
+
+// scanEvent scans eventColumns, then any extra columns into extra.
+func scanEvent(row rowScanner, extra ...any) (model.Event, error) {
 	var e model.Event
-	err := r.db.QueryRowContext(ctx, q, id).Scan(
+	dest := []any{
 		&e.ID,
+		&e.Provider,
 		&e.Type,
+		&e.OrderingKey,
 		&e.Status,
 		&e.Attempts,
 		&e.NextRetryAt,
@@ -53,14 +116,11 @@ WHERE id = $1;
 		&e.CreatedAt,
 		&e.UpdatedAt,
 		&e.ProcessedAt,
-	)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return model.Event{}, model.ErrNotFound
-		}
-		return model.Event{}, err
+		&e.DeadAt,
+		&e.RequestID,
 	}
-	return e, nil
+	err := row.Scan(append(dest, extra...)...)
+	return e, err
 }
 
 // Optional: helpful for readyz/healthz
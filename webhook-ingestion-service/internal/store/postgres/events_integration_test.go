@@ -4,10 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
 )
 
 func TestInsertReceived_Dedup(t *testing.T) {
@@ -28,7 +33,7 @@ func TestInsertReceived_Dedup(t *testing.T) {
 	id := "evt_test_dedup_1"
 	payload := json.RawMessage(`{"type":"payment_succeeded","data":{"x":1}}`)
 
-	created1, err := repo.InsertReceived(ctx, id, "payment_succeeded", payload)
+	created1, err := repo.InsertReceived(ctx, "provider", id, "payment_succeeded", "", payload, model.Origin{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -36,7 +41,7 @@ func TestInsertReceived_Dedup(t *testing.T) {
 		t.Fatalf("expected created on first insert")
 	}
 
-	created2, err := repo.InsertReceived(ctx, id, "payment_succeeded", payload)
+	created2, err := repo.InsertReceived(ctx, "provider", id, "payment_succeeded", "", payload, model.Origin{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -44,3 +49,112 @@ func TestInsertReceived_Dedup(t *testing.T) {
 		t.Fatalf("expected duplicate on second insert")
 	}
 }
+
+func TestInsertReceived_SameIDFromTwoProviders(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set (integration test)")
+	}
+
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repo := NewEventRepo(db)
+
+	ctx := context.Background()
+	id := "evt_provider_clash_" + time.Now().UTC().Format("20060102_150405.000000")
+	payload := json.RawMessage(`{"type":"push"}`)
+
+	for _, provider := range []string{"github", "stripe"} {
+		created, err := repo.InsertReceived(ctx, provider, id, "push", "", payload, model.Origin{})
+		if err != nil || !created {
+			t.Fatalf("%s: created=%v err=%v", provider, created, err)
+		}
+	}
+	if created, err := repo.InsertReceived(ctx, "stripe", id, "push", "", payload, model.Origin{}); err != nil || created {
+		t.Fatalf("redelivery: created=%v err=%v", created, err)
+	}
+
+	ev, err := repo.GetByID(ctx, "stripe", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Provider != "stripe" {
+		t.Fatalf("expected provider stripe, got %q", ev.Provider)
+	}
+	if _, err := repo.GetByID(ctx, "", id); !errors.Is(err, task.ErrAmbiguousEventID) {
+		t.Fatalf("expected task.ErrAmbiguousEventID without a provider, got %v", err)
+	}
+}
+
+func TestInsertQuarantined_NotClaimedUntilReplayed(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	id := "evt_quarantined_" + time.Now().UTC().Format("20060102_150405.000000")
+
+	created, err := repo.InsertQuarantined(ctx, "provider", id, "invoice.paid", "", json.RawMessage(`{"data":{}}`), "/data: missing property 'invoice_id'", model.Origin{})
+	if err != nil || !created {
+		t.Fatalf("created=%v err=%v", created, err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE events SET created_at = '1970-01-01' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+	if created, err := repo.InsertQuarantined(ctx, "provider", id, "invoice.paid", "", json.RawMessage(`{}`), "again", model.Origin{}); err != nil || created {
+		t.Fatalf("duplicate: created=%v err=%v", created, err)
+	}
+
+	ev, err := repo.GetByID(ctx, "provider", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Status != model.StatusQuarantined || ev.LastError == nil || *ev.LastError != "/data: missing property 'invoice_id'" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if e, ok, err := repo.ClaimNextDue(ctx, task.Claim{WorkerID: "w-q", Lease: time.Minute}); err != nil || (ok && e.ID == id) {
+		t.Fatalf("quarantined event claimed: ok=%v err=%v", ok, err)
+	}
+
+	if n, err := repo.ReplayEvents(ctx, task.EventFilter{IDs: []string{id}}); err != nil || n != 1 {
+		t.Fatalf("replay: n=%d err=%v", n, err)
+	}
+	mustStatus(t, repo, id, model.StatusReceived)
+}
+
+func TestInsertReceived_OriginReachesTheWorker(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	id := "evt_origin_" + time.Now().UTC().Format("20060102_150405.000000")
+	origin := model.Origin{
+		RequestID:    "req-origin",
+		TraceContext: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+
+	if _, err := repo.InsertReceived(ctx, "provider", id, "payment_succeeded", "", json.RawMessage(`{}`), origin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE events SET created_at = '1970-01-01' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := repo.GetByID(ctx, "provider", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.RequestID == nil || *ev.RequestID != "req-origin" {
+		t.Fatalf("request_id=%v", ev.RequestID)
+	}
+
+	claimed, err := repo.ClaimBatch(ctx, task.Claim{WorkerID: "w-origin", Lease: time.Minute}, 1)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != id {
+		t.Fatalf("claimed=%+v err=%v", claimed, err)
+	}
+	if got := claimed[0].Origin; got.RequestID != origin.RequestID || got.TraceContext["traceparent"] != origin.TraceContext["traceparent"] {
+		t.Fatalf("origin=%+v", got)
+	}
+	if err := repo.MarkProcessed(ctx, testKey(id), "w-origin"); err != nil {
+		t.Fatal(err)
+	}
+}
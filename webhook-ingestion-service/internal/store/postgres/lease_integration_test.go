@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+func openLeaseTest(t *testing.T) (*sql.DB, *EventRepo) {
+	t.Helper()
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set (integration test)")
+	}
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, NewEventRepo(db)
+}
+
+// insertFirstInLine stores an event that ClaimNextDue picks before any other.
+func insertFirstInLine(t *testing.T, db *sql.DB, repo *EventRepo, prefix string) string {
+	t.Helper()
+	ctx := context.Background()
+	id := prefix + time.Now().UTC().Format("20060102_150405.000000")
+	if _, err := repo.InsertReceived(ctx, "provider", id, "payment_succeeded", "", json.RawMessage(`{}`), model.Origin{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE events SET created_at = '1970-01-01' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// testKey is the key of an event the helpers stored.
+func testKey(id string) model.EventKey {
+	return model.EventKey{Provider: "provider", ID: id}
+}
+
+func mustClaim(t *testing.T, repo *EventRepo, workerID string, lease time.Duration, want string) task.ClaimedEvent {
+	t.Helper()
+	e, ok, err := repo.ClaimNextDue(context.Background(), task.Claim{WorkerID: workerID, Lease: lease})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || e.ID != want {
+		t.Fatalf("%s: expected to claim %s, got %q (ok=%v)", workerID, want, e.ID, ok)
+	}
+	return e
+}
+
+func mustStatus(t *testing.T, repo *EventRepo, id string, want model.EventStatus) {
+	t.Helper()
+	st, _, err := repo.GetStatus(context.Background(), testKey(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != want {
+		t.Fatalf("expected status %s, got %s", want, st)
+	}
+}
+
+func TestLeases_CrashedWorkerIsReaped(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	id := insertFirstInLine(t, db, repo, "evt_lease_crash_")
+
+	mustClaim(t, repo, "w-crashed", time.Second, id)
+	// w-crashed dies here without marking the event.
+
+	if _, err := repo.ReleaseExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+	mustStatus(t, repo, id, model.StatusProcessing)
+
+	time.Sleep(1200 * time.Millisecond)
+	if _, err := repo.ReleaseExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+	mustStatus(t, repo, id, model.StatusFailed)
+	ev, err := repo.GetByID(ctx, "provider", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.LastError == nil || !strings.Contains(*ev.LastError, "w-crashed") {
+		t.Fatalf("expected last_error to name the crashed worker, got %v", ev.LastError)
+	}
+
+	if e := mustClaim(t, repo, "w-2", time.Minute, id); e.Attempts != 2 {
+		t.Fatalf("expected the lost attempt to count, got attempts=%d", e.Attempts)
+	}
+	// A worker that was only slow, not dead, finds its lease gone.
+	if err := repo.MarkProcessed(ctx, testKey(id), "w-crashed"); !errors.Is(err, task.ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost for the old worker, got %v", err)
+	}
+	if err := repo.MarkProcessed(ctx, testKey(id), "w-2"); err != nil {
+		t.Fatal(err)
+	}
+	mustStatus(t, repo, id, model.StatusProcessed)
+}
+
+func TestLeases_HeartbeatOutlivesLease(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	id := insertFirstInLine(t, db, repo, "evt_lease_heartbeat_")
+
+	mustClaim(t, repo, "w-busy", time.Second, id)
+	for i := 0; i < 3; i++ {
+		time.Sleep(500 * time.Millisecond)
+		if err := repo.ExtendLease(ctx, testKey(id), "w-busy", time.Second); err != nil {
+			t.Fatalf("heartbeat %d: %v", i, err)
+		}
+		if _, err := repo.ReleaseExpired(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 1.5s after a 1s lease, the heartbeats kept the event with its worker.
+	mustStatus(t, repo, id, model.StatusProcessing)
+
+	if err := repo.ExtendLease(ctx, testKey(id), "w-other", time.Second); !errors.Is(err, task.ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost for another worker, got %v", err)
+	}
+	if err := repo.MarkFailed(ctx, testKey(id), "w-busy", "boom", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ExtendLease(ctx, testKey(id), "w-busy", time.Second); !errors.Is(err, task.ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost after marking, got %v", err)
+	}
+}
+
+func TestLeases_CrashOnLastAttemptDeadLetters(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	id := insertFirstInLine(t, db, repo, "evt_lease_poison_")
+
+	// The only allowed attempt crashes its worker.
+	mustClaim(t, repo, "w-crashed", time.Millisecond, id)
+	time.Sleep(50 * time.Millisecond)
+	if _, err := repo.ReleaseExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	deps := task.WorkerDeps{
+		Repo:      repo,
+		Processor: task.NoopProcessor{},
+		Retry:     task.RetryPolicy{MaxAttempts: 1},
+		WorkerID:  "w-2",
+	}
+	if _, err := task.ProcessOnce(ctx, deps); !errors.Is(err, task.ErrDeadLettered) {
+		t.Fatalf("expected ErrDeadLettered, got %v", err)
+	}
+	mustStatus(t, repo, id, model.StatusDead)
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EventsDueChannel is notified by the events triggers whenever an event
+// becomes due now; see migration 006.
+const EventsDueChannel = "events_due"
+
+// listenerAppName tags the listener's connection in pg_stat_activity.
+const listenerAppName = "webhook-ingestion-listener"
+
+// Listener turns notifications on EventsDueChannel into wakeups. LISTEN needs
+// a connection of its own, outside the database/sql pool.
+type Listener struct {
+	dbURL  string
+	logger *slog.Logger
+	c      chan struct{}
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func NewListener(dbURL string, logger *slog.Logger) *Listener {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Listener{
+		dbURL:      dbURL,
+		logger:     logger,
+		c:          make(chan struct{}, 1),
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+	}
+}
+
+// C receives a value when there may be new work. Wakeups are coalesced, so a
+// burst of notifications yields one.
+func (l *Listener) C() <-chan struct{} {
+	return l.c
+}
+
+// Run listens until ctx is canceled, reconnecting with backoff whenever the
+// connection is lost.
+func (l *Listener) Run(ctx context.Context) {
+	backoff := l.minBackoff
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = l.minBackoff
+		}
+		l.logger.WarnContext(ctx, "listener: connection lost, reconnecting", "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, l.maxBackoff)
+	}
+}
+
+// listen reports whether LISTEN succeeded before the connection failed.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	cfg, err := pgx.ParseConfig(l.dbURL)
+	if err != nil {
+		return false, err
+	}
+	cfg.RuntimeParams["application_name"] = listenerAppName
+
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{EventsDueChannel}.Sanitize()); err != nil {
+		return false, err
+	}
+	// Anything inserted while we were not listening went unannounced.
+	l.wake()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return true, err
+		}
+		l.wake()
+	}
+}
+
+func (l *Listener) wake() {
+	select {
+	case l.c <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/observability/logging"
+)
+
+func expectWakeup(t *testing.T, l *Listener, what string) {
+	t.Helper()
+	select {
+	case <-l.C():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no wakeup %s", what)
+	}
+}
+
+// drainWakeups discards wakeups that are already pending.
+func drainWakeups(l *Listener) {
+	for {
+		select {
+		case <-l.C():
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
+
+func TestListener_WakesOnInsertAndReconnects(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewListener(os.Getenv("DB_URL"), logging.Discard())
+	l.minBackoff = 10 * time.Millisecond
+	go l.Run(ctx)
+
+	expectWakeup(t, l, "after connecting")
+	drainWakeups(l)
+
+	insertFirstInLine(t, db, repo, "evt_notify_")
+	expectWakeup(t, l, "after insert")
+
+	// Kill the listener's connection; it must come back and catch up.
+	if _, err := db.ExecContext(ctx, `SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE application_name = $1`, listenerAppName); err != nil {
+		t.Fatal(err)
+	}
+	expectWakeup(t, l, "after reconnecting")
+	drainWakeups(l)
+
+	insertFirstInLine(t, db, repo, "evt_notify_again_")
+	expectWakeup(t, l, "after insert on the new connection")
+}
@@ -0,0 +1,3 @@
+-- Events received before per-provider routing came through /webhooks/provider.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'provider';
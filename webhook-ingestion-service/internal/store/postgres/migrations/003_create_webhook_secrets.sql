@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS webhook_secrets (
+  id            TEXT PRIMARY KEY,
+  provider      TEXT NOT NULL,
+  secret        TEXT NOT NULL,
+
+  not_before    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  not_after     TIMESTAMPTZ NULL,
+
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_used_at  TIMESTAMPTZ NULL,
+
+  CHECK (not_after IS NULL OR not_after > not_before)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_secrets_provider
+  ON webhook_secrets (provider);
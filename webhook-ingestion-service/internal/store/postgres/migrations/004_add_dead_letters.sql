@@ -0,0 +1,26 @@
+-- Events that used up their attempts are dead-lettered instead of retried
+-- forever; an operator requeues or discards them. Discarded events are kept
+-- so a redelivery is still recognised as a duplicate.
+-- Later migrations widen the status check again, so it is only replaced
+-- while it still lacks these statuses.
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1
+    FROM pg_constraint
+    WHERE conrelid = 'events'::regclass
+      AND conname = 'events_status_check'
+      AND pg_get_constraintdef(oid) LIKE '%''discarded''%'
+  ) THEN
+    ALTER TABLE events DROP CONSTRAINT IF EXISTS events_status_check;
+    ALTER TABLE events ADD CONSTRAINT events_status_check
+      CHECK (status IN ('received','processing','processed','failed','dead','discarded'));
+  END IF;
+END $$;
+
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS dead_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_dead
+  ON events (dead_at DESC, id)
+  WHERE status = 'dead';
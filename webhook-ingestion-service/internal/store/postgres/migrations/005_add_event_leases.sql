@@ -0,0 +1,15 @@
+-- A claimed event is leased to one worker until locked_until. The worker
+-- extends the lease while it is busy; once it lapses the reaper puts the
+-- event back in the queue.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ NULL,
+  ADD COLUMN IF NOT EXISTS locked_by    TEXT NULL;
+
+-- Events claimed before leases existed get one, so they cannot stay stuck.
+UPDATE events
+SET locked_until = now() + interval '5 minutes'
+WHERE status = 'processing' AND locked_until IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_leases
+  ON events (locked_until)
+  WHERE status = 'processing';
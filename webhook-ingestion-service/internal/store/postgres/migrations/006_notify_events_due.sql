@@ -0,0 +1,27 @@
+-- Wake idle workers as soon as an event becomes due instead of waiting for
+-- their next poll. Notifications with the same payload are folded per
+-- transaction, so a bulk requeue sends one.
+CREATE OR REPLACE FUNCTION notify_events_due()
+RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM pg_notify('events_due', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_events_notify_insert ON events;
+
+CREATE TRIGGER trg_events_notify_insert
+AFTER INSERT ON events
+FOR EACH ROW
+EXECUTE FUNCTION notify_events_due();
+
+-- Requeued, released and reaped events are due right away too. Retries
+-- scheduled for later are picked up by the workers' fallback polling.
+DROP TRIGGER IF EXISTS trg_events_notify_due ON events;
+
+CREATE TRIGGER trg_events_notify_due
+AFTER UPDATE OF status ON events
+FOR EACH ROW
+WHEN (NEW.status IN ('received','failed') AND NEW.next_retry_at <= now())
+EXECUTE FUNCTION notify_events_due();
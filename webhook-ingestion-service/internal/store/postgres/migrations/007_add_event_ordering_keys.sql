@@ -0,0 +1,22 @@
+-- Events sharing an ordering key (per provider), e.g. everything about one
+-- payment, are processed one at a time in created_at order. NULL means the
+-- event is not ordered.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS ordering_key TEXT NULL;
+
+-- Claiming looks up earlier unresolved events of the same key. Dead events
+-- block their key until they are requeued or discarded.
+CREATE INDEX IF NOT EXISTS idx_events_ordering
+  ON events (provider, ordering_key, created_at, id)
+  WHERE ordering_key IS NOT NULL
+    AND status IN ('received','processing','failed','dead');
+
+-- Resolving an ordered event unblocks the next one of its key, so wake the
+-- workers as for any other event coming due.
+DROP TRIGGER IF EXISTS trg_events_notify_unblocked ON events;
+
+CREATE TRIGGER trg_events_notify_unblocked
+AFTER UPDATE OF status ON events
+FOR EACH ROW
+WHEN (NEW.ordering_key IS NOT NULL AND NEW.status IN ('processed','discarded'))
+EXECUTE FUNCTION notify_events_due();
@@ -0,0 +1,3 @@
+-- The admin API lists events newest first and pages on (created_at, id).
+CREATE INDEX IF NOT EXISTS idx_events_created
+  ON events (created_at, id);
@@ -0,0 +1,57 @@
+-- Each time an event leaves 'processing' its attempt is recorded, whichever
+-- path it took: marked by its worker, released on shutdown or reaped after
+-- the worker crashed.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ NULL;
+
+CREATE TABLE IF NOT EXISTS event_attempts (
+  id           BIGSERIAL PRIMARY KEY,
+  event_id     TEXT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+  attempt      INT  NOT NULL,
+  worker_id    TEXT NULL,
+  started_at   TIMESTAMPTZ NOT NULL,
+  finished_at  TIMESTAMPTZ NOT NULL,
+  -- released: handed back unprocessed, e.g. on shutdown; not counted.
+  outcome      TEXT NOT NULL CHECK (outcome IN ('processed','failed','dead','released')),
+  error        TEXT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_attempts_event
+  ON event_attempts (event_id, id);
+
+-- Migration 012 redefines the function to record the provider, so it is
+-- only created here when missing; re-running this file must not undo that.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'record_event_attempt') THEN
+    EXECUTE $create$
+    CREATE FUNCTION record_event_attempt()
+    RETURNS TRIGGER AS $fn$
+    DECLARE
+      released BOOLEAN := NEW.attempts < OLD.attempts;
+    BEGIN
+      INSERT INTO event_attempts (event_id, attempt, worker_id, started_at, finished_at, outcome, error)
+      VALUES (
+        OLD.id,
+        OLD.attempts,
+        OLD.locked_by,
+        -- Events claimed before this migration have no claimed_at.
+        COALESCE(OLD.claimed_at, OLD.updated_at),
+        now(),
+        CASE WHEN released THEN 'released' ELSE NEW.status END,
+        CASE WHEN released THEN NULL ELSE NEW.last_error END
+      );
+      RETURN NULL;
+    END;
+    $fn$ LANGUAGE plpgsql;
+    $create$;
+  END IF;
+END $$;
+
+DROP TRIGGER IF EXISTS trg_events_record_attempt ON events;
+
+CREATE TRIGGER trg_events_record_attempt
+AFTER UPDATE OF status ON events
+FOR EACH ROW
+WHEN (OLD.status = 'processing' AND NEW.status <> 'processing')
+EXECUTE FUNCTION record_event_attempt();
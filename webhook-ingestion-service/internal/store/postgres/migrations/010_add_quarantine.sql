@@ -0,0 +1,27 @@
+-- Events whose data fails the schema of their type can be stored as
+-- quarantined instead of rejected. They are not processed until an operator
+-- replays them, and last_error lists what did not match.
+-- This is the full list of statuses; it is only replaced while it lacks
+-- 'quarantined', so re-running 004 cannot narrow it again.
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1
+    FROM pg_constraint
+    WHERE conrelid = 'events'::regclass
+      AND conname = 'events_status_check'
+      AND pg_get_constraintdef(oid) LIKE '%''quarantined''%'
+  ) THEN
+    ALTER TABLE events DROP CONSTRAINT IF EXISTS events_status_check;
+    ALTER TABLE events ADD CONSTRAINT events_status_check
+      CHECK (status IN ('received','processing','processed','failed','dead','discarded','quarantined'));
+  END IF;
+END $$;
+
+-- A quarantined event holds up its ordering key like a dead one.
+DROP INDEX IF EXISTS idx_events_ordering;
+
+CREATE INDEX IF NOT EXISTS idx_events_ordering
+  ON events (provider, ordering_key, created_at, id)
+  WHERE ordering_key IS NOT NULL
+    AND status IN ('received','processing','failed','dead','quarantined');
@@ -0,0 +1,8 @@
+-- Each event remembers the delivery that stored it: the request ID and the
+-- W3C trace context of the ingest span, as {"traceparent": ..., ...}. The
+-- worker links its processing span to that span.
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS request_id TEXT NULL;
+
+ALTER TABLE events
+  ADD COLUMN IF NOT EXISTS trace_context JSONB NULL;
@@ -0,0 +1,52 @@
+-- Providers choose their own event IDs, so two of them may well use the same
+-- one. An event is identified by its provider and ID, and so are its
+-- attempts.
+ALTER TABLE event_attempts
+  ADD COLUMN IF NOT EXISTS provider TEXT NULL;
+
+UPDATE event_attempts a
+SET provider = e.provider
+FROM events e
+WHERE a.provider IS NULL AND e.id = a.event_id;
+
+ALTER TABLE event_attempts
+  ALTER COLUMN provider SET NOT NULL;
+
+-- Swapping the keys rebuilds their indexes, so it only happens once.
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1
+    FROM pg_constraint
+    WHERE conrelid = 'events'::regclass
+      AND conname = 'events_pkey'
+      AND pg_get_constraintdef(oid) = 'PRIMARY KEY (provider, id)'
+  ) THEN
+    ALTER TABLE event_attempts DROP CONSTRAINT IF EXISTS event_attempts_event_id_fkey;
+    ALTER TABLE events DROP CONSTRAINT IF EXISTS events_pkey;
+    ALTER TABLE events ADD CONSTRAINT events_pkey PRIMARY KEY (provider, id);
+    ALTER TABLE event_attempts ADD CONSTRAINT event_attempts_event_id_fkey
+      FOREIGN KEY (provider, event_id) REFERENCES events (provider, id) ON DELETE CASCADE;
+  END IF;
+END $$;
+
+CREATE OR REPLACE FUNCTION record_event_attempt()
+RETURNS TRIGGER AS $$
+DECLARE
+  released BOOLEAN := NEW.attempts < OLD.attempts;
+BEGIN
+  INSERT INTO event_attempts (provider, event_id, attempt, worker_id, started_at, finished_at, outcome, error)
+  VALUES (
+    OLD.provider,
+    OLD.id,
+    OLD.attempts,
+    OLD.locked_by,
+    -- Events claimed before migration 009 have no claimed_at.
+    COALESCE(OLD.claimed_at, OLD.updated_at),
+    now(),
+    CASE WHEN released THEN 'released' ELSE NEW.status END,
+    CASE WHEN released THEN NULL ELSE NEW.last_error END
+  );
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+// insertOrdered stores an event with an ordering key, first in line and
+// ordered by age among the events of one test.
+func insertOrdered(t *testing.T, db *sql.DB, repo *EventRepo, id, key string, age int) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := repo.InsertReceived(ctx, "provider", id, "payment_succeeded", key, json.RawMessage(`{}`), model.Origin{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE events SET created_at = '1970-01-01'::timestamptz + make_interval(secs => $2) WHERE id = $1`, id, float64(age)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func claimedIDs(t *testing.T, repo *EventRepo, workerID string) []string {
+	t.Helper()
+	claimed, err := repo.ClaimBatch(context.Background(), task.Claim{WorkerID: workerID, Lease: time.Minute}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range claimed {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestOrdering_OneEventPerKeyInOrder(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	run := time.Now().UTC().Format("20060102_150405.000000")
+	key, other := "pay_"+run, "pay_other_"+run
+	first, second, unrelated := "evt_order_1_"+run, "evt_order_2_"+run, "evt_order_other_"+run
+	insertOrdered(t, db, repo, first, key, 1)
+	insertOrdered(t, db, repo, second, key, 2)
+	insertOrdered(t, db, repo, unrelated, other, 3)
+
+	got := claimedIDs(t, repo, "w-order")
+	if !slices.Contains(got, first) || !slices.Contains(got, unrelated) || slices.Contains(got, second) {
+		t.Fatalf("expected %s and %s but not %s, got %v", first, unrelated, second, got)
+	}
+
+	// A failed attempt keeps the key blocked, even once the retry is due.
+	if err := repo.MarkFailed(ctx, testKey(first), "w-order", "boom", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := claimedIDs(t, repo, "w-order"); !slices.Contains(got, first) || slices.Contains(got, second) {
+		t.Fatalf("expected the retry of %s before %s, got %v", first, second, got)
+	}
+
+	// So does a dead one, until it is discarded.
+	if err := repo.MarkDead(ctx, testKey(first), "w-order", "gave up"); err != nil {
+		t.Fatal(err)
+	}
+	if got := claimedIDs(t, repo, "w-order"); slices.Contains(got, second) {
+		t.Fatalf("%s claimed behind a dead event", second)
+	}
+	if n, err := repo.DiscardDead(ctx, task.DeadLetterFilter{IDs: []string{first}}); err != nil || n != 1 {
+		t.Fatalf("discard: n=%d err=%v", n, err)
+	}
+	if got := claimedIDs(t, repo, "w-order"); !slices.Contains(got, second) {
+		t.Fatalf("expected %s once %s was discarded, got %v", second, first, got)
+	}
+}
+
+func TestOrdering_InFlightEventBlocksEarlierArrivals(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	run := time.Now().UTC().Format("20060102_150405.000000")
+	key := "pay_late_" + run
+	later, earlier := "evt_order_later_"+run, "evt_order_earlier_"+run
+	insertOrdered(t, db, repo, later, key, 2)
+
+	if got := claimedIDs(t, repo, "w-late"); !slices.Contains(got, later) {
+		t.Fatalf("expected %s, got %v", later, got)
+	}
+	// An older event of the key shows up while the newer one is in flight,
+	// e.g. its insert committed late.
+	insertOrdered(t, db, repo, earlier, key, 1)
+	if got := claimedIDs(t, repo, "w-late"); slices.Contains(got, earlier) {
+		t.Fatalf("%s claimed while %s is in flight", earlier, later)
+	}
+
+	if err := repo.MarkProcessed(ctx, testKey(later), "w-late"); err != nil {
+		t.Fatal(err)
+	}
+	if got := claimedIDs(t, repo, "w-late"); !slices.Contains(got, earlier) {
+		t.Fatalf("expected %s once %s was processed, got %v", earlier, later, got)
+	}
+}
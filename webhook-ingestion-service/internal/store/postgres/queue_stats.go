@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// queueStatuses are reported even when empty; processed and discarded
+// events are history rather than queue.
+var queueStatuses = []string{"received", "processing", "failed", "dead", "quarantined"}
+
+// QueueStats counts the events per queue status and measures how long the
+// oldest due event has been waiting. Events held back by their ordering key
+// count as waiting too.
+func (r *EventRepo) QueueStats(ctx context.Context) (map[string]int64, time.Duration, error) {
+	const q = `
+SELECT status, count(*)
+FROM events
+WHERE status = ANY($1)
+GROUP BY status;
+`
+	rows, err := r.db.QueryContext(ctx, q, queueStatuses)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	byStatus := make(map[string]int64, len(queueStatuses))
+	for _, st := range queueStatuses {
+		byStatus[st] = 0
+	}
+	for rows.Next() {
+		var st string
+		var n int64
+		if err := rows.Scan(&st, &n); err != nil {
+			return nil, 0, err
+		}
+		byStatus[st] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	const oldestQ = `
+SELECT COALESCE(extract(epoch FROM now() - min(next_retry_at)), 0)
+FROM events
+WHERE status IN ('received','failed') AND next_retry_at <= now();
+`
+	var secs float64
+	if err := r.db.QueryRowContext(ctx, oldestQ).Scan(&secs); err != nil {
+		return nil, 0, err
+	}
+	return byStatus, time.Duration(secs * float64(time.Second)), nil
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueStats(t *testing.T) {
+	db, repo := openLeaseTest(t)
+	ctx := context.Background()
+	id := insertFirstInLine(t, db, repo, "evt_queue_stats_")
+	if _, err := db.ExecContext(ctx, `UPDATE events SET next_retry_at = now() - interval '1 hour' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+
+	byStatus, oldest, err := repo.QueueStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range queueStatuses {
+		if _, ok := byStatus[st]; !ok {
+			t.Fatalf("missing status %s in %v", st, byStatus)
+		}
+	}
+	if byStatus["received"] < 1 {
+		t.Fatalf("expected at least one received event, got %v", byStatus)
+	}
+	if oldest < time.Hour {
+		t.Fatalf("expected the oldest due event to wait at least 1h, got %s", oldest)
+	}
+	mustClaim(t, repo, "w-stats", time.Minute, id)
+}
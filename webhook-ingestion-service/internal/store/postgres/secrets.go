@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+type SecretRepo struct {
+	db *sql.DB
+}
+
+func NewSecretRepo(db *sql.DB) *SecretRepo {
+	return &SecretRepo{db: db}
+}
+
+const secretColumns = `id, provider, secret, not_before, not_after, created_at, last_used_at`
+
+// InsertSecret returns model.ErrConflict if the id is taken.
+func (r *SecretRepo) InsertSecret(ctx context.Context, s model.WebhookSecret) (model.WebhookSecret, error) {
+	const q = `
+INSERT INTO webhook_secrets (id, provider, secret, not_before, not_after)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + secretColumns + `;
+`
+	out, err := scanSecret(r.db.QueryRowContext(ctx, q, s.ID, s.Provider, s.Secret, s.NotBefore.UTC(), s.NotAfter))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		return model.WebhookSecret{}, model.ErrConflict
+	}
+	return out, err
+}
+
+// ListSecrets returns the provider's secrets, newest first.
+func (r *SecretRepo) ListSecrets(ctx context.Context, provider string) ([]model.WebhookSecret, error) {
+	const q = `
+SELECT ` + secretColumns + `
+FROM webhook_secrets
+WHERE provider = $1
+ORDER BY not_before DESC, created_at DESC;
+`
+	rows, err := r.db.QueryContext(ctx, q, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.WebhookSecret
+	for rows.Next() {
+		s, err := scanSecret(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// RetireSecret ends the secret's validity at `at`. A secret that already
+// expires earlier keeps its earlier end.
+func (r *SecretRepo) RetireSecret(ctx context.Context, provider, id string, at time.Time) (model.WebhookSecret, error) {
+	// LEAST ignores NULLs, so an open-ended secret simply gets `at`;
+	// GREATEST keeps not_after > not_before for secrets not yet valid.
+	const q = `
+UPDATE webhook_secrets
+SET not_after = GREATEST(LEAST(not_after, $3), not_before + interval '1 microsecond')
+WHERE provider = $1 AND id = $2
+RETURNING ` + secretColumns + `;
+`
+	s, err := scanSecret(r.db.QueryRowContext(ctx, q, provider, id, at.UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.WebhookSecret{}, model.ErrNotFound
+	}
+	return s, err
+}
+
+func (r *SecretRepo) TouchSecret(ctx context.Context, id string, at time.Time) error {
+	const q = `UPDATE webhook_secrets SET last_used_at = $2 WHERE id = $1;`
+	_, err := r.db.ExecContext(ctx, q, id, at.UTC())
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSecret(row rowScanner) (model.WebhookSecret, error) {
+	var s model.WebhookSecret
+	err := row.Scan(&s.ID, &s.Provider, &s.Secret, &s.NotBefore, &s.NotAfter, &s.CreatedAt, &s.LastUsedAt)
+	if err != nil {
+		return model.WebhookSecret{}, err
+	}
+	return s, nil
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+func TestSecretRepo_AddListRetire(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set (integration test)")
+	}
+
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repo := NewSecretRepo(db)
+	ctx := context.Background()
+	provider := "it_" + time.Now().UTC().Format("20060102_150405.000000")
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	s := model.WebhookSecret{ID: provider + "_k1", Provider: provider, Secret: "0123456789abcdef", NotBefore: now}
+	if _, err := repo.InsertSecret(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.InsertSecret(ctx, s); !errors.Is(err, model.ErrConflict) {
+		t.Fatalf("expected model.ErrConflict, got %v", err)
+	}
+
+	later := now.Add(time.Hour)
+	retired, err := repo.RetireSecret(ctx, provider, s.ID, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retired.NotAfter == nil || !retired.NotAfter.Equal(later) {
+		t.Fatalf("expected not_after %s, got %v", later, retired.NotAfter)
+	}
+	// Retiring again with a later time keeps the earlier end.
+	retired, err = repo.RetireSecret(ctx, provider, s.ID, later.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !retired.NotAfter.Equal(later) {
+		t.Fatalf("expected not_after to stay %s, got %v", later, retired.NotAfter)
+	}
+
+	if _, err := repo.RetireSecret(ctx, provider, "missing", later); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("expected model.ErrNotFound, got %v", err)
+	}
+
+	if err := repo.TouchSecret(ctx, s.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	list, err := repo.ListSecrets(ctx, provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Secret != s.Secret || list[0].LastUsedAt == nil {
+		t.Fatalf("unexpected secrets %+v", list)
+	}
+}
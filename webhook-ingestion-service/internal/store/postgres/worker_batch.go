@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/task"
+)
+
+// ClaimBatch claims up to n due events with one statement. The due CTE
+// locks the rows it picks and skips rows other workers hold, as in
+// ClaimNextDue. A batch never holds two events of one ordering key: the
+// later one is blocked by the earlier. Rows over a Claim.TypeCaps cap are
+// left as they were, so a capped batch can come back short.
+func (r *EventRepo) ClaimBatch(ctx context.Context, c task.Claim, n int) ([]task.ClaimedEvent, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	capTypes := make([]string, 0, len(c.TypeCaps))
+	for eventType := range c.TypeCaps {
+		capTypes = append(capTypes, eventType)
+	}
+	sort.Strings(capTypes)
+	caps := make([]int64, len(capTypes))
+	for i, eventType := range capTypes {
+		caps[i] = int64(c.TypeCaps[eventType])
+	}
+	args := []any{c.WorkerID, c.Lease.Seconds(), n, capTypes, caps}
+	skip := ""
+	if len(c.SkipTypes) > 0 {
+		args = append(args, c.SkipTypes)
+		skip = fmt.Sprintf("\n    AND NOT (type = ANY($%d))", len(args))
+	}
+	q := `
+WITH due AS (
+  SELECT provider, id, type, created_at
+  FROM events
+  WHERE status IN ('received','failed')
+    AND next_retry_at <= now()` + inKeyOrder + skip + `
+  ORDER BY created_at, id
+  FOR UPDATE SKIP LOCKED
+  LIMIT $3
+),
+picked AS (
+  SELECT provider, id
+  FROM (
+    SELECT provider, id, type,
+           row_number() OVER (PARTITION BY type ORDER BY created_at, id) AS nth
+    FROM due
+  ) d
+  LEFT JOIN unnest($4::text[], $5::int[]) AS lim(type, n) USING (type)
+  WHERE lim.n IS NULL OR d.nth <= lim.n
+),
+claimed AS (
+  UPDATE events
+  SET status = 'processing',
+      attempts = attempts + 1,
+      claimed_at = now(),
+      locked_by = $1,
+      locked_until = now() + make_interval(secs => $2)
+  WHERE (provider, id) IN (SELECT provider, id FROM picked)
+  RETURNING ` + claimedColumns + `, created_at
+)
+SELECT ` + claimedColumns + ` FROM claimed ORDER BY created_at, id;
+`
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []task.ClaimedEvent
+	for rows.Next() {
+		e, err := scanClaimed(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (r *EventRepo) MarkProcessedBatch(ctx context.Context, workerID string, keys []model.EventKey) ([]model.EventKey, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	providers, ids := splitKeys(keys)
+	const q = `
+UPDATE events e
+SET status = 'processed',
+    processed_at = now(),
+    last_error = NULL,
+    locked_by = NULL,
+    locked_until = NULL
+FROM unnest($1::text[], $2::text[]) AS k(provider, id)
+WHERE e.provider = k.provider AND e.id = k.id AND e.status = 'processing' AND e.locked_by = $3
+RETURNING e.provider, e.id;
+`
+	return r.markBatch(ctx, keys, q, providers, ids, workerID)
+}
+
+func (r *EventRepo) MarkFailedBatch(ctx context.Context, workerID string, events []task.FailedEvent) ([]model.EventKey, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	keys := make([]model.EventKey, len(events))
+	lastErrs := make([]string, len(events))
+	nexts := make([]time.Time, len(events))
+	for i, e := range events {
+		keys[i], lastErrs[i], nexts[i] = e.Key, e.LastError, e.NextRetryAt.UTC()
+	}
+	providers, ids := splitKeys(keys)
+	const q = `
+UPDATE events e
+SET status = 'failed',
+    last_error = f.last_error,
+    next_retry_at = f.next_retry_at,
+    locked_by = NULL,
+    locked_until = NULL
+FROM unnest($1::text[], $2::text[], $3::text[], $4::timestamptz[]) AS f(provider, id, last_error, next_retry_at)
+WHERE e.provider = f.provider AND e.id = f.id AND e.status = 'processing' AND e.locked_by = $5
+RETURNING e.provider, e.id;
+`
+	return r.markBatch(ctx, keys, q, providers, ids, lastErrs, nexts, workerID)
+}
+
+// splitKeys returns the providers and IDs of keys as parallel arrays, for
+// unnest.
+func splitKeys(keys []model.EventKey) (providers, ids []string) {
+	providers = make([]string, len(keys))
+	ids = make([]string, len(keys))
+	for i, k := range keys {
+		providers[i], ids[i] = k.Provider, k.ID
+	}
+	return providers, ids
+}
+
+// markBatch runs an UPDATE ... RETURNING provider, id and reports the keys
+// it missed.
+func (r *EventRepo) markBatch(ctx context.Context, keys []model.EventKey, q string, args ...any) ([]model.EventKey, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	marked := make(map[model.EventKey]bool, len(keys))
+	for rows.Next() {
+		var k model.EventKey
+		if err := rows.Scan(&k.Provider, &k.ID); err != nil {
+			return nil, err
+		}
+		marked[k] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var lost []model.EventKey
+	for _, k := range keys {
+		if !marked[k] {
+			lost = append(lost, k)
+		}
+	}
+	return lost, nil
+}
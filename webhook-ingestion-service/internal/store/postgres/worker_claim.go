@@ -3,15 +3,34 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"webhook-ingestion-service/internal/model"
 	"webhook-ingestion-service/internal/task"
 )
 
-// ClaimNextDue atomically claims ONE due event and marks it as processing.
+// inKeyOrder keeps an event with an ordering key queued while another event
+// of its key is processing, or while an earlier one is received, failed,
+// dead or quarantined. A rejected event thus blocks its key until it is
+// requeued and processed, or discarded. The candidate row is referred to as "events".
+const inKeyOrder = `
+  AND (ordering_key IS NULL OR NOT EXISTS (
+    SELECT 1
+    FROM events prev
+    WHERE prev.provider = events.provider
+      AND prev.ordering_key = events.ordering_key
+      AND prev.id <> events.id
+      AND (prev.status = 'processing'
+        OR (prev.status IN ('received','failed','dead','quarantined')
+          AND (prev.created_at, prev.id) < (events.created_at, events.id)))
+  ))`
+
+// ClaimNextDue atomically claims ONE due event, marks it as processing and
+// leases it to workerID for lease.
 // Returns (event, true, nil) if claimed; (zero, false, nil) if none due.
-func (r *EventRepo) ClaimNextDue(ctx context.Context) (task.ClaimedEvent, bool, error) {
+func (r *EventRepo) ClaimNextDue(ctx context.Context, c task.Claim) (task.ClaimedEvent, bool, error) {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
 		// default isolation is fine; row locks do the heavy lifting
 	})
@@ -20,17 +39,23 @@ func (r *EventRepo) ClaimNextDue(ctx context.Context) (task.ClaimedEvent, bool,
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	const selectQ = `
-SELECT id, type, payload, attempts
+	selectQ := `
+SELECT ` + claimedColumns + `
 FROM events
 WHERE status IN ('received','failed')
-  AND next_retry_at <= now()
-ORDER BY created_at
+  AND next_retry_at <= now()` + inKeyOrder
+	var args []any
+	if len(c.SkipTypes) > 0 {
+		selectQ += `
+  AND NOT (type = ANY($1))`
+		args = append(args, c.SkipTypes)
+	}
+	selectQ += `
+ORDER BY created_at, id
 FOR UPDATE SKIP LOCKED
 LIMIT 1;
 `
-	var e task.ClaimedEvent
-	err = tx.QueryRowContext(ctx, selectQ).Scan(&e.ID, &e.Type, &e.Payload, &e.Attempts)
+	e, err := scanClaimed(tx.QueryRowContext(ctx, selectQ, args...))
 	if err == sql.ErrNoRows {
 		_ = tx.Commit()
 		return task.ClaimedEvent{}, false, nil
@@ -42,11 +67,14 @@ LIMIT 1;
 	const updateQ = `
 UPDATE events
 SET status = 'processing',
-    attempts = attempts + 1
-WHERE id = $1
+    attempts = attempts + 1,
+    claimed_at = now(),
+    locked_by = $3,
+    locked_until = now() + make_interval(secs => $4)
+WHERE provider = $1 AND id = $2
 RETURNING attempts;
 `
-	if err := tx.QueryRowContext(ctx, updateQ, e.ID).Scan(&e.Attempts); err != nil {
+	if err := tx.QueryRowContext(ctx, updateQ, e.Provider, e.ID, c.WorkerID, c.Lease.Seconds()).Scan(&e.Attempts); err != nil {
 		return task.ClaimedEvent{}, false, err
 	}
 
@@ -56,36 +84,121 @@ RETURNING attempts;
 	return e, true, nil
 }
 
-func (r *EventRepo) MarkProcessed(ctx context.Context, id string) error {
+const claimedColumns = `provider, id, type, payload, attempts, request_id, trace_context`
+
+// scanClaimed scans claimedColumns.
+func scanClaimed(row rowScanner) (task.ClaimedEvent, error) {
+	var e task.ClaimedEvent
+	var requestID sql.NullString
+	var traceContext []byte
+	if err := row.Scan(&e.Provider, &e.ID, &e.Type, &e.Payload, &e.Attempts, &requestID, &traceContext); err != nil {
+		return task.ClaimedEvent{}, err
+	}
+	e.Origin.RequestID = requestID.String
+	if traceContext != nil {
+		if err := json.Unmarshal(traceContext, &e.Origin.TraceContext); err != nil {
+			return task.ClaimedEvent{}, fmt.Errorf("event %s/%s: trace context: %w", e.Provider, e.ID, err)
+		}
+	}
+	return e, nil
+}
+
+// ExtendLease is the heartbeat of a worker still busy with the event.
+func (r *EventRepo) ExtendLease(ctx context.Context, key model.EventKey, workerID string, lease time.Duration) error {
+	const q = `
+UPDATE events
+SET locked_until = now() + make_interval(secs => $4)
+WHERE provider = $1 AND id = $2 AND status = 'processing' AND locked_by = $3;
+`
+	return r.execLeased(ctx, q, key.Provider, key.ID, workerID, lease.Seconds())
+}
+
+func (r *EventRepo) MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error {
 	const q = `
 UPDATE events
 SET status = 'processed',
     processed_at = now(),
-    last_error = NULL
-WHERE id = $1;
+    last_error = NULL,
+    locked_by = NULL,
+    locked_until = NULL
+WHERE provider = $1 AND id = $2 AND status = 'processing' AND locked_by = $3;
 `
-	_, err := r.db.ExecContext(ctx, q, id)
-	return err
+	return r.execLeased(ctx, q, key.Provider, key.ID, workerID)
 }
 
-func (r *EventRepo) MarkFailed(ctx context.Context, id string, lastErr string, nextRetryAt time.Time) error {
+func (r *EventRepo) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
 	const q = `
 UPDATE events
 SET status = 'failed',
-    last_error = $2,
-    next_retry_at = $3
-WHERE id = $1;
+    last_error = $4,
+    next_retry_at = $5,
+    locked_by = NULL,
+    locked_until = NULL
+WHERE provider = $1 AND id = $2 AND status = 'processing' AND locked_by = $3;
 `
-	_, err := r.db.ExecContext(ctx, q, id, lastErr, nextRetryAt.UTC())
-	return err
+	return r.execLeased(ctx, q, key.Provider, key.ID, workerID, lastErr, nextRetryAt.UTC())
+}
+
+func (r *EventRepo) MarkDead(ctx context.Context, key model.EventKey, workerID string, lastErr string) error {
+	const q = `
+UPDATE events
+SET status = 'dead',
+    last_error = $4,
+    dead_at = now(),
+    locked_by = NULL,
+    locked_until = NULL
+WHERE provider = $1 AND id = $2 AND status = 'processing' AND locked_by = $3;
+`
+	return r.execLeased(ctx, q, key.Provider, key.ID, workerID, lastErr)
+}
+
+func (r *EventRepo) ReleaseLease(ctx context.Context, key model.EventKey, workerID string) error {
+	const q = `
+UPDATE events
+SET status = CASE WHEN attempts > 1 THEN 'failed' ELSE 'received' END,
+    attempts = attempts - 1,
+    next_retry_at = now(),
+    locked_by = NULL,
+    locked_until = NULL
+WHERE provider = $1 AND id = $2 AND status = 'processing' AND locked_by = $3;
+`
+	return r.execLeased(ctx, q, key.Provider, key.ID, workerID)
+}
+
+// ReleaseExpired returns events whose lease lapsed, i.e. whose worker
+// crashed or hung, to the queue as failed and due now. The attempt stays
+// counted, so an event that keeps killing its worker is dead-lettered
+// once it runs out of attempts.
+func (r *EventRepo) ReleaseExpired(ctx context.Context) (int64, error) {
+	const q = `
+UPDATE events
+SET status = 'failed',
+    last_error = 'lease expired: worker ' || COALESCE(locked_by, 'unknown') || ' did not finish',
+    next_retry_at = now(),
+    locked_by = NULL,
+    locked_until = NULL
+WHERE status = 'processing' AND locked_until < now();
+`
+	return r.exec(ctx, q)
+}
+
+func (r *EventRepo) execLeased(ctx context.Context, q string, args ...any) error {
+	n, err := r.exec(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return task.ErrLeaseLost
+	}
+	return nil
 }
 
 // (Optional helper) expose status for tests/debug
-func (r *EventRepo) GetStatus(ctx context.Context, id string) (model.EventStatus, int, error) {
-	const q = `SELECT status, attempts FROM events WHERE id = $1;`
+func (r *EventRepo) GetStatus(ctx context.Context, key model.EventKey) (model.EventStatus, int, error) {
+	const q = `SELECT status, attempts FROM events WHERE provider = $1 AND id = $2;`
 	var st model.EventStatus
 	var attempts int
-	err := r.db.QueryRowContext(ctx, q, id).Scan(&st, &attempts)
+	err := r.db.QueryRowContext(ctx, q, key.Provider, key.ID).Scan(&st, &attempts)
 	if err == sql.ErrNoRows {
 		return "", 0, model.ErrNotFound
 	}
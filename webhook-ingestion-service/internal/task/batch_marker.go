@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/metrics"
+)
+
+// maxMarkBatch caps the events written by one batched mark.
+const maxMarkBatch = 100
+
+// batchMarker group-commits MarkProcessed and MarkFailed: calls made while a
+// flush is running are written together by the next one. Callers still get
+// their own result, so to handleClaimed it is a plain WorkerRepository.
+type batchMarker struct {
+	WorkerRepository
+	reqs chan markRequest
+	done chan struct{}
+}
+
+type markRequest struct {
+	workerID string
+	key      model.EventKey
+	failed   *FailedEvent
+	result   chan error
+}
+
+func newBatchMarker(repo WorkerRepository) *batchMarker {
+	b := &batchMarker{
+		WorkerRepository: repo,
+		reqs:             make(chan markRequest, maxMarkBatch),
+		done:             make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *batchMarker) MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error {
+	return b.mark(markRequest{workerID: workerID, key: key})
+}
+
+func (b *batchMarker) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
+	return b.mark(markRequest{workerID: workerID, key: key, failed: &FailedEvent{Key: key, LastError: lastErr, NextRetryAt: nextRetryAt}})
+}
+
+// mark does not give up on ctx: the outcome of finished work should be
+// written even while the pool shuts down.
+func (b *batchMarker) mark(req markRequest) error {
+	req.result = make(chan error, 1)
+	b.reqs <- req
+	return <-req.result
+}
+
+// close flushes what is queued and stops; no marks may follow.
+func (b *batchMarker) close() {
+	close(b.reqs)
+	<-b.done
+}
+
+func (b *batchMarker) run() {
+	defer close(b.done)
+	for first := range b.reqs {
+		batch := []markRequest{first}
+	collect:
+		for len(batch) < maxMarkBatch {
+			select {
+			case req, ok := <-b.reqs:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, req)
+			default:
+				break collect
+			}
+		}
+		b.flush(batch)
+	}
+}
+
+func (b *batchMarker) flush(batch []markRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer metrics.Since(metrics.MarkFlushSeconds, time.Now())
+
+	// Leases belong to one worker ID per pool, but group by it anyway.
+	type group struct {
+		processed []model.EventKey
+		failed    []FailedEvent
+	}
+	groups := map[string]*group{}
+	for _, req := range batch {
+		g := groups[req.workerID]
+		if g == nil {
+			g = &group{}
+			groups[req.workerID] = g
+		}
+		if req.failed != nil {
+			g.failed = append(g.failed, *req.failed)
+		} else {
+			g.processed = append(g.processed, req.key)
+		}
+	}
+
+	results := map[model.EventKey]error{}
+	record := func(keys, lost []model.EventKey, err error) {
+		for _, key := range keys {
+			results[key] = err
+		}
+		if err == nil {
+			for _, key := range lost {
+				results[key] = ErrLeaseLost
+			}
+		}
+	}
+	for workerID, g := range groups {
+		if len(g.processed) > 0 {
+			lost, err := b.MarkProcessedBatch(ctx, workerID, g.processed)
+			record(g.processed, lost, err)
+		}
+		if len(g.failed) > 0 {
+			keys := make([]model.EventKey, len(g.failed))
+			for i, f := range g.failed {
+				keys[i] = f.Key
+			}
+			lost, err := b.MarkFailedBatch(ctx, workerID, g.failed)
+			record(keys, lost, err)
+		}
+	}
+
+	for _, req := range batch {
+		req.result <- results[req.key]
+	}
+}
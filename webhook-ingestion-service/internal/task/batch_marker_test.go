@@ -0,0 +1,112 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+// slowBatchRepo holds the first batched write until release is closed and
+// reports "evt_lost" as lost.
+type slowBatchRepo struct {
+	memWorkerRepo
+	release chan struct{}
+
+	mu      sync.Mutex
+	batches [][]model.EventKey
+}
+
+func (s *slowBatchRepo) MarkProcessedBatch(ctx context.Context, workerID string, keys []model.EventKey) ([]model.EventKey, error) {
+	s.mu.Lock()
+	first := len(s.batches) == 0
+	s.batches = append(s.batches, keys)
+	s.mu.Unlock()
+	if first {
+		<-s.release
+	}
+	for _, key := range keys {
+		if key.ID == "evt_lost" {
+			return []model.EventKey{key}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *slowBatchRepo) MarkFailedBatch(ctx context.Context, workerID string, events []FailedEvent) ([]model.EventKey, error) {
+	return nil, errors.New("db down")
+}
+
+func TestBatchMarker_GroupsMarksDuringFlush(t *testing.T) {
+	repo := &slowBatchRepo{release: make(chan struct{})}
+	b := newBatchMarker(repo)
+
+	results := make(chan error, 1)
+	go func() { results <- b.MarkProcessed(context.Background(), model.EventKey{ID: "evt_first"}, "w") }()
+	waitFor(t, "first flush", func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return len(repo.batches) == 1
+	})
+
+	// These queue up behind the running flush.
+	ids := []string{"evt_1", "evt_2", "evt_lost"}
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = b.MarkProcessed(context.Background(), model.EventKey{ID: id}, "w")
+		}()
+	}
+	failed := make(chan error, 1)
+	go func() {
+		failed <- b.MarkFailed(context.Background(), model.EventKey{ID: "evt_failed"}, "w", "boom", time.Now())
+	}()
+	waitFor(t, "marks queued", func() bool { return len(b.reqs) == len(ids)+1 })
+	close(repo.release)
+	wg.Wait()
+
+	if err := <-results; err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.batches) != 2 || len(repo.batches[1]) != len(ids) {
+		t.Fatalf("expected the queued marks in one batch, got %v", repo.batches)
+	}
+	for i, id := range ids {
+		want := error(nil)
+		if id == "evt_lost" {
+			want = ErrLeaseLost
+		}
+		if !errors.Is(errs[i], want) {
+			t.Fatalf("%s: expected %v, got %v", id, want, errs[i])
+		}
+	}
+	if err := <-failed; err == nil || err.Error() != "db down" {
+		t.Fatalf("expected the failed batch's error, got %v", err)
+	}
+	b.close()
+}
+
+func TestBatchMarker_CloseFlushesQueued(t *testing.T) {
+	repo := &queueRepo{status: map[string]string{}}
+	b := newBatchMarker(repo)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = b.MarkProcessed(context.Background(), model.EventKey{ID: fmt.Sprintf("evt_%d", i)}, "w")
+		}()
+	}
+	wg.Wait()
+	b.close()
+	if c := repo.counts(); c["processed"] != 10 {
+		t.Fatalf("expected 10 processed, got %v", c)
+	}
+}
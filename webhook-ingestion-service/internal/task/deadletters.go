@@ -0,0 +1,132 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+var (
+	ErrEmptyDeadLetterFilter = errors.New("bulk actions need ids, type, provider or all")
+	ErrTooManyIDs            = errors.New("too many ids")
+)
+
+const (
+	DefaultDeadLetterLimit = 50
+	MaxDeadLetterLimit     = 500
+)
+
+// DeadLetterFilter selects dead-lettered events. Conditions are combined with
+// AND; All must be set to select every dead event at once.
+type DeadLetterFilter struct {
+	IDs      []string
+	Type     string
+	Provider string
+	All      bool
+}
+
+func (f DeadLetterFilter) empty() bool {
+	return len(f.IDs) == 0 && f.Type == "" && f.Provider == ""
+}
+
+type DeadLetterRepository interface {
+	// ListDead returns the newest dead events first.
+	ListDead(ctx context.Context, f DeadLetterFilter, limit int) ([]model.Event, error)
+	// RequeueDead makes the events due again with a fresh attempt budget.
+	RequeueDead(ctx context.Context, f DeadLetterFilter) (int64, error)
+	// DiscardDead marks the events discarded; they are never processed.
+	DiscardDead(ctx context.Context, f DeadLetterFilter) (int64, error)
+}
+
+type DeadLetterService struct {
+	repo DeadLetterRepository
+}
+
+func NewDeadLetterService(repo DeadLetterRepository) *DeadLetterService {
+	return &DeadLetterService{repo: repo}
+}
+
+// List clamps limit to (0, MaxDeadLetterLimit], defaulting to
+// DefaultDeadLetterLimit.
+func (s *DeadLetterService) List(ctx context.Context, f DeadLetterFilter, limit int) ([]model.Event, error) {
+	f, err := normalizeFilter(f)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultDeadLetterLimit
+	}
+	if limit > MaxDeadLetterLimit {
+		limit = MaxDeadLetterLimit
+	}
+	return s.repo.ListDead(ctx, f, limit)
+}
+
+// Get returns a dead event, including its last_error. provider may be ""
+// if no other provider used the ID; otherwise it is ErrAmbiguousEventID.
+func (s *DeadLetterService) Get(ctx context.Context, provider string, id string) (model.Event, error) {
+	f := DeadLetterFilter{IDs: []string{id}, Provider: strings.TrimSpace(provider)}
+	events, err := s.repo.ListDead(ctx, f, 2)
+	if err != nil {
+		return model.Event{}, err
+	}
+	switch len(events) {
+	case 0:
+		return model.Event{}, model.ErrNotFound
+	case 1:
+		return events[0], nil
+	default:
+		return model.Event{}, ErrAmbiguousEventID
+	}
+}
+
+// Requeue returns how many events were requeued.
+func (s *DeadLetterService) Requeue(ctx context.Context, f DeadLetterFilter) (int64, error) {
+	f, err := bulkFilter(f)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.RequeueDead(ctx, f)
+}
+
+// Discard returns how many events were discarded.
+func (s *DeadLetterService) Discard(ctx context.Context, f DeadLetterFilter) (int64, error) {
+	f, err := bulkFilter(f)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.DiscardDead(ctx, f)
+}
+
+func normalizeFilter(f DeadLetterFilter) (DeadLetterFilter, error) {
+	if len(f.IDs) > MaxDeadLetterLimit {
+		return DeadLetterFilter{}, ErrTooManyIDs
+	}
+	ids := make([]string, 0, len(f.IDs))
+	for _, id := range f.IDs {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		ids = nil
+	}
+	f.IDs = ids
+	f.Type = strings.TrimSpace(f.Type)
+	f.Provider = strings.TrimSpace(f.Provider)
+	return f, nil
+}
+
+// bulkFilter refuses to act on every dead event unless asked to explicitly.
+func bulkFilter(f DeadLetterFilter) (DeadLetterFilter, error) {
+	f, err := normalizeFilter(f)
+	if err != nil {
+		return DeadLetterFilter{}, err
+	}
+	if f.empty() && !f.All {
+		return DeadLetterFilter{}, ErrEmptyDeadLetterFilter
+	}
+	return f, nil
+}
@@ -0,0 +1,217 @@
+package task
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+var (
+	ErrInvalidEventFilter = errors.New("invalid event filter")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrEmptyReplayFilter  = errors.New("replays need a filter or all")
+)
+
+const (
+	DefaultEventLimit = 50
+	MaxEventLimit     = 500
+)
+
+// EventFilter selects events; conditions are combined with AND and zero
+// values match everything. CreatedAfter is inclusive, CreatedBefore
+// exclusive.
+type EventFilter struct {
+	IDs           []string
+	Statuses      []model.EventStatus
+	Type          string
+	Provider      string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	MinAttempts   int
+	// ErrorContains matches last_error case-insensitively.
+	ErrorContains string
+	// All must be set to replay every event at once.
+	All bool
+}
+
+func (f EventFilter) empty() bool {
+	return len(f.IDs) == 0 && len(f.Statuses) == 0 && f.Type == "" && f.Provider == "" &&
+		f.CreatedAfter.IsZero() && f.CreatedBefore.IsZero() && f.MinAttempts == 0 && f.ErrorContains == ""
+}
+
+// EventCursor is the position after the last event of a page. Events are
+// listed newest first, by created_at and then id.
+type EventCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func (c EventCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeEventCursor(s string) (EventCursor, error) {
+	var c EventCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &c) != nil || c.ID == "" || c.CreatedAt.IsZero() {
+		return EventCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+type EventPage struct {
+	Events []model.Event `json:"events"`
+	// Next is the cursor of the following page; empty on the last one.
+	Next string `json:"next_cursor,omitempty"`
+}
+
+type EventAdminRepository interface {
+	// ListEvents returns up to limit events after the cursor, newest first.
+	ListEvents(ctx context.Context, f EventFilter, after *EventCursor, limit int) ([]model.Event, error)
+	// ReplayEvents makes the events due again as received, with a fresh
+	// attempt budget. Events being processed are left alone.
+	ReplayEvents(ctx context.Context, f EventFilter) (int64, error)
+	// GetByID is EventRepository.GetByID.
+	GetByID(ctx context.Context, provider string, id string) (model.Event, error)
+	// ListAttempts returns the finished attempts of an event, oldest first.
+	ListAttempts(ctx context.Context, key model.EventKey) ([]model.EventAttempt, error)
+}
+
+type EventAdminService struct {
+	repo EventAdminRepository
+}
+
+func NewEventAdminService(repo EventAdminRepository) *EventAdminService {
+	return &EventAdminService{repo: repo}
+}
+
+// List returns a page of events. limit is clamped to (0, MaxEventLimit],
+// defaulting to DefaultEventLimit; cursor is "" for the first page.
+func (s *EventAdminService) List(ctx context.Context, f EventFilter, cursor string, limit int) (EventPage, error) {
+	f, err := normalizeEventFilter(f)
+	if err != nil {
+		return EventPage{}, err
+	}
+	var after *EventCursor
+	if cursor != "" {
+		c, err := DecodeEventCursor(cursor)
+		if err != nil {
+			return EventPage{}, err
+		}
+		after = &c
+	}
+	if limit <= 0 {
+		limit = DefaultEventLimit
+	}
+	if limit > MaxEventLimit {
+		limit = MaxEventLimit
+	}
+
+	// One extra event tells whether there is a next page.
+	events, err := s.repo.ListEvents(ctx, f, after, limit+1)
+	if err != nil {
+		return EventPage{}, err
+	}
+	page := EventPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.Next = EventCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	if page.Events == nil {
+		page.Events = []model.Event{}
+	}
+	return page, nil
+}
+
+// Replay returns how many events were reset to received.
+func (s *EventAdminService) Replay(ctx context.Context, f EventFilter) (int64, error) {
+	f, err := normalizeEventFilter(f)
+	if err != nil {
+		return 0, err
+	}
+	if f.empty() && !f.All {
+		return 0, ErrEmptyReplayFilter
+	}
+	return s.repo.ReplayEvents(ctx, f)
+}
+
+// ReplayOne resets a single event; provider may be "" if no other provider
+// used its ID. It fails with model.ErrNotFound for unknown events,
+// ErrAmbiguousEventID, and model.ErrConflict while the event is being
+// processed.
+func (s *EventAdminService) ReplayOne(ctx context.Context, provider string, id string) error {
+	ev, err := s.repo.GetByID(ctx, strings.TrimSpace(provider), strings.TrimSpace(id))
+	if err != nil {
+		return err
+	}
+	n, err := s.Replay(ctx, EventFilter{IDs: []string{ev.ID}, Provider: ev.Provider})
+	if err != nil || n > 0 {
+		return err
+	}
+	return model.ErrConflict
+}
+
+// Attempts returns the processing history of an event, looked up as in
+// ReplayOne.
+func (s *EventAdminService) Attempts(ctx context.Context, provider string, id string) ([]model.EventAttempt, error) {
+	ev, err := s.repo.GetByID(ctx, strings.TrimSpace(provider), strings.TrimSpace(id))
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, ev.Key())
+	if err != nil {
+		return nil, err
+	}
+	if attempts == nil {
+		attempts = []model.EventAttempt{}
+	}
+	return attempts, nil
+}
+
+func normalizeEventFilter(f EventFilter) (EventFilter, error) {
+	if len(f.IDs) > MaxEventLimit {
+		return EventFilter{}, ErrTooManyIDs
+	}
+	ids := make([]string, 0, len(f.IDs))
+	for _, id := range f.IDs {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		ids = nil
+	}
+	f.IDs = ids
+	for _, st := range f.Statuses {
+		if !validStatus(st) {
+			return EventFilter{}, fmt.Errorf("%w: unknown status %q", ErrInvalidEventFilter, st)
+		}
+	}
+	if f.MinAttempts < 0 {
+		return EventFilter{}, fmt.Errorf("%w: min_attempts must not be negative", ErrInvalidEventFilter)
+	}
+	if !f.CreatedAfter.IsZero() && !f.CreatedBefore.IsZero() && !f.CreatedAfter.Before(f.CreatedBefore) {
+		return EventFilter{}, fmt.Errorf("%w: created_after must be before created_before", ErrInvalidEventFilter)
+	}
+	f.Type = strings.TrimSpace(f.Type)
+	f.Provider = strings.TrimSpace(f.Provider)
+	f.ErrorContains = strings.TrimSpace(f.ErrorContains)
+	return f, nil
+}
+
+func validStatus(st model.EventStatus) bool {
+	switch st {
+	case model.StatusReceived, model.StatusProcessing, model.StatusProcessed,
+		model.StatusFailed, model.StatusDead, model.StatusDiscarded, model.StatusQuarantined:
+		return true
+	}
+	return false
+}
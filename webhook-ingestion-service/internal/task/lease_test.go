@@ -0,0 +1,101 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+// leaseRepo hands out one event and records the lease calls.
+type leaseRepo struct {
+	memWorkerRepo
+	lost bool // ExtendLease reports the lease as lost
+
+	mu      sync.Mutex
+	extends int
+	marked  bool
+}
+
+func (l *leaseRepo) ExtendLease(ctx context.Context, key model.EventKey, workerID string, lease time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.extends++
+	if l.lost {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (l *leaseRepo) MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error {
+	l.marked = true
+	return nil
+}
+
+func (l *leaseRepo) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
+	l.marked = true
+	return nil
+}
+
+func TestProcessOnce_HeartbeatKeepsLease(t *testing.T) {
+	repo := &leaseRepo{memWorkerRepo: memWorkerRepo{ev: ClaimedEvent{ID: "evt_1", Type: "slow"}}}
+	slow := ProcessorFunc(func(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	deps := WorkerDeps{Repo: repo, Processor: slow, WorkerID: "w1", Lease: 30 * time.Millisecond}
+
+	if _, err := ProcessOnce(context.Background(), deps); err != nil {
+		t.Fatal(err)
+	}
+	if repo.extends < 2 || !repo.marked {
+		t.Fatalf("expected heartbeats and a processed mark, got extends=%d marked=%v", repo.extends, repo.marked)
+	}
+}
+
+func TestProcessOnce_LostLeaseCancelsProcessing(t *testing.T) {
+	repo := &leaseRepo{memWorkerRepo: memWorkerRepo{ev: ClaimedEvent{ID: "evt_1", Type: "slow"}}, lost: true}
+	blocking := ProcessorFunc(func(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	deps := WorkerDeps{Repo: repo, Processor: blocking, WorkerID: "w1", Lease: 15 * time.Millisecond}
+
+	claimed, err := ProcessOnce(context.Background(), deps)
+	if !claimed || !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost, got claimed=%v err=%v", claimed, err)
+	}
+	if repo.marked {
+		t.Fatal("an event whose lease was lost must not be marked")
+	}
+}
+
+func TestProcessOnce_DeadLettersUnfinishedLastAttempt(t *testing.T) {
+	// Attempt 3 of 3 was claimed, the worker died and the reaper released
+	// the event; the next claim is attempt 4.
+	repo := &memWorkerRepo{ev: ClaimedEvent{ID: "evt_1", Type: "crashy", Attempts: 3}}
+	called := false
+	deps := WorkerDeps{
+		Repo: repo,
+		Processor: ProcessorFunc(func(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+			called = true
+			return nil
+		}),
+		Retry: RetryPolicy{MaxAttempts: 3},
+	}
+
+	if _, err := ProcessOnce(context.Background(), deps); !errors.Is(err, ErrDeadLettered) {
+		t.Fatalf("expected ErrDeadLettered, got %v", err)
+	}
+	if called || !repo.dead {
+		t.Fatalf("expected dead letter without processing, called=%v dead=%v", called, repo.dead)
+	}
+}
@@ -0,0 +1,44 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// OrderingKeys maps an event type to the dot-separated JSON path of its
+// ordering key in the payload, e.g. "data.object.payment_intent". Events of
+// one provider sharing a key are processed one at a time, oldest first, and
+// an event waits while an earlier one with its key is failed or dead.
+type OrderingKeys map[string]string
+
+// Key returns the ordering key of an event, or "" if its type has no path
+// or the payload holds no string or number there.
+func (k OrderingKeys) Key(eventType string, payload []byte) string {
+	path, ok := k[eventType]
+	if !ok || path == "" {
+		return ""
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return ""
+	}
+	for _, field := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		if v, ok = obj[field]; !ok {
+			return ""
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package task
+
+import "testing"
+
+func TestOrderingKeys_Key(t *testing.T) {
+	keys := OrderingKeys{
+		"payment.refunded": "data.object.payment_intent",
+		"invoice.paid":     "invoice_id",
+	}
+	tests := []struct {
+		name      string
+		eventType string
+		payload   string
+		want      string
+	}{
+		{"nested string", "payment.refunded", `{"data":{"object":{"payment_intent":"pi_1"}}}`, "pi_1"},
+		{"number keeps its digits", "invoice.paid", `{"invoice_id":12345678901234567890}`, "12345678901234567890"},
+		{"type without path", "payment.succeeded", `{"data":{"object":{"payment_intent":"pi_1"}}}`, ""},
+		{"missing field", "payment.refunded", `{"data":{"object":{}}}`, ""},
+		{"path through a non-object", "payment.refunded", `{"data":["object"]}`, ""},
+		{"object value", "invoice.paid", `{"invoice_id":{"id":"in_1"}}`, ""},
+		{"null value", "invoice.paid", `{"invoice_id":null}`, ""},
+		{"invalid json", "invoice.paid", `{`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keys.Key(tt.eventType, []byte(tt.payload)); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	var none OrderingKeys
+	if got := none.Key("invoice.paid", []byte(`{"invoice_id":"in_1"}`)); got != "" {
+		t.Fatalf("nil keys: expected no key, got %q", got)
+	}
+}
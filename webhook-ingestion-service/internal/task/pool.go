@@ -0,0 +1,328 @@
+package task
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/logging"
+	"webhook-ingestion-service/internal/observability/metrics"
+)
+
+type PoolConfig struct {
+	Workers      int            // events processed at once (e.g. 4)
+	PollInterval time.Duration  // wait before polling again when nothing is due
+	TypeLimits   map[string]int // max events of a type in flight; others keep flowing
+	DrainTimeout time.Duration  // how long shutdown waits for in-flight events
+	// Wakeups, when set, signals new work (e.g. a Postgres LISTEN). Polling
+	// remains the fallback for retries whose next_retry_at comes due.
+	Wakeups <-chan struct{}
+}
+
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		Workers:      4,
+		PollInterval: 500 * time.Millisecond,
+		DrainTimeout: 10 * time.Second,
+	}
+}
+
+// PoolStats is a snapshot of a pool's activity since it started.
+type PoolStats struct {
+	Workers int `json:"workers"`
+	Busy    int `json:"busy"`
+	// Utilization is the share of worker time spent processing, 0..1.
+	Utilization  float64        `json:"utilization"`
+	InFlight     map[string]int `json:"in_flight"`
+	Processed    uint64         `json:"processed"`
+	Failed       uint64         `json:"failed"`
+	DeadLettered uint64         `json:"dead_lettered"`
+	LeaseLost    uint64         `json:"lease_lost"`
+	Released     uint64         `json:"released"`
+}
+
+// Pool runs up to Workers events at once. A single dispatcher claims events
+// in batches for idle workers, leaving types at their TypeLimits in the
+// queue, so one slow type cannot occupy every worker. Outcomes are written in
+// batches too.
+type Pool struct {
+	deps   WorkerDeps
+	cfg    PoolConfig
+	logger *slog.Logger
+
+	wake chan struct{} // a worker finished; there may be room again
+
+	mu       sync.Mutex
+	started  time.Time
+	running  map[model.EventKey]inFlight // by event
+	inFlight map[string]int              // by event type
+	busyTime time.Duration               // finished processing time
+	stats    PoolStats
+}
+
+type inFlight struct {
+	eventType string
+	since     time.Time
+}
+
+func NewPool(deps WorkerDeps, cfg PoolConfig, logger *slog.Logger) *Pool {
+	def := DefaultPoolConfig()
+	if cfg.Workers <= 0 {
+		cfg.Workers = def.Workers
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = def.PollInterval
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = def.DrainTimeout
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Pool{
+		deps:     deps.withDefaults(),
+		cfg:      cfg,
+		logger:   logger,
+		wake:     make(chan struct{}, 1),
+		running:  map[model.EventKey]inFlight{},
+		inFlight: map[string]int{},
+	}
+}
+
+// Run processes events until ctx is canceled, then drains: in-flight events
+// get up to DrainTimeout to finish, after which their processing is canceled
+// and their leases released so another instance picks them up right away.
+func (p *Pool) Run(ctx context.Context) {
+	p.mu.Lock()
+	p.started = time.Now()
+	p.mu.Unlock()
+
+	// Processing outlives ctx until the drain deadline.
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	marker := newBatchMarker(p.deps.Repo)
+	deps := p.deps
+	deps.Repo = marker
+
+	jobs := make(chan ClaimedEvent)
+	idle := make(chan struct{}, p.cfg.Workers)
+
+	var wg sync.WaitGroup
+	wg.Add(p.cfg.Workers)
+	for i := 0; i < p.cfg.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			for ev := range jobs {
+				p.handle(workCtx, deps, ev)
+				idle <- struct{}{}
+			}
+		}()
+	}
+
+	p.logger.InfoContext(ctx, "worker pool started", "workers", p.cfg.Workers, "worker_id", p.deps.WorkerID)
+	p.dispatch(ctx, jobs, idle)
+	close(jobs)
+
+	p.logger.InfoContext(ctx, "worker pool draining", "in_flight", p.Stats().Busy)
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(p.cfg.DrainTimeout):
+		p.logger.WarnContext(ctx, "worker pool: drain timeout, releasing unfinished events", "in_flight", p.Stats().Busy)
+		cancelWork()
+		<-drained
+	}
+	marker.close()
+	p.logger.InfoContext(ctx, "worker pool stopped", "cause", ctx.Err())
+}
+
+// dispatch claims a batch for all idle workers at once.
+func (p *Pool) dispatch(ctx context.Context, jobs chan<- ClaimedEvent, idle <-chan struct{}) {
+	free := p.cfg.Workers
+	for {
+		if free == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-idle:
+				free++
+			}
+		}
+		free += drainIdle(idle)
+
+		events := p.claim(ctx, free)
+		if len(events) == 0 {
+			if ctx.Err() != nil {
+				return
+			}
+			// Wait for new work or a finished worker, which may lift a
+			// type limit.
+			p.wait(ctx)
+			continue
+		}
+		for _, ev := range events {
+			jobs <- ev
+			free--
+		}
+	}
+}
+
+func drainIdle(idle <-chan struct{}) int {
+	n := 0
+	for {
+		select {
+		case <-idle:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+// claim claims up to n events, no more of a type than its limit has room
+// for. Only the dispatcher adds to inFlight, so that room cannot shrink
+// before the claimed events are counted.
+func (p *Pool) claim(ctx context.Context, n int) []ClaimedEvent {
+	p.mu.Lock()
+	skip, caps := p.roomLocked()
+	c := Claim{WorkerID: p.deps.WorkerID, Lease: p.deps.Lease, SkipTypes: skip, TypeCaps: caps}
+	p.mu.Unlock()
+
+	start := time.Now()
+	events, err := p.deps.Repo.ClaimBatch(ctx, c, n)
+	if err == nil {
+		metrics.Since(metrics.ClaimSeconds, start)
+		metrics.ClaimBatchSize.Observe(float64(len(events)))
+		traceClaim(ctx, start, events)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.ErrorContext(ctx, "worker pool: claim", logging.KeyError, err)
+		}
+		return nil
+	}
+
+	p.mu.Lock()
+	for _, ev := range events {
+		p.running[ev.Key()] = inFlight{eventType: ev.Type, since: time.Now()}
+		p.inFlight[ev.Type]++
+	}
+	p.mu.Unlock()
+	return events
+}
+
+func (p *Pool) wait(ctx context.Context) {
+	defer metrics.Since(metrics.IdleSeconds, time.Now())
+	timer := time.NewTimer(p.cfg.PollInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-p.wake:
+	case <-p.cfg.Wakeups:
+	case <-timer.C:
+	}
+}
+
+func (p *Pool) handle(ctx context.Context, deps WorkerDeps, ev ClaimedEvent) {
+	ctx = eventContext(ctx, ev)
+	start := time.Now()
+	err := handleClaimed(ctx, deps, ev)
+	outcome := outcomeOf(ctx, err)
+
+	p.mu.Lock()
+	started := p.running[ev.Key()].since
+	delete(p.running, ev.Key())
+	if p.inFlight[ev.Type]--; p.inFlight[ev.Type] <= 0 {
+		delete(p.inFlight, ev.Type)
+	}
+	p.busyTime += time.Since(started)
+	switch outcome {
+	case outcomeProcessed:
+		p.stats.Processed++
+	case outcomeDead:
+		p.stats.DeadLettered++
+	case outcomeLeaseLost:
+		p.stats.LeaseLost++
+	case outcomeReleased:
+		p.stats.Released++
+	default:
+		p.stats.Failed++
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	logOutcome(ctx, p.logger, outcome, time.Since(start), err)
+}
+
+// logOutcome logs how an attempt ended. Only processed events are logged at
+// Info, where sampling applies; every other outcome is logged.
+func logOutcome(ctx context.Context, logger *slog.Logger, outcome string, dur time.Duration, err error) {
+	level := slog.LevelWarn
+	switch outcome {
+	case outcomeProcessed:
+		level = slog.LevelInfo
+	case outcomeDead:
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{slog.String("outcome", outcome), slog.Duration("dur", dur)}
+	if err != nil {
+		attrs = append(attrs, slog.String(logging.KeyError, err.Error()))
+	}
+	logger.LogAttrs(ctx, level, "event "+outcome, attrs...)
+}
+
+// roomLocked returns the types at their in-flight limit, sorted, and how
+// many more events each of the other limited types may take.
+func (p *Pool) roomLocked() (saturated []string, room map[string]int) {
+	for eventType, limit := range p.cfg.TypeLimits {
+		if limit <= 0 {
+			continue
+		}
+		if n := limit - p.inFlight[eventType]; n > 0 {
+			if room == nil {
+				room = map[string]int{}
+			}
+			room[eventType] = n
+		} else {
+			saturated = append(saturated, eventType)
+		}
+	}
+	sort.Strings(saturated)
+	return saturated, room
+}
+
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.stats
+	st.Workers = p.cfg.Workers
+	st.Busy = len(p.running)
+	st.InFlight = make(map[string]int, len(p.inFlight))
+	for eventType, n := range p.inFlight {
+		st.InFlight[eventType] = n
+	}
+
+	now := time.Now()
+	busy := p.busyTime
+	for _, r := range p.running {
+		busy += now.Sub(r.since)
+	}
+	if !p.started.IsZero() {
+		if total := now.Sub(p.started) * time.Duration(p.cfg.Workers); total > 0 {
+			st.Utilization = min(float64(busy)/float64(total), 1)
+		}
+	}
+	return st
+}
@@ -0,0 +1,289 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/logging"
+)
+
+// queueRepo is an in-memory queue honouring Claim.SkipTypes and
+// Claim.TypeCaps.
+type queueRepo struct {
+	mu       sync.Mutex
+	events   []ClaimedEvent
+	status   map[string]string
+	batches  []int // sizes of MarkProcessedBatch calls
+	released int   // ReleaseLease calls
+}
+
+func newQueueRepo(types ...string) *queueRepo {
+	q := &queueRepo{status: map[string]string{}}
+	for i, eventType := range types {
+		id := fmt.Sprintf("evt_%d", i)
+		q.events = append(q.events, ClaimedEvent{ID: id, Type: eventType})
+		q.status[id] = "due"
+	}
+	return q
+}
+
+func (q *queueRepo) add(id, eventType string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.events = append(q.events, ClaimedEvent{ID: id, Type: eventType})
+	q.status[id] = "due"
+}
+
+func (q *queueRepo) ClaimNextDue(ctx context.Context, c Claim) (ClaimedEvent, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, ev := range q.events {
+		if q.status[ev.ID] != "due" || slices.Contains(c.SkipTypes, ev.Type) {
+			continue
+		}
+		q.status[ev.ID] = "processing"
+		q.events[i].Attempts++
+		return q.events[i], true, nil
+	}
+	return ClaimedEvent{}, false, nil
+}
+
+func (q *queueRepo) ClaimBatch(ctx context.Context, c Claim, n int) ([]ClaimedEvent, error) {
+	c.SkipTypes = slices.Clone(c.SkipTypes)
+	taken := map[string]int{}
+	var out []ClaimedEvent
+	for len(out) < n {
+		ev, ok, _ := q.ClaimNextDue(ctx, c)
+		if !ok {
+			break
+		}
+		out = append(out, ev)
+		taken[ev.Type]++
+		if limit, ok := c.TypeCaps[ev.Type]; ok && taken[ev.Type] >= limit {
+			c.SkipTypes = append(c.SkipTypes, ev.Type)
+		}
+	}
+	return out, nil
+}
+
+func (q *queueRepo) MarkProcessedBatch(ctx context.Context, workerID string, keys []model.EventKey) ([]model.EventKey, error) {
+	q.mu.Lock()
+	q.batches = append(q.batches, len(keys))
+	q.mu.Unlock()
+	for _, key := range keys {
+		q.set(key.ID, "processed")
+	}
+	return nil, nil
+}
+
+func (q *queueRepo) MarkFailedBatch(ctx context.Context, workerID string, events []FailedEvent) ([]model.EventKey, error) {
+	for _, e := range events {
+		q.set(e.Key.ID, "failed")
+	}
+	return nil, nil
+}
+
+func (q *queueRepo) set(id, status string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.status[id] = status
+	return nil
+}
+
+func (q *queueRepo) counts() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := map[string]int{}
+	for _, st := range q.status {
+		out[st]++
+	}
+	return out
+}
+
+func (q *queueRepo) ExtendLease(ctx context.Context, key model.EventKey, workerID string, lease time.Duration) error {
+	return nil
+}
+
+func (q *queueRepo) MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error {
+	return q.set(key.ID, "processed")
+}
+
+func (q *queueRepo) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
+	return q.set(key.ID, "failed")
+}
+
+func (q *queueRepo) MarkDead(ctx context.Context, key model.EventKey, workerID string, lastErr string) error {
+	return q.set(key.ID, "dead")
+}
+
+func (q *queueRepo) ReleaseLease(ctx context.Context, key model.EventKey, workerID string) error {
+	q.mu.Lock()
+	q.released++
+	q.mu.Unlock()
+	return q.set(key.ID, "due")
+}
+
+// gauge tracks how many calls run at once, per event type.
+type gauge struct {
+	mu      sync.Mutex
+	running map[string]int
+	peak    map[string]int
+	order   []string
+}
+
+func newGauge() *gauge {
+	return &gauge{running: map[string]int{}, peak: map[string]int{}}
+}
+
+func (g *gauge) processor(d func(eventType string) time.Duration) Processor {
+	return ProcessorFunc(func(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+		g.mu.Lock()
+		g.running[eventType]++
+		g.running["*"]++
+		g.peak[eventType] = max(g.peak[eventType], g.running[eventType])
+		g.peak["*"] = max(g.peak["*"], g.running["*"])
+		g.mu.Unlock()
+		defer func() {
+			g.mu.Lock()
+			g.running[eventType]--
+			g.running["*"]--
+			g.order = append(g.order, eventType)
+			g.mu.Unlock()
+		}()
+
+		select {
+		case <-time.After(d(eventType)):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func runPool(t *testing.T, repo *queueRepo, p Processor, cfg PoolConfig) (*Pool, context.CancelFunc, <-chan struct{}) {
+	t.Helper()
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 5 * time.Millisecond
+	}
+	pool := NewPool(WorkerDeps{Repo: repo, Processor: p, WorkerID: "test"}, cfg, logging.Discard())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.Run(ctx)
+	}()
+	return pool, cancel, done
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestPool_ProcessesConcurrently(t *testing.T) {
+	repo := newQueueRepo("a", "a", "a", "a", "a", "a", "a", "a")
+	g := newGauge()
+	pool, cancel, done := runPool(t, repo, g.processor(func(string) time.Duration { return 30 * time.Millisecond }), PoolConfig{Workers: 4})
+
+	waitFor(t, "all processed", func() bool { return repo.counts()["processed"] == 8 })
+	cancel()
+	<-done
+
+	if g.peak["*"] != 4 {
+		t.Fatalf("expected 4 events in parallel, peak was %d", g.peak["*"])
+	}
+	st := pool.Stats()
+	if st.Processed != 8 || st.Busy != 0 || st.Workers != 4 || st.Utilization <= 0 || st.Utilization > 1 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestPool_TypeLimitsKeepOtherTypesFlowing(t *testing.T) {
+	repo := newQueueRepo("slow", "slow", "slow", "slow", "fast", "fast", "fast")
+	g := newGauge()
+	durations := func(eventType string) time.Duration {
+		if eventType == "slow" {
+			return 40 * time.Millisecond
+		}
+		return time.Millisecond
+	}
+	_, cancel, done := runPool(t, repo, g.processor(durations), PoolConfig{Workers: 3, TypeLimits: map[string]int{"slow": 1}})
+
+	waitFor(t, "all processed", func() bool { return repo.counts()["processed"] == 7 })
+	cancel()
+	<-done
+
+	if g.peak["slow"] != 1 {
+		t.Fatalf("expected at most 1 slow event in flight, peak was %d", g.peak["slow"])
+	}
+	// Events over the limit are never claimed, so none is handed back.
+	if repo.released != 0 {
+		t.Fatalf("expected no released leases, got %d", repo.released)
+	}
+	// The fast events are done before even the first slow one.
+	if !slices.Equal(g.order[:3], []string{"fast", "fast", "fast"}) {
+		t.Fatalf("fast events were stalled behind slow ones: %v", g.order)
+	}
+}
+
+func TestPool_DrainFinishesInFlightWork(t *testing.T) {
+	repo := newQueueRepo("a", "a")
+	g := newGauge()
+	_, cancel, done := runPool(t, repo, g.processor(func(string) time.Duration { return 50 * time.Millisecond }), PoolConfig{Workers: 2, DrainTimeout: time.Second})
+
+	waitFor(t, "both in flight", func() bool { return repo.counts()["processing"] == 2 })
+	cancel()
+	<-done
+
+	if c := repo.counts(); c["processed"] != 2 {
+		t.Fatalf("expected in-flight events to finish during drain, got %v", c)
+	}
+}
+
+func TestPool_DrainTimeoutReleasesLeases(t *testing.T) {
+	repo := newQueueRepo("a", "a", "a")
+	g := newGauge()
+	pool, cancel, done := runPool(t, repo, g.processor(func(string) time.Duration { return time.Hour }), PoolConfig{Workers: 2, DrainTimeout: 20 * time.Millisecond})
+
+	waitFor(t, "two in flight", func() bool { return repo.counts()["processing"] == 2 })
+	cancel()
+	<-done
+
+	if c := repo.counts(); c["due"] != 3 {
+		t.Fatalf("expected every event back in the queue, got %v", c)
+	}
+	if st := pool.Stats(); st.Released != 2 || st.Busy != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestPool_WakesOnNotification(t *testing.T) {
+	repo := newQueueRepo()
+	wakeups := make(chan struct{}, 1)
+	g := newGauge()
+	_, cancel, done := runPool(t, repo, g.processor(func(string) time.Duration { return 0 }),
+		PoolConfig{Workers: 2, PollInterval: time.Hour, Wakeups: wakeups})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Let the dispatcher find the queue empty and go to sleep.
+	time.Sleep(20 * time.Millisecond)
+	repo.add("evt_new", "a")
+	wakeups <- struct{}{}
+
+	waitFor(t, "event processed without polling", func() bool { return repo.counts()["processed"] == 1 })
+}
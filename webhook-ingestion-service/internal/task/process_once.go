@@ -3,44 +3,198 @@ package task
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"webhook-ingestion-service/internal/observability/logging"
+	"webhook-ingestion-service/internal/observability/metrics"
+	"webhook-ingestion-service/internal/observability/tracing"
 )
 
 var ErrNoWork = errors.New("no due events")
 
+// DefaultLease is how long a claimed event belongs to its worker without a
+// heartbeat.
+const DefaultLease = 30 * time.Second
+
 type WorkerDeps struct {
 	Repo      WorkerRepository
 	Processor Processor
 	Backoff   BackoffConfig
+	Retry     RetryPolicy
 	RNG       *rand.Rand
 	Now       func() time.Time
+	// WorkerID identifies this worker in leases; see NewWorkerID.
+	WorkerID string
+	// Lease is extended every Lease/3 while the event is processed.
+	Lease time.Duration
 }
 
-func ProcessOnce(ctx context.Context, deps WorkerDeps) (bool, error) {
+func (deps WorkerDeps) withDefaults() WorkerDeps {
 	if deps.Now == nil {
 		deps.Now = func() time.Time { return time.Now().UTC() }
 	}
 	if deps.Backoff.BaseDelay == 0 && deps.Backoff.MaxDelay == 0 {
 		deps.Backoff = DefaultBackoff()
 	}
+	if deps.Lease <= 0 {
+		deps.Lease = DefaultLease
+	}
+	if deps.WorkerID == "" {
+		deps.WorkerID = NewWorkerID()
+	}
+	return deps
+}
 
-	ev, ok, err := deps.Repo.ClaimNextDue(ctx)
+func ProcessOnce(ctx context.Context, deps WorkerDeps) (bool, error) {
+	deps = deps.withDefaults()
+
+	start := time.Now()
+	ev, ok, err := deps.Repo.ClaimNextDue(ctx, Claim{WorkerID: deps.WorkerID, Lease: deps.Lease})
 	if err != nil {
 		return false, err
 	}
+	metrics.Since(metrics.ClaimSeconds, start)
 	if !ok {
+		metrics.ClaimBatchSize.Observe(0)
 		return false, ErrNoWork
 	}
+	metrics.ClaimBatchSize.Observe(1)
+	claimed := []ClaimedEvent{ev}
+	traceClaim(ctx, start, claimed)
+	return true, handleClaimed(eventContext(ctx, claimed[0]), deps, claimed[0])
+}
 
-	if err := deps.Processor.Process(ctx, ev.ID, ev.Type, ev.Payload); err != nil {
-		next := NextRetryAt(deps.Now(), ev.Attempts, deps.Backoff, deps.RNG)
-		_ = deps.Repo.MarkFailed(ctx, ev.ID, err.Error(), next)
-		return true, err
+// Outcomes of handleClaimed, as reported by outcomeOf.
+const (
+	outcomeProcessed = "processed"
+	outcomeFailed    = "failed"
+	outcomeDead      = "dead"
+	outcomeLeaseLost = "lease_lost"
+	outcomeReleased  = "released"
+)
+
+// outcomeOf classifies the error handleClaimed returned under ctx.
+func outcomeOf(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return outcomeProcessed
+	case errors.Is(err, ErrDeadLettered):
+		return outcomeDead
+	case errors.Is(err, ErrLeaseLost):
+		return outcomeLeaseLost
+	case ctx.Err() != nil:
+		return outcomeReleased
+	default:
+		return outcomeFailed
 	}
+}
+
+// handleClaimed processes an event leased to deps.WorkerID and records the
+// outcome. If ctx is canceled, e.g. on shutdown, the lease is released
+// without counting the attempt and ctx's error returned.
+func handleClaimed(ctx context.Context, deps WorkerDeps, ev ClaimedEvent) (err error) {
+	spanCtx, span := startProcess(ctx, ev)
+	defer func(start time.Time) {
+		outcome := outcomeOf(ctx, err)
+		metrics.ProcessingSeconds.WithLabelValues(ev.Type, outcome).Observe(time.Since(start).Seconds())
+		metrics.Attempts.WithLabelValues(ev.Type, outcome).Observe(float64(ev.Attempts))
+		span.SetAttributes(attribute.String("webhook.event.outcome", outcome))
+		tracing.End(span, err)
+	}(time.Now())
+	ctx = spanCtx
+	deps.Repo = tracedMarks{deps.Repo}
+
+	// The previous attempt never finished, e.g. the worker crashed and the
+	// reaper released the event.
+	if deps.Retry.exceeded(ev.Type, ev.Attempts) {
+		if err := deps.Repo.MarkDead(ctx, ev.Key(), deps.WorkerID, "attempts exhausted: the last attempt did not finish"); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w after %d unfinished attempts", ErrDeadLettered, ev.Attempts-1)
+	}
+
+	if err := process(ctx, deps, ev); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			return err
+		}
+		if ctx.Err() != nil {
+			releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			return errors.Join(ctx.Err(), deps.Repo.ReleaseLease(releaseCtx, ev.Key(), deps.WorkerID))
+		}
+		if errors.Is(err, ErrPermanent) || deps.Retry.exhausted(ev.Type, ev.Attempts) {
+			if markErr := deps.Repo.MarkDead(ctx, ev.Key(), deps.WorkerID, err.Error()); markErr != nil {
+				return errors.Join(err, markErr)
+			}
+			return fmt.Errorf("%w after %d attempts: %w", ErrDeadLettered, ev.Attempts, err)
+		}
+		backoff := deps.Backoff
+		if tb, ok := deps.Processor.(TypeBackoff); ok {
+			if b, ok := tb.BackoffFor(ev.Type); ok {
+				backoff = b
+			}
+		}
+		next := NextRetryAt(deps.Now(), ev.Attempts, backoff, deps.RNG)
+		if markErr := deps.Repo.MarkFailed(ctx, ev.Key(), deps.WorkerID, err.Error(), next); errors.Is(markErr, ErrLeaseLost) {
+			return errors.Join(err, markErr)
+		}
+		return err
+	}
+
+	return deps.Repo.MarkProcessed(ctx, ev.Key(), deps.WorkerID)
+}
+
+// process runs the processor while a heartbeat keeps the lease alive. If the
+// lease is lost anyway, processing is canceled and ErrLeaseLost returned:
+// the event is someone else's now.
+func process(ctx context.Context, deps WorkerDeps, ev ClaimedEvent) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(max(deps.Lease/3, time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Other errors are transient; the next beat retries before
+				// the lease runs out.
+				if err := deps.Repo.ExtendLease(ctx, ev.Key(), deps.WorkerID, deps.Lease); errors.Is(err, ErrLeaseLost) {
+					cancel(ErrLeaseLost)
+					return
+				}
+			}
+		}
+	}()
+
+	err := traced(ctx, "event.processor", func(ctx context.Context) error {
+		return deps.Processor.Process(ctx, ev.ID, ev.Type, ev.Payload)
+	})
+	close(done)
+	<-stopped
+	if errors.Is(context.Cause(ctx), ErrLeaseLost) {
+		return ErrLeaseLost
+	}
+	return err
+}
 
-	if err := deps.Repo.MarkProcessed(ctx, ev.ID); err != nil {
-		return true, err
+// eventContext adds the event to the logging attributes of ctx, so that
+// processors logging with it name the event.
+func eventContext(ctx context.Context, ev ClaimedEvent) context.Context {
+	args := []any{logging.KeyProvider, ev.Provider, logging.KeyEventID, ev.ID, logging.KeyEventType, ev.Type, logging.KeyAttempt, ev.Attempts}
+	if ev.Origin.RequestID != "" {
+		args = append(args, logging.KeyRequestID, ev.Origin.RequestID)
 	}
-	return true, nil
+	return logging.With(ctx, args...)
 }
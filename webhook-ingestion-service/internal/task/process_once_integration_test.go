@@ -12,6 +12,7 @@ import (
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 
+	"webhook-ingestion-service/internal/model"
 	pg "webhook-ingestion-service/internal/store/postgres"
 	"webhook-ingestion-service/internal/task"
 )
@@ -47,7 +48,7 @@ func TestProcessOnce_FailsTwiceThenProcesses(t *testing.T) {
 	id := "evt_process_once_" + time.Now().UTC().Format("20060102_150405.000000")
 
 	payload := json.RawMessage(`{"type":"payment_succeeded","data":{"x":1}}`)
-	created, err := repo.InsertReceived(ctx, id, "payment_succeeded", payload)
+	created, err := repo.InsertReceived(ctx, "provider", id, "payment_succeeded", "", payload, model.Origin{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -76,7 +77,7 @@ func TestProcessOnce_FailsTwiceThenProcesses(t *testing.T) {
 		t.Fatalf("expected error on first attempt")
 	}
 
-	st, attempts, err2 := repo.GetStatus(ctx, id)
+	st, attempts, err2 := repo.GetStatus(ctx, model.EventKey{Provider: "provider", ID: id})
 	if err2 != nil {
 		t.Fatal(err2)
 	}
@@ -99,7 +100,7 @@ func TestProcessOnce_FailsTwiceThenProcesses(t *testing.T) {
 		t.Fatalf("expected error on second attempt")
 	}
 
-	st, attempts, err2 = repo.GetStatus(ctx, id)
+	st, attempts, err2 = repo.GetStatus(ctx, model.EventKey{Provider: "provider", ID: id})
 	if err2 != nil {
 		t.Fatal(err2)
 	}
@@ -122,7 +123,7 @@ func TestProcessOnce_FailsTwiceThenProcesses(t *testing.T) {
 	}
 
 	// verify processed
-	ev, err := repo.GetByID(ctx, id)
+	ev, err := repo.GetByID(ctx, "provider", id)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"webhook-ingestion-service/internal/observability/logging"
+)
+
+// LeaseReaper returns events whose lease expired to the queue.
+type LeaseReaper interface {
+	ReleaseExpired(ctx context.Context) (int64, error)
+}
+
+// NewWorkerID returns "<hostname>-<pid>-<random>", unique per process.
+func NewWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "worker"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// RunReaper releases expired leases every interval until ctx is canceled.
+func RunReaper(ctx context.Context, reaper LeaseReaper, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		interval = DefaultLease / 2
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := reaper.ReleaseExpired(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.ErrorContext(ctx, "reaper: release expired leases", logging.KeyError, err)
+				}
+				continue
+			}
+			if n > 0 {
+				logger.WarnContext(ctx, "reaper: returned events with expired leases to the queue", "events", n)
+			}
+		}
+	}
+}
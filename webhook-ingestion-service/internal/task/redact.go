@@ -0,0 +1,109 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Redacted replaces the value of a redacted payload field.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields are hidden wherever they appear in a payload. The
+// "*_" entries catch names such as Stripe's client_secret or a
+// signing_key that no list spells out.
+var DefaultRedactFields = []string{
+	"password", "secret", "token", "access_token", "refresh_token", "api_key",
+	"x-api-key", "client_secret", "webhook_secret", "private_key",
+	"authorization", "card_number", "cvc", "cvv", "ssn",
+	"*_secret", "*_token", "*_key",
+}
+
+// Redaction says which payload fields are hidden when a payload is shown.
+// Fields match object keys at any depth, ignoring case and treating "-" as
+// "_"; a field starting with "*" matches every key ending in the rest.
+// Paths are dot-separated from the root, e.g.
+// "data.object.billing_details.email", and pass through arrays without an
+// index.
+type Redaction struct {
+	Fields []string
+	Paths  []string
+}
+
+// Apply returns payload with the values of matching fields replaced by
+// Redacted. Objects come back with their keys sorted.
+func (rd Redaction) Apply(payload []byte) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	fields := fieldMatcher{names: make(map[string]bool, len(rd.Fields))}
+	for _, f := range rd.Fields {
+		if suffix, ok := strings.CutPrefix(f, "*"); ok {
+			fields.suffixes = append(fields.suffixes, normalizeField(suffix))
+			continue
+		}
+		fields.names[normalizeField(f)] = true
+	}
+	paths := make(map[string]bool, len(rd.Paths))
+	for _, p := range rd.Paths {
+		paths[p] = true
+	}
+	v = redact(v, "", fields, paths)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// fieldMatcher matches object keys against Redaction.Fields.
+type fieldMatcher struct {
+	names    map[string]bool
+	suffixes []string
+}
+
+func (m fieldMatcher) match(key string) bool {
+	key = normalizeField(key)
+	if m.names[key] {
+		return true
+	}
+	for _, suffix := range m.suffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeField(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+func redact(v any, path string, fields fieldMatcher, paths map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			if fields.match(k) || paths[p] {
+				v[k] = Redacted
+				continue
+			}
+			v[k] = redact(child, p, fields, paths)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redact(child, path, fields, paths)
+		}
+	}
+	return v
+}
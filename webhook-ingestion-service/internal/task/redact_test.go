@@ -0,0 +1,111 @@
+package task
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedaction_Apply(t *testing.T) {
+	rd := Redaction{Fields: []string{"password", "API_KEY", "*_PIN"}, Paths: []string{"data.object.email", "items.owner"}}
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"field at any depth, any case", `{"Password":"x","a":{"b":{"api_key":"k"}}}`, `{"Password":"[REDACTED]","a":{"b":{"api_key":"[REDACTED]"}}}`},
+		{"path only at its place", `{"data":{"object":{"email":"a@b.c"}},"email":"kept"}`, `{"data":{"object":{"email":"[REDACTED]"}},"email":"kept"}`},
+		{"path through arrays", `{"items":[{"owner":"o1"},{"owner":"o2","id":1}]}`, `{"items":[{"owner":"[REDACTED]"},{"id":1,"owner":"[REDACTED]"}]}`},
+		{"whole subtree", `{"password":{"old":"a","new":"b"}}`, `{"password":"[REDACTED]"}`},
+		{"numbers and html untouched", `{"amount":12345678901234567890,"note":"<b>&</b>"}`, `{"amount":12345678901234567890,"note":"<b>&</b>"}`},
+		{"not an object", `[1,"password"]`, `[1,"password"]`},
+		{"suffix pattern", `{"signing_pin":"1","pin":"2"}`, `{"pin":"2","signing_pin":"[REDACTED]"}`},
+		{"dashes as underscores", `{"Api-Key":"k"}`, `{"Api-Key":"[REDACTED]"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rd.Apply([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := rd.Apply([]byte(`{`)); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
+
+// A payment_intent.succeeded event as Stripe sends it, trimmed.
+const stripePaymentIntentSucceeded = `{
+  "id": "evt_3PqL2sLkdIwHu7ix0m8TfNqA",
+  "object": "event",
+  "api_version": "2024-06-20",
+  "created": 1724235617,
+  "data": {
+    "object": {
+      "id": "pi_3PqL2sLkdIwHu7ix0tb1lKzY",
+      "object": "payment_intent",
+      "amount": 2000,
+      "amount_received": 2000,
+      "capture_method": "automatic_async",
+      "client_secret": "pi_3PqL2sLkdIwHu7ix0tb1lKzY_secret_vQ8yTfRzb7H6kkx3nZyBqLd0M",
+      "confirmation_method": "automatic",
+      "created": 1724235614,
+      "currency": "usd",
+      "customer": "cus_QhkX4H1rS9ZcTb",
+      "latest_charge": "ch_3PqL2sLkdIwHu7ix0y3lqXRZ",
+      "livemode": false,
+      "metadata": {"order_id": "6735"},
+      "payment_method": "pm_1PqL2rLkdIwHu7ixe2pNcYvF",
+      "payment_method_options": {"card": {"request_three_d_secure": "automatic"}},
+      "payment_method_types": ["card"],
+      "status": "succeeded"
+    }
+  },
+  "livemode": false,
+  "pending_webhooks": 1,
+  "request": {"id": "req_8ZrX0fzWJzr1Ku", "idempotency_key": "3a6c2f0e-4d4b-4a8e-9d6a-0c6f9a1b2e7d"},
+  "type": "payment_intent.succeeded"
+}`
+
+func TestDefaultRedactFields_StripePaymentIntent(t *testing.T) {
+	got, err := Redaction{Fields: DefaultRedactFields}.Apply([]byte(stripePaymentIntentSucceeded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "_secret_vQ8y") || strings.Contains(string(got), "3a6c2f0e") {
+		t.Fatalf("secret left in %s", got)
+	}
+
+	var ev struct {
+		Data struct {
+			Object map[string]any `json:"object"`
+		} `json:"data"`
+		Request map[string]any `json:"request"`
+	}
+	if err := json.Unmarshal(got, &ev); err != nil {
+		t.Fatal(err)
+	}
+	pi := ev.Data.Object
+	if pi["client_secret"] != Redacted || ev.Request["idempotency_key"] != Redacted {
+		t.Fatalf("expected client_secret and idempotency_key redacted, got %s", got)
+	}
+	for field, want := range map[string]any{
+		"id":             "pi_3PqL2sLkdIwHu7ix0tb1lKzY",
+		"customer":       "cus_QhkX4H1rS9ZcTb",
+		"payment_method": "pm_1PqL2rLkdIwHu7ixe2pNcYvF",
+		"status":         "succeeded",
+		"currency":       "usd",
+	} {
+		if pi[field] != want {
+			t.Fatalf("%s: expected %v kept, got %v", field, want, pi[field])
+		}
+	}
+	if ev.Request["id"] != "req_8ZrX0fzWJzr1Ku" {
+		t.Fatalf("expected the request id kept, got %v", ev.Request["id"])
+	}
+}
@@ -8,6 +8,13 @@ import (
 )
 
 type EventRepository interface {
-	InsertReceived(ctx context.Context, id string, eventType string, payload json.RawMessage) (bool, error)
-	GetByID(ctx context.Context, id string) (model.Event, error)
+	// orderingKey is "" for events that are not ordered; origin is handed
+	// to the worker when the event is claimed.
+	InsertReceived(ctx context.Context, provider string, id string, eventType string, orderingKey string, payload json.RawMessage, origin model.Origin) (bool, error)
+	// InsertQuarantined stores an event that failed its schema as
+	// quarantined, with reason as its last_error.
+	InsertQuarantined(ctx context.Context, provider string, id string, eventType string, orderingKey string, payload json.RawMessage, reason string, origin model.Origin) (bool, error)
+	// GetByID looks the event up by ID and, unless provider is "", its
+	// provider. An ID that several providers used is ErrAmbiguousEventID.
+	GetByID(ctx context.Context, provider string, id string) (model.Event, error)
 }
@@ -0,0 +1,61 @@
+package task
+
+import "errors"
+
+// ErrDeadLettered wraps the processing error of an event's last attempt.
+var ErrDeadLettered = errors.New("event dead-lettered")
+
+// ErrPermanent marks processing errors that retrying cannot fix; see
+// Permanent.
+var ErrPermanent = errors.New("permanent failure")
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Is(target error) bool { return target == ErrPermanent }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so ProcessOnce dead-letters the event right away
+// instead of retrying it.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// TypeBackoff is implemented by processors, such as Router, that choose the
+// retry backoff per event type.
+type TypeBackoff interface {
+	BackoffFor(eventType string) (BackoffConfig, bool)
+}
+
+// RetryPolicy caps how often an event is attempted before it is
+// dead-lettered. Zero means no cap.
+type RetryPolicy struct {
+	MaxAttempts int
+	// ByType overrides MaxAttempts for individual event types.
+	ByType map[string]int
+}
+
+func (p RetryPolicy) MaxAttemptsFor(eventType string) int {
+	if n, ok := p.ByType[eventType]; ok {
+		return n
+	}
+	return p.MaxAttempts
+}
+
+// exhausted reports whether attempt was the event's last one.
+func (p RetryPolicy) exhausted(eventType string, attempt int) bool {
+	max := p.MaxAttemptsFor(eventType)
+	return max > 0 && attempt >= max
+}
+
+// exceeded reports whether attempt is past the cap, which only happens when
+// earlier attempts never finished.
+func (p RetryPolicy) exceeded(eventType string, attempt int) bool {
+	max := p.MaxAttemptsFor(eventType)
+	return max > 0 && attempt > max
+}
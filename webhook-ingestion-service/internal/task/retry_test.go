@@ -0,0 +1,115 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+// memWorkerRepo holds a single event and re-offers it until it is processed
+// or dead-lettered.
+type memWorkerRepo struct {
+	ev       ClaimedEvent
+	done     bool
+	dead     bool
+	failures int
+}
+
+func (m *memWorkerRepo) ClaimNextDue(ctx context.Context, c Claim) (ClaimedEvent, bool, error) {
+	if m.done || m.dead {
+		return ClaimedEvent{}, false, nil
+	}
+	m.ev.Attempts++
+	return m.ev, true, nil
+}
+
+func (m *memWorkerRepo) ClaimBatch(ctx context.Context, c Claim, n int) ([]ClaimedEvent, error) {
+	ev, ok, err := m.ClaimNextDue(ctx, c)
+	if !ok {
+		return nil, err
+	}
+	return []ClaimedEvent{ev}, nil
+}
+
+func (m *memWorkerRepo) MarkProcessedBatch(ctx context.Context, workerID string, keys []model.EventKey) ([]model.EventKey, error) {
+	return nil, m.MarkProcessed(ctx, keys[0], workerID)
+}
+
+func (m *memWorkerRepo) MarkFailedBatch(ctx context.Context, workerID string, events []FailedEvent) ([]model.EventKey, error) {
+	return nil, m.MarkFailed(ctx, events[0].Key, workerID, events[0].LastError, events[0].NextRetryAt)
+}
+
+func (m *memWorkerRepo) ExtendLease(ctx context.Context, key model.EventKey, workerID string, lease time.Duration) error {
+	return nil
+}
+
+func (m *memWorkerRepo) MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error {
+	m.done = true
+	return nil
+}
+
+func (m *memWorkerRepo) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
+	m.failures++
+	return nil
+}
+
+func (m *memWorkerRepo) ReleaseLease(ctx context.Context, key model.EventKey, workerID string) error {
+	return nil
+}
+
+func (m *memWorkerRepo) MarkDead(ctx context.Context, key model.EventKey, workerID string, lastErr string) error {
+	m.dead = true
+	return nil
+}
+
+type alwaysFails struct{}
+
+func (alwaysFails) Process(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+	return errors.New("downstream unavailable")
+}
+
+func TestProcessOnce_DeadLettersAfterMaxAttempts(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, ByType: map[string]int{"ping": 1, "invoice.paid": 0}}
+
+	cases := []struct {
+		eventType string
+		attempts  int // processed before giving up; 0 = never gives up
+	}{
+		{"payment_succeeded", 3},
+		{"ping", 1},
+		{"invoice.paid", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.eventType, func(t *testing.T) {
+			repo := &memWorkerRepo{ev: ClaimedEvent{ID: "evt_1", Type: tc.eventType, Payload: json.RawMessage(`{}`)}}
+			deps := WorkerDeps{Repo: repo, Processor: alwaysFails{}, Retry: policy}
+
+			for i := 1; i <= 5; i++ {
+				claimed, err := ProcessOnce(context.Background(), deps)
+				if !claimed {
+					if tc.attempts == 0 || i != tc.attempts+1 {
+						t.Fatalf("attempt %d: nothing claimed", i)
+					}
+					return
+				}
+				if err == nil {
+					t.Fatalf("attempt %d: expected error", i)
+				}
+				last := i == tc.attempts
+				if errors.Is(err, ErrDeadLettered) != last || repo.dead != last {
+					t.Fatalf("attempt %d: dead=%v err=%v", i, repo.dead, err)
+				}
+			}
+			if tc.attempts != 0 {
+				t.Fatalf("expected dead letter after %d attempts", tc.attempts)
+			}
+			if repo.failures != 5 {
+				t.Fatalf("expected 5 retries scheduled, got %d", repo.failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownEventType = errors.New("no processor for event type")
+	ErrInvalidPattern   = errors.New("pattern must be an event type, a prefix ending in \".*\" or \"*\"")
+	ErrDuplicatePattern = errors.New("pattern already registered")
+)
+
+// ProcessorFunc adapts a function to Processor.
+type ProcessorFunc func(ctx context.Context, eventID string, eventType string, payload json.RawMessage) error
+
+func (f ProcessorFunc) Process(ctx context.Context, eventID string, eventType string, payload json.RawMessage) error {
+	return f(ctx, eventID, eventType, payload)
+}
+
+// UnknownPolicy decides what happens to events no route matches.
+type UnknownPolicy string
+
+const (
+	// UnknownSkip marks the event processed without doing anything.
+	UnknownSkip UnknownPolicy = "skip"
+	// UnknownFail fails the attempt, so the event is retried like any
+	// other failure until a processor is registered or attempts run out.
+	UnknownFail UnknownPolicy = "fail"
+	// UnknownDeadLetter dead-letters the event on its first attempt.
+	UnknownDeadLetter UnknownPolicy = "dead"
+)
+
+func ParseUnknownPolicy(s string) (UnknownPolicy, error) {
+	switch p := UnknownPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case UnknownSkip, UnknownFail, UnknownDeadLetter:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown-type policy must be skip, fail or dead, got %q", s)
+}
+
+// RouteOptions tune a route; zero values mean no timeout and the worker's
+// default backoff. How many events of a type run at once is up to the pool,
+// see PoolConfig.TypeLimits, which leaves the surplus queued.
+type RouteOptions struct {
+	// Timeout bounds a single Process call.
+	Timeout time.Duration
+	// Backoff replaces the worker's backoff for matching events.
+	Backoff *BackoffConfig
+}
+
+type route struct {
+	pattern   string
+	processor Processor
+	opts      RouteOptions
+}
+
+// Router is a Processor that dispatches on the event type. Patterns are
+// either an exact type ("payment.succeeded"), a prefix ("payment.*", which
+// matches "payment.succeeded" and "payment.refund.created" but not
+// "payment") or "*". An exact match wins over prefixes, and the longest
+// prefix wins over shorter ones and "*".
+type Router struct {
+	unknown UnknownPolicy
+
+	mu       sync.RWMutex
+	exact    map[string]*route
+	prefixes []*route // longest first
+	fallback *route
+}
+
+func NewRouter(unknown UnknownPolicy) *Router {
+	if unknown == "" {
+		unknown = UnknownSkip
+	}
+	return &Router{unknown: unknown, exact: map[string]*route{}}
+}
+
+// Handle registers p for pattern. It is meant to be called before the
+// worker starts.
+func (r *Router) Handle(pattern string, p Processor, opts RouteOptions) error {
+	pattern = strings.TrimSpace(pattern)
+	prefix, isPrefix := strings.CutSuffix(pattern, "*")
+	if pattern == "" || strings.Contains(prefix, "*") || (isPrefix && prefix != "" && !strings.HasSuffix(prefix, ".")) {
+		return fmt.Errorf("%w: %q", ErrInvalidPattern, pattern)
+	}
+
+	rt := &route{pattern: pattern, processor: p, opts: opts}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case pattern == "*":
+		if r.fallback != nil {
+			return fmt.Errorf("%w: %q", ErrDuplicatePattern, pattern)
+		}
+		r.fallback = rt
+	case isPrefix:
+		for _, existing := range r.prefixes {
+			if existing.pattern == pattern {
+				return fmt.Errorf("%w: %q", ErrDuplicatePattern, pattern)
+			}
+		}
+		r.prefixes = append(r.prefixes, rt)
+		sort.SliceStable(r.prefixes, func(i, j int) bool {
+			return len(r.prefixes[i].pattern) > len(r.prefixes[j].pattern)
+		})
+	default:
+		if _, ok := r.exact[pattern]; ok {
+			return fmt.Errorf("%w: %q", ErrDuplicatePattern, pattern)
+		}
+		r.exact[pattern] = rt
+	}
+	return nil
+}
+
+func (r *Router) match(eventType string) *route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if rt, ok := r.exact[eventType]; ok {
+		return rt
+	}
+	for _, rt := range r.prefixes {
+		if strings.HasPrefix(eventType, strings.TrimSuffix(rt.pattern, "*")) {
+			return rt
+		}
+	}
+	return r.fallback
+}
+
+func (r *Router) Process(ctx context.Context, eventID string, eventType string, payload json.RawMessage) error {
+	rt := r.match(eventType)
+	if rt == nil {
+		switch r.unknown {
+		case UnknownFail:
+			return fmt.Errorf("%w %q", ErrUnknownEventType, eventType)
+		case UnknownDeadLetter:
+			return Permanent(fmt.Errorf("%w %q", ErrUnknownEventType, eventType))
+		}
+		return nil
+	}
+
+	if rt.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rt.opts.Timeout)
+		defer cancel()
+	}
+	return rt.processor.Process(ctx, eventID, eventType, payload)
+}
+
+// BackoffFor returns the backoff of the route matching eventType, if it sets
+// one.
+func (r *Router) BackoffFor(eventType string) (BackoffConfig, bool) {
+	rt := r.match(eventType)
+	if rt == nil || rt.opts.Backoff == nil {
+		return BackoffConfig{}, false
+	}
+	return *rt.opts.Backoff, true
+}
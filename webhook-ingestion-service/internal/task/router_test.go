@@ -0,0 +1,147 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+func recordRoute(t *testing.T, r *Router, pattern string, got *string) {
+	t.Helper()
+	p := ProcessorFunc(func(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+		*got = pattern
+		return nil
+	})
+	if err := r.Handle(pattern, p, RouteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouter_Dispatch(t *testing.T) {
+	r := NewRouter(UnknownFail)
+	var got string
+	for _, pattern := range []string{"payment.*", "payment.refund.*", "payment.succeeded", "*"} {
+		recordRoute(t, r, pattern, &got)
+	}
+
+	cases := map[string]string{
+		"payment.succeeded":      "payment.succeeded",
+		"payment.failed":         "payment.*",
+		"payment.refund.created": "payment.refund.*",
+		"payment":                "*",
+		"invoice.paid":           "*",
+	}
+	for eventType, want := range cases {
+		got = ""
+		if err := r.Process(context.Background(), "evt_1", eventType, nil); err != nil {
+			t.Fatalf("%s: %v", eventType, err)
+		}
+		if got != want {
+			t.Fatalf("%s: expected route %q, got %q", eventType, want, got)
+		}
+	}
+
+	for _, pattern := range []string{"", "pay*", "a.*.b", "**"} {
+		if err := r.Handle(pattern, NoopProcessor{}, RouteOptions{}); !errors.Is(err, ErrInvalidPattern) {
+			t.Fatalf("%q: expected ErrInvalidPattern, got %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{"payment.*", "payment.succeeded", "*"} {
+		if err := r.Handle(pattern, NoopProcessor{}, RouteOptions{}); !errors.Is(err, ErrDuplicatePattern) {
+			t.Fatalf("%q: expected ErrDuplicatePattern, got %v", pattern, err)
+		}
+	}
+}
+
+func TestRouter_UnknownPolicy(t *testing.T) {
+	cases := []struct {
+		policy    UnknownPolicy
+		wantErr   bool
+		permanent bool
+	}{
+		{UnknownSkip, false, false},
+		{UnknownFail, true, false},
+		{UnknownDeadLetter, true, true},
+	}
+	for _, tc := range cases {
+		r := NewRouter(tc.policy)
+		err := r.Process(context.Background(), "evt_1", "mystery", nil)
+		if (err != nil) != tc.wantErr || errors.Is(err, ErrPermanent) != tc.permanent {
+			t.Fatalf("%s: unexpected error %v", tc.policy, err)
+		}
+		if tc.wantErr && !errors.Is(err, ErrUnknownEventType) {
+			t.Fatalf("%s: expected ErrUnknownEventType, got %v", tc.policy, err)
+		}
+	}
+
+	// Through the worker a dead-letter policy skips the retries.
+	repo := &memWorkerRepo{ev: ClaimedEvent{ID: "evt_1", Type: "mystery"}}
+	deps := WorkerDeps{Repo: repo, Processor: NewRouter(UnknownDeadLetter), Retry: RetryPolicy{MaxAttempts: 5}}
+	if _, err := ProcessOnce(context.Background(), deps); !errors.Is(err, ErrDeadLettered) || !repo.dead {
+		t.Fatalf("expected dead letter on first attempt, got %v", err)
+	}
+}
+
+func TestRouter_Timeout(t *testing.T) {
+	r := NewRouter(UnknownFail)
+
+	slow := ProcessorFunc(func(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := r.Handle("slow", slow, RouteOptions{Timeout: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Process(context.Background(), "evt_1", "slow", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestProcessOnce_UsesRouteBackoff(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(UnknownSkip)
+	fixed := BackoffConfig{BaseDelay: time.Hour, MaxDelay: time.Hour}
+	if err := r.Handle("slow.*", ProcessorFunc(func(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+		return errors.New("try later")
+	}), RouteOptions{Backoff: &fixed}); err != nil {
+		t.Fatal(err)
+	}
+
+	var next time.Time
+	repo := &backoffRepo{memWorkerRepo: memWorkerRepo{ev: ClaimedEvent{ID: "evt_1", Type: "slow.job"}}, next: &next}
+	deps := WorkerDeps{
+		Repo:      repo,
+		Processor: r,
+		Backoff:   BackoffConfig{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		RNG:       rand.New(rand.NewSource(1)),
+		Now:       func() time.Time { return now },
+	}
+	if _, err := ProcessOnce(context.Background(), deps); err == nil {
+		t.Fatal("expected error")
+	}
+	// The worker's own backoff would retry within a millisecond.
+	if d := next.Sub(now); d <= time.Millisecond || d > time.Hour {
+		t.Fatalf("expected the route's backoff, retry in %s", d)
+	}
+	if _, ok := r.BackoffFor("slow.job"); !ok {
+		t.Fatal("expected route backoff")
+	}
+	if _, ok := r.BackoffFor("other"); ok {
+		t.Fatal("unexpected backoff for unrouted type")
+	}
+}
+
+type backoffRepo struct {
+	memWorkerRepo
+	next *time.Time
+}
+
+func (b *backoffRepo) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
+	*b.next = nextRetryAt
+	return nil
+}
@@ -0,0 +1,171 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// ErrSchemaMismatch means an event's data does not match the schema of its
+// type; the *SchemaError carrying it lists the offending fields.
+var ErrSchemaMismatch = errors.New("event data does not match its schema")
+
+// InvalidPayloadPolicy decides what happens to events failing their schema.
+type InvalidPayloadPolicy string
+
+const (
+	// InvalidReject refuses the delivery with 400 and the field errors.
+	InvalidReject InvalidPayloadPolicy = "reject"
+	// InvalidQuarantine stores the event as quarantined: it is not processed
+	// until replayed.
+	InvalidQuarantine InvalidPayloadPolicy = "quarantine"
+)
+
+func ParseInvalidPayloadPolicy(s string) (InvalidPayloadPolicy, error) {
+	switch p := InvalidPayloadPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case InvalidReject, InvalidQuarantine:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid-payload policy must be reject or quarantine, got %q", s)
+}
+
+// FieldError is one schema violation; Path is a JSON pointer into the
+// payload, e.g. "/data/amount".
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type SchemaError struct {
+	Type   string
+	Fields []FieldError
+}
+
+func (e *SchemaError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Path + ": " + f.Message
+	}
+	return fmt.Sprintf("%s %s: %s", e.Type, ErrSchemaMismatch, strings.Join(parts, "; "))
+}
+
+func (e *SchemaError) Unwrap() error { return ErrSchemaMismatch }
+
+// Schemas validates the "data" member of payloads against a JSON Schema per
+// event type. Types without a schema are not validated.
+type Schemas struct {
+	byType   map[string]*jsonschema.Schema
+	policy   InvalidPayloadPolicy
+	byPolicy map[string]InvalidPayloadPolicy
+}
+
+// LoadSchemas compiles every "<event type>.json" file in dir, e.g.
+// "invoice.paid.json". Schemas may $ref each other by file name. Invalid
+// events are rejected unless SetPolicy says otherwise.
+func LoadSchemas(dir string) (*Schemas, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	c := jsonschema.NewCompiler()
+	s := &Schemas{byType: map[string]*jsonschema.Schema{}, policy: InvalidReject}
+	for _, e := range entries {
+		eventType, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok || eventType == "" {
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sch, err := c.Compile(path)
+		if err != nil {
+			return nil, fmt.Errorf("schema for %s: %w", eventType, err)
+		}
+		s.byType[eventType] = sch
+	}
+	return s, nil
+}
+
+// SetPolicy sets the policy for invalid events, overridden per type by
+// byType.
+func (s *Schemas) SetPolicy(policy InvalidPayloadPolicy, byType map[string]InvalidPayloadPolicy) {
+	s.policy = policy
+	s.byPolicy = byType
+}
+
+// Types lists the event types with a schema.
+func (s *Schemas) Types() []string {
+	types := make([]string, 0, len(s.byType))
+	for t := range s.byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// PolicyFor returns what happens to invalid events of eventType.
+func (s *Schemas) PolicyFor(eventType string) InvalidPayloadPolicy {
+	if p, ok := s.byPolicy[eventType]; ok {
+		return p
+	}
+	return s.policy
+}
+
+// Validate checks the data member of payload against the schema of
+// eventType and returns a *SchemaError listing the violations. A missing
+// data member is validated as null.
+func (s *Schemas) Validate(eventType string, payload []byte) error {
+	sch, ok := s.byType[eventType]
+	if !ok {
+		return nil
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return &SchemaError{Type: eventType, Fields: []FieldError{{Path: "", Message: "payload is not a JSON object"}}}
+	}
+	data := envelope.Data
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+	v, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	var ve *jsonschema.ValidationError
+	if err := sch.Validate(v); !errors.As(err, &ve) {
+		return err
+	}
+	return &SchemaError{Type: eventType, Fields: fieldErrors(ve)}
+}
+
+var schemaMessages = message.NewPrinter(language.English)
+
+// fieldErrors flattens a validation error to its leaves, which name the
+// fields at fault; the others only group them.
+func fieldErrors(ve *jsonschema.ValidationError) []FieldError {
+	if len(ve.Causes) > 0 {
+		var out []FieldError
+		for _, c := range ve.Causes {
+			out = append(out, fieldErrors(c)...)
+		}
+		return out
+	}
+	path := "/data"
+	for _, tok := range ve.InstanceLocation {
+		path += "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(tok)
+	}
+	return []FieldError{{Path: path, Message: ve.ErrorKind.LocalizedString(schemaMessages)}}
+}
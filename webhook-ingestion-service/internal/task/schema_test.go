@@ -0,0 +1,96 @@
+package task
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeSchemas(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSchemas_Validate(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"invoice.paid.json": `{
+			"type": "object",
+			"required": ["invoice_id", "amount"],
+			"properties": {
+				"invoice_id": {"type": "string"},
+				"amount": {"$ref": "defs/money.json"},
+				"lines": {"type": "array", "items": {"type": "object", "required": ["sku"]}}
+			}
+		}`,
+		"defs/money.json": `{"type": "integer", "minimum": 0}`,
+		"README.md":       `not a schema`,
+	})
+	schemas, err := LoadSchemas(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schemas.Types(); !slices.Equal(got, []string{"invoice.paid"}) {
+		t.Fatalf("expected only invoice.paid, got %v", got)
+	}
+
+	if err := schemas.Validate("invoice.paid", []byte(`{"data":{"invoice_id":"in_1","amount":5}}`)); err != nil {
+		t.Fatalf("valid data: %v", err)
+	}
+	if err := schemas.Validate("push", []byte(`{"anything":true}`)); err != nil {
+		t.Fatalf("type without schema: %v", err)
+	}
+
+	err = schemas.Validate("invoice.paid", []byte(`{"data":{"amount":-1,"lines":[{"sku":"a"},{}]}}`))
+	var se *SchemaError
+	if !errors.As(err, &se) || !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	var paths []string
+	for _, f := range se.Fields {
+		paths = append(paths, f.Path)
+	}
+	slices.Sort(paths)
+	if want := []string{"/data", "/data/amount", "/data/lines/1"}; !slices.Equal(paths, want) {
+		t.Fatalf("expected errors at %v, got %+v", want, se.Fields)
+	}
+
+	if err := schemas.Validate("invoice.paid", []byte(`{"type":"invoice.paid"}`)); !errors.As(err, &se) || se.Fields[0].Path != "/data" {
+		t.Fatalf("missing data: expected an error at /data, got %v", err)
+	}
+}
+
+func TestSchemas_PolicyAndBrokenSchemas(t *testing.T) {
+	schemas, err := LoadSchemas(writeSchemas(t, map[string]string{"a.json": `{}`}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := schemas.PolicyFor("a"); p != InvalidReject {
+		t.Fatalf("expected reject by default, got %s", p)
+	}
+	schemas.SetPolicy(InvalidQuarantine, map[string]InvalidPayloadPolicy{"a": InvalidReject})
+	if schemas.PolicyFor("a") != InvalidReject || schemas.PolicyFor("b") != InvalidQuarantine {
+		t.Fatal("per-type policy not applied")
+	}
+	if _, err := ParseInvalidPayloadPolicy("drop"); err == nil {
+		t.Fatal("expected an error for an unknown policy")
+	}
+
+	if _, err := LoadSchemas(writeSchemas(t, map[string]string{"bad.json": `{"type": 5}`})); err == nil {
+		t.Fatal("expected an error for an invalid schema")
+	}
+	if _, err := LoadSchemas(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,187 @@
+package task
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"webhook-ingestion-service/internal/model"
+)
+
+var (
+	ErrInvalidSecret    = errors.New("secret must be at least 16 characters")
+	ErrInvalidSecretID  = errors.New("secret id must be 1-64 letters, digits, '-' or '_'")
+	ErrReservedSecretID = errors.New(`secret id "env" is reserved for the configured secret`)
+	ErrInvalidWindow    = errors.New("not_after must be after not_before")
+)
+
+// EnvSecretID names the provider secret configured in the environment among
+// the keys tried for a delivery; stored secrets cannot take it.
+const EnvSecretID = "env"
+
+const minSecretLen = 16
+
+// How often a secret's last_used_at is written at most; enough to tell
+// whether an old secret is still in use before retiring it.
+const touchInterval = time.Minute
+
+var secretIDRE = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type SecretRepository interface {
+	InsertSecret(ctx context.Context, s model.WebhookSecret) (model.WebhookSecret, error)
+	ListSecrets(ctx context.Context, provider string) ([]model.WebhookSecret, error)
+	RetireSecret(ctx context.Context, provider, id string, at time.Time) (model.WebhookSecret, error)
+	TouchSecret(ctx context.Context, id string, at time.Time) error
+}
+
+// SecretService manages the signing secrets stored per provider. Keys are
+// cached for cacheTTL so verifying a webhook does not cost a query; changes
+// made through this instance take effect immediately, others within the TTL.
+type SecretService struct {
+	repo     SecretRepository
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	cache   map[string]cachedSecrets
+	touched map[string]time.Time
+	// gens counts invalidations per provider, so a load that raced with
+	// one is not cached.
+	gens map[string]uint64
+}
+
+type cachedSecrets struct {
+	secrets []model.WebhookSecret
+	loaded  time.Time
+}
+
+func NewSecretService(repo SecretRepository, cacheTTL time.Duration) *SecretService {
+	return &SecretService{
+		repo:     repo,
+		cacheTTL: cacheTTL,
+		now:      func() time.Time { return time.Now().UTC() },
+		cache:    map[string]cachedSecrets{},
+		touched:  map[string]time.Time{},
+		gens:     map[string]uint64{},
+	}
+}
+
+type NewSecret struct {
+	Provider  string
+	ID        string // optional; generated when empty
+	Secret    string
+	NotBefore time.Time // zero: now
+	NotAfter  *time.Time
+}
+
+func (s *SecretService) Add(ctx context.Context, in NewSecret) (model.WebhookSecret, error) {
+	if len(in.Secret) < minSecretLen {
+		return model.WebhookSecret{}, ErrInvalidSecret
+	}
+	in.ID = strings.TrimSpace(in.ID)
+	if in.ID == "" {
+		in.ID = newSecretID()
+	}
+	if !secretIDRE.MatchString(in.ID) {
+		return model.WebhookSecret{}, ErrInvalidSecretID
+	}
+	if strings.EqualFold(in.ID, EnvSecretID) {
+		return model.WebhookSecret{}, ErrReservedSecretID
+	}
+	if in.NotBefore.IsZero() {
+		in.NotBefore = s.now()
+	}
+	if in.NotAfter != nil && !in.NotAfter.After(in.NotBefore) {
+		return model.WebhookSecret{}, ErrInvalidWindow
+	}
+
+	created, err := s.repo.InsertSecret(ctx, model.WebhookSecret{
+		ID:        in.ID,
+		Provider:  in.Provider,
+		Secret:    in.Secret,
+		NotBefore: in.NotBefore,
+		NotAfter:  in.NotAfter,
+	})
+	if err != nil {
+		return model.WebhookSecret{}, err
+	}
+	s.invalidate(in.Provider)
+	return created, nil
+}
+
+// Retire ends a secret's validity at `at` (zero: now). A future `at` leaves
+// a grace period for deliveries still signed with it.
+func (s *SecretService) Retire(ctx context.Context, provider, id string, at time.Time) (model.WebhookSecret, error) {
+	if at.IsZero() {
+		at = s.now()
+	}
+	retired, err := s.repo.RetireSecret(ctx, provider, id, at)
+	if err != nil {
+		return model.WebhookSecret{}, err
+	}
+	s.invalidate(provider)
+	return retired, nil
+}
+
+// List returns every stored secret of the provider, including retired ones.
+func (s *SecretService) List(ctx context.Context, provider string) ([]model.WebhookSecret, error) {
+	return s.repo.ListSecrets(ctx, provider)
+}
+
+// Keys is List served from the cache, for verifying deliveries.
+func (s *SecretService) Keys(ctx context.Context, provider string) ([]model.WebhookSecret, error) {
+	now := s.now()
+	s.mu.Lock()
+	c, ok := s.cache[provider]
+	gen := s.gens[provider]
+	s.mu.Unlock()
+	if ok && now.Sub(c.loaded) < s.cacheTTL {
+		return c.secrets, nil
+	}
+
+	secrets, err := s.repo.ListSecrets(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	// An Add or Retire since the load may not be in secrets; the next call
+	// loads again.
+	if s.gens[provider] == gen {
+		s.cache[provider] = cachedSecrets{secrets: secrets, loaded: now}
+	}
+	s.mu.Unlock()
+	return secrets, nil
+}
+
+// MarkUsed records that a delivery was signed with the secret, at most once
+// per touchInterval.
+func (s *SecretService) MarkUsed(ctx context.Context, id string) error {
+	now := s.now()
+	s.mu.Lock()
+	if last, ok := s.touched[id]; ok && now.Sub(last) < touchInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.touched[id] = now
+	s.mu.Unlock()
+
+	return s.repo.TouchSecret(ctx, id, now)
+}
+
+func (s *SecretService) invalidate(provider string) {
+	s.mu.Lock()
+	delete(s.cache, provider)
+	s.gens[provider]++
+	s.mu.Unlock()
+}
+
+func newSecretID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "key_" + hex.EncodeToString(b)
+}
@@ -4,40 +4,141 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/logging"
+	"webhook-ingestion-service/internal/observability/metrics"
+	"webhook-ingestion-service/internal/observability/tracing"
 )
 
 type Service struct {
-	events EventRepository
+	events    EventRepository
+	ordering  OrderingKeys
+	redaction Redaction
+	schemas   *Schemas
+	logger    *slog.Logger
 }
 
 func NewService(events EventRepository) *Service {
-	return &Service{events: events}
+	return &Service{events: events, redaction: Redaction{Fields: DefaultRedactFields}, logger: slog.Default()}
+}
+
+// SetLogger replaces slog.Default. Records are logged with the caller's
+// context and so carry its request ID.
+func (s *Service) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetOrderingKeys configures where events keep their ordering key. It is
+// meant to be called before the service ingests webhooks.
+func (s *Service) SetOrderingKeys(keys OrderingKeys) {
+	s.ordering = keys
+}
+
+// SetRedaction replaces the redaction applied by RedactedPayload, which
+// defaults to DefaultRedactFields.
+func (s *Service) SetRedaction(rd Redaction) {
+	s.redaction = rd
+}
+
+// SetSchemas makes ingestion validate event data; see Schemas.
+func (s *Service) SetSchemas(schemas *Schemas) {
+	s.schemas = schemas
 }
 
 var ErrInvalidEvent = errors.New("invalid event payload")
 
-type IncomingEvent struct {
-	Type string          `json:"type"`
-	Data json.RawMessage `json:"data"`
+// ErrAmbiguousEventID means an event was looked up by ID alone and several
+// providers have used that ID.
+var ErrAmbiguousEventID = errors.New("event id is used by several providers; pass provider")
+
+// Webhook is a verified delivery, with the event ID and type already taken
+// from wherever its provider puts them.
+type Webhook struct {
+	Provider string
+	ID       string
+	Type     string
+	Payload  []byte
+	// RequestID is stored with the event, as is the trace context of ctx.
+	RequestID string
 }
 
-func (s *Service) IngestWebhook(ctx context.Context, id string, rawBody []byte) (created bool, err error) {
-	var in IncomingEvent
-	if err := json.Unmarshal(rawBody, &in); err != nil {
+// IngestWebhook stores the delivery once; created is false for duplicates.
+// Data failing its schema is either rejected with a *SchemaError or stored
+// as quarantined, depending on the policy for its type.
+func (s *Service) IngestWebhook(ctx context.Context, wh Webhook) (created bool, err error) {
+	origin := model.Origin{RequestID: wh.RequestID, TraceContext: tracing.Inject(ctx)}
+
+	wh.ID = strings.TrimSpace(wh.ID)
+	wh.Type = strings.TrimSpace(wh.Type)
+	if wh.ID == "" || wh.Type == "" || !json.Valid(wh.Payload) {
 		return false, ErrInvalidEvent
 	}
-	in.Type = strings.TrimSpace(in.Type)
-	if in.Type == "" {
-		return false, ErrInvalidEvent
+
+	// store full payload (rawBody) as JSONB
+	key := s.ordering.Key(wh.Type, wh.Payload)
+	if s.schemas != nil {
+		if err := s.schemas.Validate(wh.Type, wh.Payload); err != nil {
+			var se *SchemaError
+			if !errors.As(err, &se) || s.schemas.PolicyFor(wh.Type) == InvalidReject {
+				return false, err
+			}
+			ctx, span := startInsert(ctx, wh)
+			span.SetAttributes(attribute.Bool("webhook.event.quarantined", true))
+			created, err := s.events.InsertQuarantined(ctx, wh.Provider, wh.ID, wh.Type, key, json.RawMessage(wh.Payload), se.Error(), origin)
+			endInsert(span, created, err)
+			if created {
+				metrics.QuarantinedTotal.WithLabelValues(wh.Provider, wh.Type).Inc()
+				s.logger.WarnContext(ctx, "event quarantined", logging.KeyEventType, wh.Type, "violations", se.Error())
+			} else {
+				s.logInsert(ctx, wh, created, err)
+			}
+			return created, err
+		}
+	}
+	ctx, span := startInsert(ctx, wh)
+	created, err = s.events.InsertReceived(ctx, wh.Provider, wh.ID, wh.Type, key, json.RawMessage(wh.Payload), origin)
+	endInsert(span, created, err)
+	s.logInsert(ctx, wh, created, err)
+	return created, err
+}
+
+func (s *Service) logInsert(ctx context.Context, wh Webhook, created bool, err error) {
+	switch {
+	case err != nil:
+		s.logger.ErrorContext(ctx, "store event", logging.KeyEventType, wh.Type, logging.KeyError, err)
+	case created:
+		s.logger.InfoContext(ctx, "event stored", logging.KeyEventType, wh.Type)
+	default:
+		s.logger.InfoContext(ctx, "duplicate event", logging.KeyEventType, wh.Type)
 	}
+}
+
+func startInsert(ctx context.Context, wh Webhook) (context.Context, trace.Span) {
+	return tracing.Tracer.Start(ctx, "event.insert", trace.WithAttributes(
+		tracing.AttrEventID.String(wh.ID),
+		tracing.AttrEventType.String(wh.Type),
+		tracing.AttrProvider.String(wh.Provider),
+	))
+}
+
+func endInsert(span trace.Span, created bool, err error) {
+	span.SetAttributes(attribute.Bool("webhook.event.duplicate", err == nil && !created))
+	tracing.End(span, err)
+}
 
-	// store full payload (rawBody) or structured payload; here: full body as JSONB
-	return s.events.InsertReceived(ctx, id, in.Type, json.RawMessage(rawBody))
+// GetEvent looks an event up as EventRepository.GetByID does.
+func (s *Service) GetEvent(ctx context.Context, provider string, id string) (model.Event, error) {
+	return s.events.GetByID(ctx, strings.TrimSpace(provider), id)
 }
 
-func (s *Service) GetEvent(ctx context.Context, id string) (model.Event, error) {
-	return s.events.GetByID(ctx, id)
+// RedactedPayload returns the stored payload of ev, as loaded by GetEvent,
+// with sensitive fields hidden.
+func (s *Service) RedactedPayload(ev model.Event) (json.RawMessage, error) {
+	return s.redaction.Apply(ev.Payload)
 }
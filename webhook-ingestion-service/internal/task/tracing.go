@@ -0,0 +1,101 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/tracing"
+)
+
+// traceClaim records a claim that returned events as a span after the fact,
+// so polls that find nothing leave no trace, and notes it on the events for
+// their process spans to link to.
+func traceClaim(ctx context.Context, start time.Time, events []ClaimedEvent) {
+	if len(events) == 0 {
+		return
+	}
+	_, span := tracing.Tracer.Start(ctx, "event.claim",
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attribute.Int("webhook.claim.size", len(events))),
+	)
+	span.End()
+	for i := range events {
+		events[i].claimSpan = span.SpanContext()
+	}
+}
+
+// startProcess starts the span of one attempt. It is a new trace: the
+// delivery that stored the event has long been answered, so the span links
+// to its ingest span, and to the claim, instead of continuing that trace.
+func startProcess(ctx context.Context, ev ClaimedEvent) (context.Context, trace.Span) {
+	var links []trace.Link
+	if link, ok := tracing.LinkFrom(ev.Origin.TraceContext); ok {
+		link.Attributes = []attribute.KeyValue{attribute.String("webhook.link", "ingest")}
+		links = append(links, link)
+	}
+	if ev.claimSpan.IsValid() {
+		links = append(links, trace.Link{
+			SpanContext: ev.claimSpan,
+			Attributes:  []attribute.KeyValue{attribute.String("webhook.link", "claim")},
+		})
+	}
+	attrs := []attribute.KeyValue{
+		tracing.AttrEventID.String(ev.ID),
+		tracing.AttrProvider.String(ev.Provider),
+		tracing.AttrEventType.String(ev.Type),
+		tracing.AttrAttempt.Int(ev.Attempts),
+	}
+	if ev.Origin.RequestID != "" {
+		attrs = append(attrs, tracing.AttrRequestID.String(ev.Origin.RequestID))
+	}
+	return tracing.Tracer.Start(ctx, "event.process",
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// traced runs fn in a child span of ctx's span named name.
+func traced(ctx context.Context, name string, fn func(ctx context.Context) error, attrs ...attribute.KeyValue) error {
+	ctx, span := tracing.Tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	err := fn(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// tracedMarks records each way an attempt is written back as an event.mark
+// span; heartbeats are left out.
+type tracedMarks struct {
+	WorkerRepository
+}
+
+func (t tracedMarks) MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error {
+	return traced(ctx, "event.mark", func(ctx context.Context) error {
+		return t.WorkerRepository.MarkProcessed(ctx, key, workerID)
+	}, markStatus.String(outcomeProcessed))
+}
+
+func (t tracedMarks) MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error {
+	return traced(ctx, "event.mark", func(ctx context.Context) error {
+		return t.WorkerRepository.MarkFailed(ctx, key, workerID, lastErr, nextRetryAt)
+	}, markStatus.String(outcomeFailed), attribute.String("webhook.event.next_retry_at", nextRetryAt.UTC().Format(time.RFC3339)))
+}
+
+func (t tracedMarks) MarkDead(ctx context.Context, key model.EventKey, workerID string, lastErr string) error {
+	return traced(ctx, "event.mark", func(ctx context.Context) error {
+		return t.WorkerRepository.MarkDead(ctx, key, workerID, lastErr)
+	}, markStatus.String(outcomeDead))
+}
+
+func (t tracedMarks) ReleaseLease(ctx context.Context, key model.EventKey, workerID string) error {
+	return traced(ctx, "event.mark", func(ctx context.Context) error {
+		return t.WorkerRepository.ReleaseLease(ctx, key, workerID)
+	}, markStatus.String(outcomeReleased))
+}
+
+const markStatus = attribute.Key("webhook.event.mark")
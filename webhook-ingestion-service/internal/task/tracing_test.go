@@ -0,0 +1,98 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"webhook-ingestion-service/internal/model"
+	"webhook-ingestion-service/internal/observability/tracing"
+)
+
+var (
+	spanRecorder    = tracetest.NewSpanRecorder()
+	installRecorder sync.Once
+)
+
+// recordSpans installs a recording tracer provider, once per test binary
+// since tracing.Tracer only follows the first global provider, and returns
+// the spans ended so far.
+func recordSpans() func() []sdktrace.ReadOnlySpan {
+	installRecorder.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	seen := len(spanRecorder.Ended())
+	return func() []sdktrace.ReadOnlySpan {
+		return spanRecorder.Ended()[seen:]
+	}
+}
+
+func TestProcessOnce_ProcessSpanLinksToIngest(t *testing.T) {
+	spans := recordSpans()
+
+	ingestCtx, ingest := tracing.Tracer.Start(context.Background(), "POST /webhooks/{provider}")
+	origin := model.Origin{RequestID: "req-1", TraceContext: tracing.Inject(ingestCtx)}
+	ingest.End()
+
+	repo := &memWorkerRepo{ev: ClaimedEvent{ID: "evt_1", Type: "ping", Payload: json.RawMessage(`{}`), Origin: origin}}
+	deps := WorkerDeps{Repo: repo, Processor: NoopProcessor{}}
+	if _, err := ProcessOnce(context.Background(), deps); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans() {
+		byName[s.Name()] = s
+	}
+	process, claim := byName["event.process"], byName["event.claim"]
+	if process == nil || claim == nil {
+		t.Fatalf("spans: %v", byName)
+	}
+	if process.Parent().IsValid() || process.SpanContext().TraceID() == ingest.SpanContext().TraceID() {
+		t.Fatalf("process span should start a new trace, parent=%v", process.Parent())
+	}
+	linked := map[trace.SpanID]bool{}
+	for _, l := range process.Links() {
+		linked[l.SpanContext.SpanID()] = true
+	}
+	if !linked[ingest.SpanContext().SpanID()] || !linked[claim.SpanContext().SpanID()] {
+		t.Fatalf("links: %v", process.Links())
+	}
+	attrs := map[string]string{}
+	for _, kv := range process.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["webhook.request_id"] != "req-1" || attrs["webhook.event.id"] != "evt_1" || attrs["webhook.event.outcome"] != "processed" {
+		t.Fatalf("attributes: %v", attrs)
+	}
+	for _, name := range []string{"event.processor", "event.mark"} {
+		child := byName[name]
+		if child == nil || child.Parent().SpanID() != process.SpanContext().SpanID() {
+			t.Fatalf("%s should be a child of event.process", name)
+		}
+	}
+}
+
+func TestProcessOnce_UntracedEventHasNoIngestLink(t *testing.T) {
+	spans := recordSpans()
+
+	repo := &memWorkerRepo{ev: ClaimedEvent{ID: "evt_untraced", Type: "ping", Payload: json.RawMessage(`{}`)}}
+	if _, err := ProcessOnce(context.Background(), WorkerDeps{Repo: repo, Processor: NoopProcessor{}}); err != nil {
+		t.Fatal(err)
+	}
+	var process sdktrace.ReadOnlySpan
+	for _, s := range spans() {
+		if s.Name() == "event.process" {
+			process = s
+		}
+	}
+	if process == nil || len(process.Links()) != 1 {
+		t.Fatalf("expected only the claim link, got %v", process)
+	}
+}
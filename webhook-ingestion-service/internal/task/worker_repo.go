@@ -3,18 +3,69 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"webhook-ingestion-service/internal/model"
 )
 
+// ErrLeaseLost means the worker no longer holds the event: its lease expired
+// and the event was returned to the queue, possibly to another worker.
+var ErrLeaseLost = errors.New("lease lost")
+
+// Claim says who claims events and for how long.
+type Claim struct {
+	WorkerID string
+	Lease    time.Duration
+	// SkipTypes stay in the queue, e.g. because they are at their in-flight
+	// limit.
+	SkipTypes []string
+	// TypeCaps bounds how many events of a type ClaimBatch returns, e.g. the
+	// room left under an in-flight limit; the rest stay queued untouched.
+	TypeCaps map[string]int
+}
+
 type ClaimedEvent struct {
+	Provider string
 	ID       string
 	Type     string
 	Payload  json.RawMessage
 	Attempts int
+	Origin   model.Origin
+
+	claimSpan trace.SpanContext // set by traceClaim
+}
+
+func (e ClaimedEvent) Key() model.EventKey {
+	return model.EventKey{Provider: e.Provider, ID: e.ID}
+}
+
+// FailedEvent is one entry of MarkFailedBatch.
+type FailedEvent struct {
+	Key         model.EventKey
+	LastError   string
+	NextRetryAt time.Time
 }
 
+// WorkerRepository claims events on a lease. The Mark and ExtendLease calls
+// only apply while workerID still holds the lease and return ErrLeaseLost
+// otherwise.
 type WorkerRepository interface {
-	ClaimNextDue(ctx context.Context) (ClaimedEvent, bool, error)
-	MarkProcessed(ctx context.Context, id string) error
-	MarkFailed(ctx context.Context, id string, lastErr string, nextRetryAt time.Time) error
+	ClaimNextDue(ctx context.Context, c Claim) (ClaimedEvent, bool, error)
+	// ClaimBatch claims up to n due events at once, oldest first.
+	ClaimBatch(ctx context.Context, c Claim, n int) ([]ClaimedEvent, error)
+	ExtendLease(ctx context.Context, key model.EventKey, workerID string, lease time.Duration) error
+	MarkProcessed(ctx context.Context, key model.EventKey, workerID string) error
+	MarkFailed(ctx context.Context, key model.EventKey, workerID string, lastErr string, nextRetryAt time.Time) error
+	// The batch variants return the events whose lease was lost instead of
+	// ErrLeaseLost; the others are marked.
+	MarkProcessedBatch(ctx context.Context, workerID string, keys []model.EventKey) (lost []model.EventKey, err error)
+	MarkFailedBatch(ctx context.Context, workerID string, events []FailedEvent) (lost []model.EventKey, err error)
+	// MarkDead stops retrying the event; it stays dead until requeued.
+	MarkDead(ctx context.Context, key model.EventKey, workerID string, lastErr string) error
+	// ReleaseLease hands the event back to the queue, due now, without
+	// counting the attempt; used when a worker shuts down mid-processing.
+	ReleaseLease(ctx context.Context, key model.EventKey, workerID string) error
 }